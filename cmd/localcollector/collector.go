@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mailru/easyjson"
+	"github.com/signalfx/golib/trace"
+	traceformat "github.com/signalfx/golib/trace/format"
+
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+// defaultLimit is the default number of traces listed by /traces.
+const defaultLimit = 100
+
+// config holds the configuration of the collector.
+type config struct {
+	addr         string
+	maxTraces    int
+	file         string
+	forwardURL   string
+	forwardToken string
+}
+
+// collector receives spans and serves the recent traces.
+type collector struct {
+	zipkin    *zipkinserver.ZipkinServer
+	mux       *http.ServeMux
+	forwarder *forwarder // nil if not forwarding
+
+	mu   sync.Mutex // guards file
+	file *os.File   // nil if not persisting
+}
+
+func newCollector(cfg config) (*collector, error) {
+	c := &collector{mux: http.NewServeMux()}
+	if cfg.forwardURL != "" {
+		c.forwarder = newForwarder(cfg.forwardURL, cfg.forwardToken)
+	}
+	c.zipkin = zipkinserver.New(
+		zipkinserver.WithMaxTraces(cfg.maxTraces),
+		zipkinserver.WithRequestHook(c.received),
+	)
+	if cfg.file != "" {
+		if err := c.load(cfg.file); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(cfg.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		c.file = f
+	}
+	c.mux.Handle("/v1/trace", c.zipkin)
+	c.mux.HandleFunc("/traces", c.serveTraces)
+	c.mux.HandleFunc("/traces/", c.serveTrace)
+	return c, nil
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mux.ServeHTTP(w, r)
+}
+
+// Close stops forwarding spans, once the pending ones are sent, and closes
+// the file spans are persisted to.
+func (c *collector) Close() error {
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// received persists and forwards the spans of a received request.
+func (c *collector) received(req zipkinserver.Request) {
+	if len(req.Spans) == 0 {
+		return
+	}
+	if c.forwarder != nil {
+		c.forwarder.forward(req.Spans, req.Header.Get("X-SF-Token"))
+	}
+	if err := c.persist(req.Spans); err != nil {
+		log.Printf("localcollector: failed to persist spans: %v", err)
+	}
+}
+
+// persist appends spans to the file, as a line of JSON.
+func (c *collector) persist(spans traceformat.Trace) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	data, err := easyjson.Marshal(spans)
+	if err != nil {
+		return err
+	}
+	_, err = c.file.Write(append(data, '\n'))
+	return err
+}
+
+// load adds the spans persisted to the given file, if it exists.
+func (c *collector) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			var spans traceformat.Trace
+			if err := easyjson.Unmarshal(data, &spans); err != nil {
+				return fmt.Errorf("%s:%d: %v", path, line, err)
+			}
+			c.zipkin.AddSpans(spans)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// traceSummary describes a trace in the list of traces.
+type traceSummary struct {
+	TraceID   string   `json:"traceId"`
+	Root      string   `json:"root,omitempty"`
+	Services  []string `json:"services"`
+	Spans     int      `json:"spans"`
+	Errors    int      `json:"errors"`
+	Timestamp int64    `json:"timestamp"`
+	Duration  int64    `json:"duration"`
+}
+
+func summarize(t traceformat.Trace) traceSummary {
+	sum := traceSummary{TraceID: strings.ToLower(t[0].TraceID), Spans: len(t)}
+	services := make(map[string]bool)
+	for _, s := range t {
+		if svc := serviceName(s); svc != "" && !services[svc] {
+			services[svc] = true
+			sum.Services = append(sum.Services, svc)
+		}
+		if _, ok := s.Tags["error"]; ok {
+			sum.Errors++
+		}
+	}
+	sort.Strings(sum.Services)
+	if roots := buildTree(t); len(roots) > 0 {
+		sum.Root = spanName(roots[0].span)
+	}
+	sum.Timestamp, sum.Duration = traceBounds(t)
+	return sum
+}
+
+// serveTraces lists the recent traces, most recent first.
+func (c *collector) serveTraces(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultLimit
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	service, name := q.Get("service"), q.Get("name")
+
+	traces := c.zipkin.Traces()
+	summaries := []traceSummary{}
+	for i := len(traces) - 1; i >= 0 && len(summaries) < limit; i-- {
+		if !hasSpan(traces[i], service, name) {
+			continue
+		}
+		summaries = append(summaries, summarize(traces[i]))
+	}
+
+	if q.Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, sum := range summaries {
+			fmt.Fprintf(w, "%s  %-30s %3d spans %3d errors %12s  %s\n",
+				sum.TraceID, sum.Root, sum.Spans, sum.Errors, formatMicros(sum.Duration), strings.Join(sum.Services, ","))
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// serveTrace shows the trace with the ID following /traces/.
+func (c *collector) serveTrace(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/traces/")
+	t := c.zipkin.Trace(id)
+	if len(t) == 0 {
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeWaterfall(w, t)
+		return
+	}
+	data, err := easyjson.Marshal(t)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// hasSpan reports whether t has a span of the given service and name, any
+// of them when empty.
+func hasSpan(t traceformat.Trace, service, name string) bool {
+	for _, s := range t {
+		if (service == "" || serviceName(s) == service) && (name == "" || spanName(s) == name) {
+			return true
+		}
+	}
+	return false
+}
+
+func serviceName(s *trace.Span) string {
+	if s.LocalEndpoint == nil || s.LocalEndpoint.ServiceName == nil {
+		return ""
+	}
+	return *s.LocalEndpoint.ServiceName
+}
+
+func spanName(s *trace.Span) string {
+	if s.Name == nil {
+		return ""
+	}
+	return *s.Name
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+const testSpans = `[
+	{"traceId": "00000000000000aa", "id": "0000000000000001", "name": "get", "kind": "SERVER", "timestamp": 1000, "duration": 400, "localEndpoint": {"serviceName": "web"}},
+	{"traceId": "00000000000000aa", "id": "0000000000000002", "parentId": "0000000000000001", "name": "query", "kind": "CLIENT", "timestamp": 1100, "duration": 100, "localEndpoint": {"serviceName": "web"}, "tags": {"error": "true"}},
+	{"traceId": "00000000000000bb", "id": "0000000000000003", "name": "consume", "timestamp": 2000, "duration": 10, "localEndpoint": {"serviceName": "worker"}}
+]`
+
+func startCollector(t *testing.T, cfg config) (*collector, *httptest.Server) {
+	c, err := newCollector(cfg)
+	require.NoError(t, err)
+	return c, httptest.NewServer(c)
+}
+
+func postSpans(t *testing.T, url, body string, header http.Header) {
+	req, err := http.NewRequest(http.MethodPost, url+"/v1/trace", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestTraces(t *testing.T) {
+	c, srv := startCollector(t, config{maxTraces: 10})
+	defer srv.Close()
+	defer c.Close()
+	postSpans(t, srv.URL, testSpans, nil)
+
+	t.Run("list", func(t *testing.T) {
+		code, body := get(t, srv.URL+"/traces")
+		assert.Equal(t, http.StatusOK, code)
+		var summaries []traceSummary
+		require.NoError(t, json.Unmarshal([]byte(body), &summaries))
+		assert.Equal(t, []traceSummary{
+			{TraceID: "00000000000000bb", Root: "consume", Services: []string{"worker"}, Spans: 1, Timestamp: 2000, Duration: 10},
+			{TraceID: "00000000000000aa", Root: "get", Services: []string{"web"}, Spans: 2, Errors: 1, Timestamp: 1000, Duration: 400},
+		}, summaries)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		for query, want := range map[string]int{
+			"service=web":   1,
+			"name=query":    1,
+			"service=other": 0,
+			"limit=1":       1,
+			"limit=x":       -1,
+		} {
+			code, body := get(t, srv.URL+"/traces?"+query)
+			if want < 0 {
+				assert.Equal(t, http.StatusBadRequest, code, query)
+				continue
+			}
+			var summaries []traceSummary
+			require.NoError(t, json.Unmarshal([]byte(body), &summaries), query)
+			assert.Len(t, summaries, want, query)
+		}
+	})
+
+	t.Run("list-text", func(t *testing.T) {
+		_, body := get(t, srv.URL+"/traces?format=text")
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		require.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], "00000000000000bb  consume"))
+		assert.Contains(t, lines[1], "2 spans")
+		assert.Contains(t, lines[1], "400µs")
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		code, body := get(t, srv.URL+"/traces/00000000000000AA")
+		assert.Equal(t, http.StatusOK, code)
+		var spans []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &spans))
+		assert.Len(t, spans, 2)
+
+		code, body = get(t, srv.URL+"/traces/00000000000000aa?format=text")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "web: query [client] (error)")
+
+		code, _ = get(t, srv.URL+"/traces/00000000000000cc")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+}
+
+func TestPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localcollector")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "spans.json")
+
+	c, srv := startCollector(t, config{maxTraces: 10, file: file})
+	postSpans(t, srv.URL, testSpans, nil)
+	srv.Close()
+	require.NoError(t, c.Close())
+
+	c, srv = startCollector(t, config{maxTraces: 1, file: file})
+	defer srv.Close()
+	defer c.Close()
+	traces := c.zipkin.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, "00000000000000bb", traces[0][0].TraceID)
+
+	postSpans(t, srv.URL, `[{"traceId": "00000000000000cc", "id": "0000000000000004"}]`, nil)
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("[{\n"), 0644))
+	_, err = newCollector(config{file: file})
+	assert.Error(t, err)
+}
+
+func TestForward(t *testing.T) {
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+
+	for name, tt := range map[string]struct {
+		token, want string
+	}{
+		"request-token": {"", "received"},
+		"forward-token": {"forwarded", "forwarded"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			zipkin.Reset()
+			c, srv := startCollector(t, config{forwardURL: zipkin.URL(), forwardToken: tt.token})
+			defer srv.Close()
+			postSpans(t, srv.URL, testSpans, http.Header{"X-Sf-Token": {"received"}})
+			c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			requests, err := zipkin.WaitForRequests(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, requests[0].Spans, 3)
+			assert.Equal(t, tt.want, requests[0].Header.Get("X-SF-Token"))
+			assert.Len(t, c.zipkin.Spans(), 3)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mailru/easyjson"
+	traceformat "github.com/signalfx/golib/trace/format"
+)
+
+// forwardQueueSize is the number of requests waiting to be forwarded beyond
+// which spans are dropped.
+const forwardQueueSize = 100
+
+type forwardRequest struct {
+	spans traceformat.Trace
+	token string
+}
+
+// forwarder sends spans to another endpoint, in the background.
+type forwarder struct {
+	url    string
+	token  string // overrides the token of received requests, if set
+	client *http.Client
+	queue  chan forwardRequest
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex // guards closed and sending to queue
+	closed bool
+}
+
+func newForwarder(url, token string) *forwarder {
+	f := &forwarder{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan forwardRequest, forwardQueueSize),
+	}
+	f.wg.Add(1)
+	go f.run()
+	return f
+}
+
+// forward queues spans to be sent with the given access token. Spans are
+// dropped when the queue is full or the forwarder is closed.
+func (f *forwarder) forward(spans traceformat.Trace, token string) {
+	if f.token != "" {
+		token = f.token
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		log.Printf("localcollector: forwarder closed, dropping %d spans", len(spans))
+		return
+	}
+	select {
+	case f.queue <- forwardRequest{spans: spans, token: token}:
+	default:
+		log.Printf("localcollector: forwarding queue full, dropping %d spans", len(spans))
+	}
+}
+
+// Close waits for the queued spans to be sent.
+func (f *forwarder) Close() {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.queue)
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+func (f *forwarder) run() {
+	defer f.wg.Done()
+	for req := range f.queue {
+		if err := f.send(req); err != nil {
+			log.Printf("localcollector: failed to forward %d spans: %v", len(req.spans), err)
+		}
+	}
+}
+
+func (f *forwarder) send(req forwardRequest) error {
+	data, err := easyjson.Marshal(req.spans)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequest(http.MethodPost, f.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if req.token != "" {
+		r.Header.Set("X-SF-Token", req.token)
+	}
+	resp, err := f.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s responded %s", f.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Command localcollector receives the spans sent by the tracer to the default
+// endpoint, http://localhost:9080/v1/trace, and keeps the recent traces to be
+// browsed without running the Smart Agent or an OpenTelemetry Collector.
+//
+// Traces are listed at /traces and shown at /traces/{id}, as JSON or, with
+// the format=text query parameter, as text waterfalls:
+//
+//	curl 'http://localhost:9080/traces?format=text'
+//	curl 'http://localhost:9080/traces/00000000000000aa?format=text'
+//
+// The list can be filtered with the service, name and limit query parameters.
+//
+// With -file, received spans are appended to a file and reloaded at start.
+// With -forward, received spans are also sent to another endpoint, making the
+// collector a debugging proxy.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.addr, "listen", "localhost:9080", "address to listen on")
+	flag.IntVar(&cfg.maxTraces, "max-traces", 1000, "number of recent traces to keep")
+	flag.StringVar(&cfg.file, "file", "", "file to persist received spans to, and to load spans from at start")
+	flag.StringVar(&cfg.forwardURL, "forward", "", "URL of an endpoint to forward received spans to")
+	flag.StringVar(&cfg.forwardToken, "forward-token", "", "access token sent with forwarded spans, defaults to the token of the received request")
+	flag.Parse()
+
+	c, err := newCollector(cfg)
+	if err != nil {
+		log.Fatalf("localcollector: %v", err)
+	}
+	l, err := net.Listen("tcp", cfg.addr)
+	if err != nil {
+		c.Close()
+		log.Fatalf("localcollector: %v", err)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		stop()
+	}()
+
+	log.Printf("localcollector: receiving spans at http://%s/v1/trace, browse traces at http://%s/traces", cfg.addr, cfg.addr)
+	serve(ctx, l, c)
+}
+
+// serve serves the collector on l until ctx is done. The collector is closed
+// once the server is shut down, so that the spans being received are still
+// persisted and forwarded.
+func serve(ctx context.Context, l net.Listener, c *collector) {
+	ctx, cancel := context.WithCancel(ctx)
+	srv := &http.Server{Handler: c}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("localcollector: failed to shut down: %v", err)
+		}
+	}()
+	if err := srv.Serve(l); err != http.ErrServerClosed {
+		log.Printf("localcollector: %v", err)
+	}
+	cancel()
+	<-done
+	if err := c.Close(); err != nil {
+		log.Printf("localcollector: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func TestServeShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localcollector")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "spans.json")
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+
+	c, err := newCollector(config{maxTraces: 10, file: file, forwardURL: zipkin.URL()})
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	url := "http://" + l.Addr().String() + "/v1/trace"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan struct{})
+	go func() {
+		serve(ctx, l, c)
+		close(served)
+	}()
+
+	// the request being received when the server is shut down
+	body, w := io.Pipe()
+	resp := make(chan *http.Response, 1)
+	go func() {
+		r, err := http.Post(url, "application/json", body)
+		assert.NoError(t, err)
+		resp <- r
+	}()
+	_, err = w.Write([]byte(testSpans[:1]))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond) // for the handler to start
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	_, err = w.Write([]byte(testSpans[1:]))
+	require.NoError(t, err)
+	w.Close()
+
+	r := <-resp
+	require.NotNil(t, r)
+	r.Body.Close()
+	assert.Equal(t, http.StatusOK, r.StatusCode)
+	<-served
+
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reqs, err := zipkin.WaitForRequests(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, reqs[0].Spans, 3)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/signalfx/golib/trace"
+	traceformat "github.com/signalfx/golib/trace/format"
+)
+
+// waterfallWidth is the width of the bars of the waterfall, in characters.
+const waterfallWidth = 40
+
+// spanNode is a span of a trace tree.
+type spanNode struct {
+	span     *trace.Span
+	children []*spanNode
+}
+
+// buildTree returns the roots of the tree of t, the spans whose parent is not
+// in t. Siblings are ordered by timestamp.
+func buildTree(t traceformat.Trace) []*spanNode {
+	nodes := make(map[string]*spanNode, len(t))
+	for _, s := range t {
+		nodes[s.ID] = &spanNode{span: s}
+	}
+	var roots []*spanNode
+	for _, s := range t {
+		n := nodes[s.ID]
+		if n.span != s {
+			continue // duplicate ID
+		}
+		if s.ParentID != nil {
+			if parent, ok := nodes[*s.ParentID]; ok && parent != n {
+				parent.children = append(parent.children, n)
+				continue
+			}
+		}
+		roots = append(roots, n)
+	}
+	sortNodes(roots)
+	return roots
+}
+
+func sortNodes(nodes []*spanNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return timestamp(nodes[i].span) < timestamp(nodes[j].span)
+	})
+	for _, n := range nodes {
+		sortNodes(n.children)
+	}
+}
+
+func timestamp(s *trace.Span) int64 {
+	if s.Timestamp == nil {
+		return 0
+	}
+	return *s.Timestamp
+}
+
+func duration(s *trace.Span) int64 {
+	if s.Duration == nil {
+		return 0
+	}
+	return *s.Duration
+}
+
+// traceBounds returns the timestamp and the duration of t, in microseconds.
+func traceBounds(t traceformat.Trace) (start, dur int64) {
+	var end int64
+	for i, s := range t {
+		if i == 0 || timestamp(s) < start {
+			start = timestamp(s)
+		}
+		if e := timestamp(s) + duration(s); e > end {
+			end = e
+		}
+	}
+	return start, end - start
+}
+
+// writeWaterfall writes t as a waterfall, one span per line, indented by
+// depth, with a bar showing when the span ran within the trace.
+func writeWaterfall(w io.Writer, t traceformat.Trace) {
+	start, total := traceBounds(t)
+	fmt.Fprintf(w, "trace %s  %d spans  %s\n", strings.ToLower(t[0].TraceID), len(t), formatMicros(total))
+
+	var write func(n *spanNode, depth int)
+	write = func(n *spanNode, depth int) {
+		s := n.span
+		offset, length := 0, waterfallWidth
+		if total > 0 {
+			offset = int((timestamp(s) - start) * waterfallWidth / total)
+			length = int(duration(s) * waterfallWidth / total)
+		}
+		if offset >= waterfallWidth {
+			offset = waterfallWidth - 1
+		}
+		if length < 1 {
+			length = 1
+		}
+		if offset+length > waterfallWidth {
+			length = waterfallWidth - offset
+		}
+		bar := strings.Repeat(" ", offset) + strings.Repeat("=", length) + strings.Repeat(" ", waterfallWidth-offset-length)
+
+		label := spanName(s)
+		if svc := serviceName(s); svc != "" {
+			label = svc + ": " + label
+		}
+		if s.Kind != nil {
+			label += " [" + strings.ToLower(*s.Kind) + "]"
+		}
+		if _, ok := s.Tags["error"]; ok {
+			label += " (error)"
+		}
+		fmt.Fprintf(w, "%12s %12s |%s| %s%s\n",
+			formatMicros(timestamp(s)-start), formatMicros(duration(s)), bar, strings.Repeat("  ", depth), label)
+		for _, c := range n.children {
+			write(c, depth+1)
+		}
+	}
+	for _, root := range buildTree(t) {
+		write(root, 0)
+	}
+}
+
+// formatMicros formats a duration in microseconds.
+func formatMicros(us int64) string {
+	return (time.Duration(us) * time.Microsecond).String()
+}
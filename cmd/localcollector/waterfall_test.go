@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mailru/easyjson"
+	traceformat "github.com/signalfx/golib/trace/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteWaterfall(t *testing.T) {
+	var spans traceformat.Trace
+	require.NoError(t, easyjson.Unmarshal([]byte(`[
+		{"traceId": "00000000000000AA", "id": "0000000000000003", "parentId": "0000000000000001", "name": "render", "timestamp": 1300, "duration": 100},
+		{"traceId": "00000000000000AA", "id": "0000000000000002", "parentId": "0000000000000001", "name": "query", "timestamp": 1100, "duration": 200},
+		{"traceId": "00000000000000AA", "id": "0000000000000001", "name": "get", "kind": "SERVER", "timestamp": 1000, "duration": 400, "localEndpoint": {"serviceName": "web"}},
+		{"traceId": "00000000000000AA", "id": "0000000000000004", "parentId": "00000000000000ff", "name": "orphan", "timestamp": 1400}
+	]`), &spans))
+
+	var buf bytes.Buffer
+	writeWaterfall(&buf, spans)
+	want := "trace 00000000000000aa  4 spans  400µs\n" +
+		"          0s        400µs |========================================| web: get [server]\n" +
+		"       100µs        200µs |          ====================          |   query\n" +
+		"       300µs        100µs |                              ==========|   render\n" +
+		"       400µs           0s |                                       =| orphan\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestBuildTree(t *testing.T) {
+	parent := "0000000000000001"
+	spans := traceformat.Trace{
+		{ID: "0000000000000001", ParentID: &parent},
+		{ID: "0000000000000002", ParentID: &parent},
+	}
+	roots := buildTree(spans)
+	require.Len(t, roots, 1)
+	assert.Len(t, roots[0].children, 1)
+}
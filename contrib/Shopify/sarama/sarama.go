@@ -43,6 +43,7 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 				tracer.ServiceName(cfg.serviceName),
 				tracer.ResourceName("Consume Topic " + msg.Topic),
 				tracer.SpanType(ext.SpanTypeMessageConsumer),
+				tracer.SpanKind(tracer.KindConsumer),
 				tracer.Tag("partition", msg.Partition),
 				tracer.Tag("offset", msg.Offset),
 				tracer.Tag(ext.PeerService, cfg.peerServiceName),
@@ -254,6 +255,7 @@ func startProducerSpan(cfg *config, version sarama.KafkaVersion, msg *sarama.Pro
 		tracer.ServiceName(cfg.serviceName),
 		tracer.ResourceName("Produce Topic " + msg.Topic),
 		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.SpanKind(tracer.KindProducer),
 		tracer.Tag(ext.PeerService, cfg.peerServiceName),
 		tracer.Tag(ext.MessageBusDestination, msg.Topic),
 	}
@@ -45,6 +45,7 @@ func WrapSession(s *session.Session, opts ...Option) *session.Session {
 func (h *handlers) Send(req *request.Request) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ServiceName(h.serviceName(req)),
 		tracer.ResourceName(h.resourceName(req)),
 		tracer.Tag(tagAWSAgent, h.awsAgent(req)),
@@ -58,6 +58,7 @@ func (c *Client) WithContext(ctx context.Context) *Client {
 func (c *Client) startSpan(resourceName string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeMemcached),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ServiceName(c.cfg.serviceName),
 		tracer.ResourceName(resourceName),
 	}
@@ -84,6 +84,7 @@ func (c *Consumer) startSpan(msg *kafka.Message) ddtrace.Span {
 		tracer.ServiceName(c.cfg.serviceName),
 		tracer.ResourceName("Consume Topic " + *msg.TopicPartition.Topic),
 		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.SpanKind(tracer.KindConsumer),
 		tracer.Tag("partition", msg.TopicPartition.Partition),
 		tracer.Tag("offset", msg.TopicPartition.Offset),
 	}
@@ -174,6 +175,7 @@ func (p *Producer) startSpan(msg *kafka.Message) ddtrace.Span {
 		tracer.ServiceName(p.cfg.serviceName),
 		tracer.ResourceName("Produce Topic " + *msg.TopicPartition.Topic),
 		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.SpanKind(tracer.KindProducer),
 		tracer.Tag("partition", msg.TopicPartition.Partition),
 	}
 	if p.cfg.analyticsRate > 0 {
@@ -63,6 +63,7 @@ func (tp *traceParams) tryTrace(ctx context.Context, resource string, query stri
 	}
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ServiceName(tp.config.serviceName),
 		tracer.StartTime(startTime),
 	}
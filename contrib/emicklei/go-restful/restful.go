@@ -22,6 +22,7 @@ func FilterFunc(configOpts ...Option) restful.FilterFunction {
 			tracer.ServiceName(cfg.serviceName),
 			tracer.ResourceName(req.SelectedRoutePath()),
 			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.SpanKind(tracer.KindServer),
 			tracer.Tag(ext.HTTPMethod, req.Request.Method),
 			tracer.Tag(ext.HTTPURL, req.Request.URL.Path),
 		}
@@ -49,6 +50,7 @@ func Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterC
 	opts := []ddtrace.StartSpanOption{
 		tracer.ResourceName(req.SelectedRoutePath()),
 		tracer.SpanType(ext.SpanTypeWeb),
+		tracer.SpanKind(tracer.KindServer),
 		tracer.Tag(ext.HTTPMethod, req.Request.Method),
 		tracer.Tag(ext.HTTPURL, req.Request.URL.Path),
 	}
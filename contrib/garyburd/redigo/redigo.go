@@ -93,6 +93,7 @@ func (tc Conn) newChildSpan(ctx context.Context) ddtrace.Span {
 	p := tc.params
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeRedis),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ServiceName(p.config.serviceName),
 	}
 	if rate := p.config.analyticsRate; rate > 0 {
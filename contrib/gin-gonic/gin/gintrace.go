@@ -25,7 +25,7 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 			tracer.ServiceName(service),
 			tracer.ResourceName(operationName),
 			tracer.SpanType(ext.SpanTypeGin),
-			tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+			tracer.SpanKind(tracer.KindServer),
 			tracer.Tag(ext.HTTPMethod, c.Request.Method),
 			tracer.Tag(ext.HTTPURL, utils.GetURL(c.Request)),
 		}
@@ -54,7 +54,7 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 
 // HTML will trace the rendering of the template as a child of the span in the given context.
 func HTML(c *gin.Context, code int, name string, obj interface{}) {
-	span, _ := tracer.StartSpanFromContext(c.Request.Context(), "gin.render.html")
+	span, _ := tracer.StartSpanFromContext(c.Request.Context(), "gin.render.html", tracer.SpanKind(tracer.KindInternal))
 	span.SetTag("go.template", name)
 	defer func() {
 		if r := recover(); r != nil {
@@ -298,7 +298,7 @@ func TestWithZipkin(t *testing.T) {
 
 		assert.Equal(*span.Name, "/successful")
 		assert.Equal(*span.Kind, "SERVER")
-		assert.Equal(*span.LocalEndpoint.ServiceName, "test-gin-service")
+		assert.Equal("foobar", *span.LocalEndpoint.ServiceName)
 		testutil.AssertSpanWithTags(t, span, map[string]string{
 			"component":        "gin",
 			"http.method":      "GET",
@@ -323,7 +323,7 @@ func TestWithZipkin(t *testing.T) {
 
 		span := spans[0]
 
-		assert.Equal(*span.LocalEndpoint.ServiceName, "test-gin-service")
+		assert.Equal("foobar", *span.LocalEndpoint.ServiceName)
 		assert.Equal(*span.Kind, "SERVER")
 		assert.Equal(*span.Name, "/unsuccessful")
 		testutil.AssertSpanWithTags(t, span, map[string]string{
@@ -104,7 +104,7 @@ func (c *Pipeliner) execWithContext(ctx context.Context) ([]redis.Cmder, error)
 		tracer.Tag(ext.DBType, "redis"),
 		tracer.Tag(ext.TargetHost, p.host),
 		tracer.Tag(ext.TargetPort, p.port),
-		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.Tag("out.db", p.db),
 	}
 	if rate := p.config.analyticsRate; rate > 0 {
@@ -170,7 +170,7 @@ func createWrapperFromClient(tc *Client) func(oldProcess func(cmd redis.Cmder) e
 				tracer.Tag(ext.DBType, "redis"),
 				tracer.Tag(ext.TargetHost, p.host),
 				tracer.Tag(ext.TargetPort, p.port),
-				tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+				tracer.SpanKind(tracer.KindClient),
 				tracer.Tag("out.db", p.db),
 				tracer.Tag("redis.raw_command", raw),
 				tracer.Tag("redis.args_length", strconv.Itoa(argsLength)),
@@ -0,0 +1,27 @@
+package opencensus_test
+
+import (
+	"context"
+
+	octrace "go.opencensus.io/trace"
+
+	"github.com/signalfx/signalfx-go-tracing/contrib/go.opencensus.io"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/tracing"
+)
+
+func Example() {
+	tracing.Start(tracing.WithServiceName("my-service"))
+	defer tracing.Stop()
+
+	// Report the spans of OpenCensus instrumented libraries with the tracer.
+	e := opencensus.Register()
+	defer opencensus.Unregister(e)
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	defer span.Finish()
+
+	// OpenCensus spans started with ctx are children of span.
+	_, child := octrace.StartSpan(ctx, "child")
+	child.End()
+}
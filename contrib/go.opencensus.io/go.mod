@@ -0,0 +1,13 @@
+module github.com/signalfx/signalfx-go-tracing/contrib/go.opencensus.io
+
+go 1.13
+
+require (
+	github.com/opentracing/opentracing-go v1.1.0
+	github.com/signalfx/golib v2.5.1+incompatible
+	github.com/signalfx/signalfx-go-tracing v1.12.0
+	github.com/stretchr/testify v1.7.0
+	go.opencensus.io v0.23.0
+)
+
+replace github.com/signalfx/signalfx-go-tracing => ../../
@@ -0,0 +1,295 @@
+// Package opencensus provides an OpenCensus bridge, allowing libraries
+// instrumented with OpenCensus to report their spans through a ddtrace.Tracer,
+// in the same traces as the spans of the contrib packages.
+package opencensus // import "github.com/signalfx/signalfx-go-tracing/contrib/go.opencensus.io"
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	octrace "go.opencensus.io/trace"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+// b3 converts the OpenCensus span contexts of parents and links.
+var b3 = tracer.NewPropagator(&tracer.PropagatorConfig{Styles: []string{"b3"}})
+
+var (
+	mu         sync.Mutex // guards below
+	translated *translator
+)
+
+// Register registers an exporter converting the OpenCensus spans to spans of
+// the global tracer, or the one set with WithTracer. It also translates the
+// contexts holding spans of this tracer, such as the ones returned by
+// tracer.StartSpanFromContext, so that the OpenCensus spans started with them
+// are their children. Spans are only exported when sampled by OpenCensus,
+// which is the case of the children of sampled spans of this tracer.
+func Register(opts ...Option) *Exporter {
+	e := NewExporter(opts...)
+	octrace.RegisterExporter(e)
+	mu.Lock()
+	defer mu.Unlock()
+	if translated == nil {
+		translated = &translator{Tracer: octrace.DefaultTracer}
+		octrace.DefaultTracer = translated
+	}
+	return e
+}
+
+// Unregister unregisters the exporter returned by Register, and the context
+// translation.
+func Unregister(e *Exporter) {
+	octrace.UnregisterExporter(e)
+	mu.Lock()
+	defer mu.Unlock()
+	if translated != nil && octrace.DefaultTracer == translated {
+		octrace.DefaultTracer = translated.Tracer
+	}
+	translated = nil
+}
+
+// translator implements octrace.Tracer, starting the OpenCensus spans of
+// contexts holding no OpenCensus span as children of the span of this tracer
+// they hold, if any.
+type translator struct {
+	octrace.Tracer
+}
+
+// StartSpan implements octrace.Tracer.
+func (t *translator) StartSpan(ctx context.Context, name string, o ...octrace.StartOption) (context.Context, *octrace.Span) {
+	if t.FromContext(ctx) == nil {
+		if s, ok := tracer.SpanFromContext(ctx); ok {
+			if sc, ok := spanContext(s.Context()); ok {
+				return t.Tracer.StartSpanWithRemoteParent(ctx, name, sc, o...)
+			}
+		}
+	}
+	return t.Tracer.StartSpan(ctx, name, o...)
+}
+
+// spanContext returns the OpenCensus span context of the given span context.
+// Spans are sampled unless the trace was rejected.
+func spanContext(ctx ddtrace.SpanContext) (octrace.SpanContext, bool) {
+	var sc octrace.SpanContext
+	if tracer.TraceID(ctx) == 0 || tracer.SpanID(ctx) == 0 {
+		return sc, false
+	}
+	binary.BigEndian.PutUint64(sc.TraceID[:8], tracer.TraceIDHigh(ctx))
+	binary.BigEndian.PutUint64(sc.TraceID[8:], tracer.TraceID(ctx))
+	binary.BigEndian.PutUint64(sc.SpanID[:], tracer.SpanID(ctx))
+	if p, ok := tracer.SamplingPriority(ctx); !ok || p > ext.PriorityAutoReject {
+		sc.TraceOptions = 1
+	}
+	return sc, true
+}
+
+// maxEnded is the number of ended traces remembered by an exporter, so that
+// their spans ending after their local root are not held.
+const maxEnded = 1000
+
+// Exporter implements octrace.Exporter, converting the OpenCensus spans to
+// spans of a ddtrace.Tracer.
+type Exporter struct {
+	cfg config
+
+	mu     sync.Mutex                      // guards below
+	traces map[octrace.TraceID]*localTrace // traces whose local root is running
+	ended  map[octrace.TraceID]bool        // traces whose local root was exported
+	order  []octrace.TraceID               // keys of ended, oldest first
+}
+
+// localTrace holds the spans of a trace started in this process.
+type localTrace struct {
+	// spans holds the contexts of the spans exported so far.
+	spans map[octrace.SpanID]ddtrace.SpanContext
+	// pending holds the spans waiting for their parent to end, by parent ID.
+	pending map[octrace.SpanID][]*octrace.SpanData
+}
+
+var _ octrace.Exporter = (*Exporter)(nil)
+
+// NewExporter returns an exporter converting the OpenCensus spans to spans of
+// the global tracer, or the one set with WithTracer. Use Register to also
+// translate contexts.
+func NewExporter(opts ...Option) *Exporter {
+	e := new(Exporter)
+	for _, fn := range opts {
+		fn(&e.cfg)
+	}
+	return e
+}
+
+// ExportSpan reports the given span, keeping its IDs and times. Its
+// attributes are set as tags, and its annotations and message events as logs.
+//
+// As OpenCensus exports the spans when they end, the spans of a trace started
+// in this process are held until their parent ends, and reported as its
+// children, in the same trace as the spans of this tracer. The spans whose
+// parent is remote, or ends after the local root of their trace, are reported
+// as children of a remote span context.
+func (e *Exporter) ExportSpan(sd *octrace.SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.traces == nil {
+		e.traces = make(map[octrace.TraceID]*localTrace)
+		e.ended = make(map[octrace.TraceID]bool)
+	}
+	root := sd.ParentSpanID == (octrace.SpanID{}) || sd.HasRemoteParent
+	lt, ok := e.traces[sd.TraceID]
+	if !ok {
+		if !root && e.ended[sd.TraceID] {
+			e.export(nil, sd, nil)
+			return
+		}
+		lt = &localTrace{
+			spans:   make(map[octrace.SpanID]ddtrace.SpanContext),
+			pending: make(map[octrace.SpanID][]*octrace.SpanData),
+		}
+		e.traces[sd.TraceID] = lt
+	}
+	if !root {
+		parent, ok := lt.spans[sd.ParentSpanID]
+		if !ok {
+			lt.pending[sd.ParentSpanID] = append(lt.pending[sd.ParentSpanID], sd)
+			return
+		}
+		e.export(lt, sd, parent)
+		return
+	}
+	e.export(lt, sd, nil)
+	for _, spans := range lt.pending {
+		for _, s := range spans {
+			e.export(nil, s, nil)
+		}
+	}
+	delete(e.traces, sd.TraceID)
+	e.ended[sd.TraceID] = true
+	e.order = append(e.order, sd.TraceID)
+	if len(e.order) > maxEnded {
+		delete(e.ended, e.order[0])
+		e.order = e.order[1:]
+	}
+}
+
+// export reports sd as the child of parent, or of its remote parent if nil.
+// The spans of lt waiting for sd are reported as its children.
+func (e *Exporter) export(lt *localTrace, sd *octrace.SpanData, parent ddtrace.SpanContext) {
+	t := e.cfg.tracer
+	if t == nil {
+		t = ddtrace.GetGlobalTracer()
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.WithSpanID(binary.BigEndian.Uint64(sd.SpanID[:])),
+		tracer.StartTime(sd.StartTime),
+		tracer.SpanKind(spanKind(sd.SpanKind)),
+	}
+	if parent == nil && sd.ParentSpanID != (octrace.SpanID{}) {
+		parent = remoteContext(sd.TraceID, sd.ParentSpanID)
+	}
+	if parent != nil {
+		opts = append(opts, tracer.ChildOf(parent))
+	} else if sd.ParentSpanID == (octrace.SpanID{}) {
+		opts = append(opts, tracer.WithTraceID(
+			binary.BigEndian.Uint64(sd.TraceID[:8]),
+			binary.BigEndian.Uint64(sd.TraceID[8:]),
+		))
+	}
+	if e.cfg.serviceName != "" {
+		opts = append(opts, tracer.ServiceName(e.cfg.serviceName))
+	}
+	for _, l := range sd.Links {
+		if ctx := remoteContext(l.TraceID, l.SpanID); ctx != nil {
+			opts = append(opts, tracer.Link(ctx))
+		}
+	}
+	for k, v := range sd.Attributes {
+		opts = append(opts, tracer.Tag(k, v))
+	}
+	s := t.StartSpan(sd.Name, opts...)
+	if sd.Code != 0 {
+		tracer.SetStatus(s, ddtrace.StatusError, sd.Message)
+	}
+	if lt != nil {
+		lt.spans[sd.SpanID] = s.Context()
+		children := lt.pending[sd.SpanID]
+		delete(lt.pending, sd.SpanID)
+		for _, c := range children {
+			e.export(lt, c, s.Context())
+		}
+	}
+	s.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: sd.EndTime,
+		LogRecords: logRecords(sd),
+	})
+}
+
+// remoteContext returns the span context of the given IDs, or nil if they are
+// invalid.
+func remoteContext(tid octrace.TraceID, sid octrace.SpanID) ddtrace.SpanContext {
+	ctx, err := b3.Extract(tracer.TextMapCarrier{
+		"x-b3-traceid": hex.EncodeToString(tid[:]),
+		"x-b3-spanid":  hex.EncodeToString(sid[:]),
+		"x-b3-sampled": "1",
+	})
+	if err != nil {
+		return nil
+	}
+	return ctx
+}
+
+// spanKind returns the kind of the spans of the given OpenCensus kind.
+func spanKind(kind int) tracer.Kind {
+	switch kind {
+	case octrace.SpanKindServer:
+		return tracer.KindServer
+	case octrace.SpanKindClient:
+		return tracer.KindClient
+	default:
+		return tracer.KindInternal
+	}
+}
+
+// logRecords returns the logs of the annotations and message events of sd.
+func logRecords(sd *octrace.SpanData) []opentracing.LogRecord {
+	records := make([]opentracing.LogRecord, 0, len(sd.Annotations)+len(sd.MessageEvents))
+	for _, a := range sd.Annotations {
+		fields := []log.Field{log.String("event", a.Message)}
+		for k, v := range a.Attributes {
+			fields = append(fields, log.Object(k, v))
+		}
+		records = append(records, opentracing.LogRecord{Timestamp: a.Time, Fields: fields})
+	}
+	for _, m := range sd.MessageEvents {
+		records = append(records, opentracing.LogRecord{
+			Timestamp: m.Time,
+			Fields: []log.Field{
+				log.String("event", "message"),
+				log.String("message.type", messageType(m.EventType)),
+				log.Int64("message.id", m.MessageID),
+				log.Int64("message.uncompressed_size", m.UncompressedByteSize),
+				log.Int64("message.compressed_size", m.CompressedByteSize),
+			},
+		})
+	}
+	return records
+}
+
+// messageType returns the "message.type" log field of a message event.
+func messageType(t octrace.MessageEventType) string {
+	switch t {
+	case octrace.MessageEventTypeSent:
+		return "SENT"
+	case octrace.MessageEventTypeRecv:
+		return "RECEIVED"
+	default:
+		return "UNSPECIFIED"
+	}
+}
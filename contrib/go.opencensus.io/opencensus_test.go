@@ -0,0 +1,163 @@
+package opencensus
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	traceformat "github.com/signalfx/golib/trace/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	octrace "go.opencensus.io/trace"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/tracing"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func startZipkin(t *testing.T) (*zipkinserver.ZipkinServer, func()) {
+	zipkin := zipkinserver.Start()
+	tracing.Start(tracing.WithEndpointURL(zipkin.URL()), tracing.WithServiceName("test-opencensus-service"))
+	return zipkin, func() {
+		tracing.Stop()
+		zipkin.Stop()
+	}
+}
+
+func spansByName(spans traceformat.Trace) map[string]*traceformat.Span {
+	m := make(map[string]*traceformat.Span, len(spans))
+	for _, s := range spans {
+		m[*s.Name] = (*traceformat.Span)(s)
+	}
+	return m
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	e := Register()
+	defer Unregister(e)
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "dd.root")
+	ctx, child := octrace.StartSpan(ctx, "oc.child", octrace.WithSpanKind(octrace.SpanKindClient))
+	child.AddAttributes(octrace.StringAttribute("string", "value"), octrace.Int64Attribute("int", 42))
+	child.Annotate([]octrace.Attribute{octrace.BoolAttribute("hit", false)}, "cache")
+	_, leaf := octrace.StartSpan(ctx, "oc.leaf")
+	leaf.SetStatus(octrace.Status{Code: 5, Message: "not found"})
+	leaf.End()
+	child.End()
+	root.Finish()
+
+	tracer.ForceFlush()
+	spans := spansByName(zipkin.WaitForSpans(t, 3))
+	rs, cs, ls := spans["dd.root"], spans["oc.child"], spans["oc.leaf"]
+	require.NotNil(t, rs)
+	require.NotNil(t, cs)
+	require.NotNil(t, ls)
+
+	assert.Equal(rs.TraceID, cs.TraceID)
+	assert.Equal(rs.TraceID, ls.TraceID)
+	assert.Equal(rs.ID, *cs.ParentID)
+	assert.Equal(cs.ID, *ls.ParentID)
+	assert.Equal(child.SpanContext().SpanID.String(), cs.ID)
+
+	assert.Equal("CLIENT", *cs.Kind)
+	assert.Equal("value", cs.Tags["string"])
+	assert.Equal("42", cs.Tags["int"])
+	require.Len(t, cs.Annotations, 1)
+	assert.JSONEq(`{"event":"cache","hit":false}`, *cs.Annotations[0].Value)
+
+	assert.Nil(ls.Kind)
+	assert.Equal("true", ls.Tags[ext.Error])
+	assert.Equal("not found", ls.Tags[ext.StatusDescription])
+}
+
+func TestUnregister(t *testing.T) {
+	base := octrace.DefaultTracer
+	e := Register()
+	other := Register(WithServiceName("other"))
+	defer octrace.UnregisterExporter(other)
+	assert.NotEqual(t, base, octrace.DefaultTracer)
+	Unregister(e)
+	assert.Equal(t, base, octrace.DefaultTracer)
+}
+
+func TestExportSpan(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	tid, _ := hex.DecodeString("0102030405060708090a0b0c0d0e0f10")
+	var sd octrace.SpanData
+	copy(sd.TraceID[:], tid)
+	sd.SpanID = octrace.SpanID{1, 2, 3, 4, 5, 6, 7, 8}
+	sd.Name = "oc.root"
+	sd.SpanKind = octrace.SpanKindServer
+	sd.StartTime = time.Unix(100, 0)
+	sd.EndTime = time.Unix(101, 0)
+	sd.Links = []octrace.Link{{TraceID: sd.TraceID, SpanID: octrace.SpanID{8}}}
+	sd.MessageEvents = []octrace.MessageEvent{{
+		Time:                 time.Unix(100, 500),
+		EventType:            octrace.MessageEventTypeRecv,
+		MessageID:            1,
+		UncompressedByteSize: 10,
+	}}
+	NewExporter(WithServiceName("library")).ExportSpan(&sd)
+
+	tracer.ForceFlush()
+	span := zipkin.WaitForSpans(t, 1)[0]
+	assert.Equal("0102030405060708090a0b0c0d0e0f10", span.TraceID)
+	assert.Equal("0102030405060708", span.ID)
+	assert.Nil(span.ParentID)
+	assert.Equal("SERVER", *span.Kind)
+	assert.Equal(int64(100000000), *span.Timestamp)
+	assert.Equal(int64(1000000), *span.Duration)
+	assert.Equal("library", *span.LocalEndpoint.ServiceName)
+	assert.Equal("0102030405060708090a0b0c0d0e0f10:0800000000000000", span.Tags[ext.SpanLinks])
+	require.Len(t, span.Annotations, 1)
+	assert.Equal(int64(100000000), *span.Annotations[0].Timestamp)
+	assert.JSONEq(`{"event":"message","message.type":"RECEIVED","message.id":1,"message.uncompressed_size":10,"message.compressed_size":0}`, *span.Annotations[0].Value)
+}
+
+func TestExportLocalParents(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	e := NewExporter()
+
+	span := func(name string, sid, parent byte, remote bool) *octrace.SpanData {
+		sd := &octrace.SpanData{Name: name, HasRemoteParent: remote}
+		sd.TraceID = octrace.TraceID{1}
+		sd.SpanID = octrace.SpanID{sid}
+		if parent != 0 {
+			sd.ParentSpanID = octrace.SpanID{parent}
+		}
+		return sd
+	}
+	// spans are exported when they end, children first
+	e.ExportSpan(span("leaf", 3, 2, false))
+	e.ExportSpan(span("child", 2, 1, false))
+	assert.Empty(mt.FinishedSpans())
+	e.ExportSpan(span("root", 1, 9, true))
+	e.ExportSpan(span("late", 4, 1, false))
+
+	spans := make(map[string]mocktracer.Span)
+	for _, s := range mt.FinishedSpans() {
+		spans[s.OperationName()] = s
+	}
+	require.Len(t, spans, 4)
+	root, child, leaf := spans["root"], spans["child"], spans["leaf"]
+	// the remote parent is not a span of the mock tracer
+	assert.Zero(root.ParentID())
+	assert.Equal(root.SpanID(), child.ParentID())
+	assert.Equal(child.SpanID(), leaf.ParentID())
+	assert.Equal(root.TraceID(), leaf.TraceID())
+	// spans ending after their local root have a remote parent
+	assert.Zero(spans["late"].ParentID())
+	assert.Empty(e.traces)
+}
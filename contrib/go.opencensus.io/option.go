@@ -0,0 +1,29 @@
+package opencensus
+
+import (
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+)
+
+type config struct {
+	tracer      ddtrace.Tracer
+	serviceName string
+}
+
+// Option represents an option that can be passed to Register and NewExporter.
+type Option func(*config)
+
+// WithTracer sets the tracer reporting the exported spans, such as one created
+// with tracer.New. It defaults to the global tracer.
+func WithTracer(t ddtrace.Tracer) Option {
+	return func(cfg *config) {
+		cfg.tracer = t
+	}
+}
+
+// WithServiceName sets the service name of the exported spans. It defaults to
+// the service name of the tracer.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
@@ -0,0 +1,26 @@
+package otel_test
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+
+	oteltrace "github.com/signalfx/signalfx-go-tracing/contrib/go.opentelemetry.io/otel"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/tracing"
+)
+
+func Example() {
+	tracing.Start(tracing.WithServiceName("my-service"))
+	defer tracing.Stop()
+
+	// Report the spans of OpenTelemetry instrumented libraries with the tracer.
+	otel.SetTracerProvider(oteltrace.NewTracerProvider())
+
+	ctx, span := otel.Tracer("my-library").Start(context.Background(), "parent")
+	defer span.End()
+
+	// Spans of both APIs share the same trace.
+	child, _ := tracer.StartSpanFromContext(ctx, "child")
+	child.Finish()
+}
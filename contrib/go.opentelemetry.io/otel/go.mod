@@ -0,0 +1,14 @@
+module github.com/signalfx/signalfx-go-tracing/contrib/go.opentelemetry.io/otel
+
+go 1.15
+
+require (
+	github.com/opentracing/opentracing-go v1.1.0
+	github.com/signalfx/golib v2.5.1+incompatible
+	github.com/signalfx/signalfx-go-tracing v1.12.0
+	github.com/stretchr/testify v1.7.0
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+)
+
+replace github.com/signalfx/signalfx-go-tracing => ../../../
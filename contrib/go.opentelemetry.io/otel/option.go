@@ -0,0 +1,20 @@
+package otel
+
+import (
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+)
+
+type config struct {
+	tracer ddtrace.Tracer
+}
+
+// Option represents an option that can be passed to NewTracerProvider.
+type Option func(*config)
+
+// WithTracer sets the tracer starting the spans of the provider, such as one
+// created with tracer.New. It defaults to the global tracer.
+func WithTracer(t ddtrace.Tracer) Option {
+	return func(cfg *config) {
+		cfg.tracer = t
+	}
+}
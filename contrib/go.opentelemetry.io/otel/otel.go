@@ -0,0 +1,220 @@
+// Package otel provides an OpenTelemetry API bridge, allowing libraries
+// instrumented with OpenTelemetry to report their spans through a
+// ddtrace.Tracer, in the same traces as the spans of the contrib packages.
+package otel // import "github.com/signalfx/signalfx-go-tracing/contrib/go.opentelemetry.io/otel"
+
+import (
+	"context"
+	"encoding/binary"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+const (
+	// tagLibraryName holds the name of the instrumentation library which
+	// started the span.
+	tagLibraryName = "otel.library.name"
+	// tagLibraryVersion holds the version of the instrumentation library
+	// which started the span.
+	tagLibraryVersion = "otel.library.version"
+)
+
+// b3 converts the OpenTelemetry span contexts of remote parents.
+var b3 = tracer.NewPropagator(&tracer.PropagatorConfig{Styles: []string{"b3"}})
+
+// TracerProvider implements trace.TracerProvider on top of a ddtrace.Tracer.
+type TracerProvider struct {
+	cfg config
+}
+
+var _ trace.TracerProvider = (*TracerProvider)(nil)
+
+// NewTracerProvider returns a trace.TracerProvider whose tracers start their
+// spans with the global tracer, or the one set with WithTracer. It is
+// usually registered with otel.SetTracerProvider.
+func NewTracerProvider(opts ...Option) *TracerProvider {
+	p := new(TracerProvider)
+	for _, fn := range opts {
+		fn(&p.cfg)
+	}
+	return p
+}
+
+// Tracer returns the tracer of the named instrumentation library.
+func (p *TracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	cfg := trace.NewTracerConfig(opts...)
+	return &otelTracer{
+		provider: p,
+		name:     name,
+		version:  cfg.InstrumentationVersion(),
+	}
+}
+
+// ddtracer returns the tracer starting the spans of the provider.
+func (p *TracerProvider) ddtracer() ddtrace.Tracer {
+	if p.cfg.tracer != nil {
+		return p.cfg.tracer
+	}
+	return ddtrace.GetGlobalTracer()
+}
+
+// otelTracer implements trace.Tracer for an instrumentation library.
+type otelTracer struct {
+	provider *TracerProvider
+	name     string
+	version  string
+}
+
+// Start starts a span, child of the OpenTelemetry span found in ctx, or of the
+// span started with tracer.StartSpanFromContext when there is none. The
+// returned context holds the new span for both APIs.
+func (t *otelTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	ddopts := []ddtrace.StartSpanOption{
+		tracer.SpanKind(spanKind(cfg.SpanKind())),
+	}
+	var parent ddtrace.SpanContext
+	if !cfg.NewRoot() {
+		if parent = parentContext(ctx); parent != nil {
+			ddopts = append(ddopts, tracer.ChildOf(parent))
+		}
+	}
+	if ts := cfg.Timestamp(); !ts.IsZero() {
+		ddopts = append(ddopts, tracer.StartTime(ts))
+	}
+	if t.name != "" {
+		ddopts = append(ddopts, tracer.Tag(tagLibraryName, t.name))
+	}
+	if t.version != "" {
+		ddopts = append(ddopts, tracer.Tag(tagLibraryVersion, t.version))
+	}
+	for _, kv := range cfg.Attributes() {
+		ddopts = append(ddopts, tracer.Tag(string(kv.Key), tagValue(kv.Value)))
+	}
+	for _, l := range cfg.Links() {
+		if lc := remoteContext(l.SpanContext); lc != nil {
+			ddopts = append(ddopts, tracer.Link(lc))
+		}
+	}
+	s := &span{
+		ddspan:   t.provider.ddtracer().StartSpan(name, ddopts...),
+		provider: t.provider,
+		parent:   parent,
+	}
+	s.sc = spanContext(s.ddspan.Context())
+	ctx = tracer.ContextWithSpan(ctx, s.ddspan)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// parentContext returns the context of the parent of spans started with ctx,
+// or nil if there is none. The spans of the bridge are unwrapped, unless a
+// span of their trace was started below them with tracer.StartSpanFromContext.
+func parentContext(ctx context.Context) ddtrace.SpanContext {
+	dd, ok := tracer.SpanFromContext(ctx)
+	if s, isBridge := trace.SpanFromContext(ctx).(*span); isBridge {
+		if ok && below(dd, s) {
+			return dd.Context()
+		}
+		return s.ddspan.Context()
+	}
+	if c := remoteContext(trace.SpanContextFromContext(ctx)); c != nil {
+		return c
+	}
+	if ok {
+		return dd.Context()
+	}
+	return nil
+}
+
+// below reports whether dd was started below the bridge span s: it belongs
+// to the trace of s, and is neither s nor its parent.
+func below(dd ddtrace.Span, s *span) bool {
+	c, sc := dd.Context(), s.ddspan.Context()
+	if tracer.TraceID(c) != tracer.TraceID(sc) || tracer.TraceIDHigh(c) != tracer.TraceIDHigh(sc) {
+		return false
+	}
+	if tracer.SpanID(c) == tracer.SpanID(sc) {
+		return false
+	}
+	return s.parent == nil || tracer.SpanID(c) != tracer.SpanID(s.parent)
+}
+
+// remoteContext returns the span context of the given OpenTelemetry span
+// context, or nil if it is not valid.
+func remoteContext(sc trace.SpanContext) ddtrace.SpanContext {
+	if !sc.IsValid() {
+		return nil
+	}
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+	c, err := b3.Extract(tracer.TextMapCarrier{
+		"x-b3-traceid": sc.TraceID().String(),
+		"x-b3-spanid":  sc.SpanID().String(),
+		"x-b3-sampled": sampled,
+	})
+	if err != nil {
+		return nil
+	}
+	return c
+}
+
+// spanContext returns the OpenTelemetry span context of the given span context.
+// Spans are reported as sampled unless the trace was rejected.
+func spanContext(ctx ddtrace.SpanContext) trace.SpanContext {
+	var (
+		tid trace.TraceID
+		sid trace.SpanID
+	)
+	binary.BigEndian.PutUint64(tid[:8], tracer.TraceIDHigh(ctx))
+	binary.BigEndian.PutUint64(tid[8:], tracer.TraceID(ctx))
+	binary.BigEndian.PutUint64(sid[:], tracer.SpanID(ctx))
+	flags := trace.FlagsSampled
+	if p, ok := tracer.SamplingPriority(ctx); ok && p <= ext.PriorityAutoReject {
+		flags = 0
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: flags,
+	})
+}
+
+// spanKind returns the kind of the spans started with the given kind.
+func spanKind(kind trace.SpanKind) tracer.Kind {
+	switch kind {
+	case trace.SpanKindServer:
+		return tracer.KindServer
+	case trace.SpanKindClient:
+		return tracer.KindClient
+	case trace.SpanKindProducer:
+		return tracer.KindProducer
+	case trace.SpanKindConsumer:
+		return tracer.KindConsumer
+	default:
+		return tracer.KindInternal
+	}
+}
+
+// tagValue returns the tag value of an attribute. Scalar values keep their
+// type and slices are converted to strings.
+func tagValue(v attribute.Value) interface{} {
+	switch v.Type() {
+	case attribute.BOOL:
+		return v.AsBool()
+	case attribute.INT64:
+		return v.AsInt64()
+	case attribute.FLOAT64:
+		return v.AsFloat64()
+	case attribute.STRING:
+		return v.AsString()
+	default:
+		return v.Emit()
+	}
+}
@@ -0,0 +1,225 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	traceformat "github.com/signalfx/golib/trace/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/tracing"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func startZipkin(t *testing.T) (*zipkinserver.ZipkinServer, func()) {
+	zipkin := zipkinserver.Start()
+	tracing.Start(tracing.WithEndpointURL(zipkin.URL()), tracing.WithServiceName("test-otel-service"))
+	return zipkin, func() {
+		tracing.Stop()
+		zipkin.Stop()
+	}
+}
+
+func spansByName(spans traceformat.Trace) map[string]*traceformat.Span {
+	m := make(map[string]*traceformat.Span, len(spans))
+	for _, s := range spans {
+		m[*s.Name] = (*traceformat.Span)(s)
+	}
+	return m
+}
+
+func TestMixedParenting(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	otr := NewTracerProvider().Tracer("test-library", trace.WithInstrumentationVersion("1.0"))
+	ctx, root := otr.Start(context.Background(), "otel.root", trace.WithSpanKind(trace.SpanKindServer))
+	dd, ctx := tracer.StartSpanFromContext(ctx, "contrib.child")
+	_, leaf := otr.Start(ctx, "otel.leaf")
+	leaf.End()
+	dd.Finish()
+	root.End()
+
+	tracer.ForceFlush()
+	spans := spansByName(zipkin.WaitForSpans(t, 3))
+	rs, cs, ls := spans["otel.root"], spans["contrib.child"], spans["otel.leaf"]
+	require.NotNil(t, rs)
+	require.NotNil(t, cs)
+	require.NotNil(t, ls)
+
+	assert.Equal(rs.TraceID, cs.TraceID)
+	assert.Equal(rs.TraceID, ls.TraceID)
+	assert.Nil(rs.ParentID)
+	assert.Equal(rs.ID, *cs.ParentID)
+	assert.Equal(cs.ID, *ls.ParentID)
+
+	assert.Equal("SERVER", *rs.Kind)
+	assert.Nil(ls.Kind)
+	assert.Equal("test-library", rs.Tags[tagLibraryName])
+	assert.Equal("1.0", rs.Tags[tagLibraryVersion])
+
+	sc := root.SpanContext()
+	assert.True(sc.IsValid())
+	assert.True(sc.IsSampled())
+	assert.Equal(rs.ID, sc.SpanID().String())
+	assert.Equal(tracer.TraceIDHex(dd.Context()), rs.TraceID)
+}
+
+func TestOtelParentPrecedence(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	otr := NewTracerProvider().Tracer("test-library")
+	_, parent := otr.Start(context.Background(), "otel.parent")
+	dd, ctx := tracer.StartSpanFromContext(context.Background(), "contrib.other")
+	// the OpenTelemetry span set over the context wins over the other trace
+	_, child := otr.Start(trace.ContextWithSpan(ctx, parent), "otel.child")
+	// so does a remote span context
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    parent.SpanContext().TraceID(),
+		SpanID:     parent.SpanContext().SpanID(),
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, rchild := otr.Start(trace.ContextWithRemoteSpanContext(ctx, remote), "otel.remote.child")
+	child.End()
+	rchild.End()
+	dd.Finish()
+	parent.End()
+
+	tracer.ForceFlush()
+	spans := spansByName(zipkin.WaitForSpans(t, 4))
+	ps, cs, rs := spans["otel.parent"], spans["otel.child"], spans["otel.remote.child"]
+	require.NotNil(t, ps)
+	require.NotNil(t, cs)
+	require.NotNil(t, rs)
+	assert.Equal(ps.TraceID, cs.TraceID)
+	assert.Equal(ps.ID, *cs.ParentID)
+	assert.Equal(ps.TraceID, rs.TraceID)
+	assert.Equal(ps.ID, *rs.ParentID)
+}
+
+func TestSpan(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	_, s := NewTracerProvider().Tracer("").Start(context.Background(), "op",
+		trace.WithAttributes(attribute.String("string", "value"), attribute.Int64("int", 42)))
+	assert.True(s.IsRecording())
+	s.SetName("renamed")
+	s.SetAttributes(attribute.Bool("bool", true), attribute.StringSlice("slice", []string{"a", "b"}))
+	s.AddEvent("cache.miss", trace.WithAttributes(attribute.String("key", "k")), trace.WithTimestamp(time.Unix(5, 0)))
+	s.RecordError(errors.New("transient"))
+	s.SetStatus(codes.Error, "failed")
+	s.End()
+	s.End()
+	assert.False(s.IsRecording())
+
+	tracer.ForceFlush()
+	span := zipkin.WaitForSpans(t, 1)[0]
+	assert.Equal("renamed", *span.Name)
+	assert.Equal("value", span.Tags["string"])
+	assert.Equal("42", span.Tags["int"])
+	assert.Equal("true", span.Tags["bool"])
+	assert.Equal("[a b]", span.Tags["slice"])
+	assert.Equal("true", span.Tags[ext.Error])
+	assert.Equal("ERROR", span.Tags[ext.StatusCode])
+	assert.Equal("failed", span.Tags[ext.StatusDescription])
+	assert.NotContains(span.Tags, tagLibraryName)
+
+	require.Len(t, span.Annotations, 2)
+	assert.JSONEq(`{"event":"cache.miss","key":"k"}`, *span.Annotations[0].Value)
+	assert.Equal(int64(5000000), *span.Annotations[0].Timestamp)
+	assert.Contains(*span.Annotations[1].Value, "transient")
+}
+
+func TestSpanStatus(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	otr := NewTracerProvider().Tracer("")
+	_, errored := otr.Start(context.Background(), "errored")
+	errored.SetStatus(codes.Error, "failed")
+	errored.SetStatus(codes.Unset, "")
+	errored.End()
+	_, ok := otr.Start(context.Background(), "ok")
+	ok.SetStatus(codes.Ok, "")
+	ok.SetStatus(codes.Error, "ignored")
+	ok.End()
+
+	tracer.ForceFlush()
+	spans := zipkin.WaitForSpans(t, 2)
+	sort.Slice(spans, func(i, j int) bool { return *spans[i].Name < *spans[j].Name })
+	assert.Equal("ERROR", spans[0].Tags[ext.StatusCode])
+	assert.Equal("failed", spans[0].Tags[ext.StatusDescription])
+	assert.Equal("OK", spans[1].Tags[ext.StatusCode])
+	assert.NotContains(spans[1].Tags, ext.Error)
+}
+
+func TestRemoteParent(t *testing.T) {
+	assert := assert.New(t)
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	tid, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	sid, _ := trace.SpanIDFromHex("0102030405060708")
+	lsid, _ := trace.SpanIDFromHex("1112131415161718")
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	link := trace.Link{SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: lsid})}
+	// 64-bit trace IDs are formatted as the links of the tracer
+	tid64, _ := trace.TraceIDFromHex("0000000000000000090a0b0c0d0e0f10")
+	link64 := trace.Link{SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid64, SpanID: lsid})}
+
+	otr := NewTracerProvider().Tracer("test-library")
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), remote)
+	_, s := otr.Start(ctx, "child", trace.WithLinks(link, link64, trace.Link{}))
+	s.End()
+	_, r := otr.Start(ctx, "root", trace.WithNewRoot())
+	r.End()
+
+	assert.Equal(tid, s.SpanContext().TraceID())
+	assert.NotEqual(tid, r.SpanContext().TraceID())
+
+	tracer.ForceFlush()
+	spans := spansByName(zipkin.WaitForSpans(t, 2))
+	child := spans["child"]
+	require.NotNil(t, child)
+	assert.Equal("0102030405060708090a0b0c0d0e0f10", child.TraceID)
+	assert.Equal("0102030405060708", *child.ParentID)
+	assert.Equal("0102030405060708090a0b0c0d0e0f10:1112131415161718,090a0b0c0d0e0f10:1112131415161718", child.Tags[ext.SpanLinks])
+	assert.Nil(spans["root"].ParentID)
+}
+
+func TestWithTracer(t *testing.T) {
+	zipkin, stop := startZipkin(t)
+	defer stop()
+
+	tr := tracer.New(tracer.WithZipkin("other-service", zipkin.URL(), ""), tracer.WithServiceName("other-service"))
+	defer tr.Stop()
+
+	_, s := NewTracerProvider(WithTracer(tr)).Tracer("").Start(context.Background(), "op")
+	s.End()
+	tr.ForceFlush()
+
+	span := zipkin.WaitForSpans(t, 1)[0]
+	require.NotNil(t, span.LocalEndpoint)
+	assert.Equal(t, "other-service", *span.LocalEndpoint.ServiceName)
+}
@@ -0,0 +1,118 @@
+package otel
+
+import (
+	"sync"
+
+	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+// span implements trace.Span on top of a ddtrace.Span.
+type span struct {
+	ddspan   ddtrace.Span
+	provider *TracerProvider
+	sc       trace.SpanContext
+	// parent holds the context the span was started as the child of, if any.
+	parent ddtrace.SpanContext
+
+	mu     sync.RWMutex
+	ended  bool
+	status codes.Code
+}
+
+var _ trace.Span = (*span)(nil)
+
+// End finishes the span. Calls following the first one are ignored.
+func (s *span) End(opts ...trace.SpanEndOption) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.mu.Unlock()
+
+	cfg := trace.NewSpanEndConfig(opts...)
+	var ddopts []ddtrace.FinishOption
+	if ts := cfg.Timestamp(); !ts.IsZero() {
+		ddopts = append(ddopts, tracer.FinishTime(ts))
+	}
+	s.ddspan.FinishWithOptionsExt(ddopts...)
+}
+
+// AddEvent logs the event, its name held by the "event" field and its
+// attributes by the others. The event is logged at the time given with
+// trace.WithTimestamp, defaulting to the current time.
+func (s *span) AddEvent(name string, opts ...trace.EventOption) {
+	cfg := trace.NewEventConfig(opts...)
+	fields := make([]log.Field, 0, len(cfg.Attributes())+1)
+	fields = append(fields, log.String("event", name))
+	for _, kv := range cfg.Attributes() {
+		fields = append(fields, log.Object(string(kv.Key), tagValue(kv.Value)))
+	}
+	tracer.LogFieldsAt(s.ddspan, cfg.Timestamp(), fields...)
+}
+
+// IsRecording returns true until the span ends.
+func (s *span) IsRecording() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.ended
+}
+
+// RecordError logs an event describing err, without marking the span as
+// failed, which is done with SetStatus.
+func (s *span) RecordError(err error, opts ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+	cfg := trace.NewEventConfig(opts...)
+	var ddopts []ddtrace.ErrorOption
+	if ts := cfg.Timestamp(); !ts.IsZero() {
+		ddopts = append(ddopts, tracer.ErrorTime(ts))
+	}
+	tracer.RecordError(s.ddspan, err, ddopts...)
+}
+
+// SpanContext returns the OpenTelemetry span context of the span.
+func (s *span) SpanContext() trace.SpanContext {
+	return s.sc
+}
+
+// SetStatus sets the status of the span, codes.Error marking it as failed.
+// As specified by OpenTelemetry, codes.Unset is ignored and codes.Ok is final.
+func (s *span) SetStatus(code codes.Code, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code == codes.Unset || s.status == codes.Ok {
+		return
+	}
+	s.status = code
+	if code == codes.Ok {
+		tracer.SetStatus(s.ddspan, ddtrace.StatusOK, description)
+	} else {
+		tracer.SetStatus(s.ddspan, ddtrace.StatusError, description)
+	}
+}
+
+// SetName sets the operation name of the span.
+func (s *span) SetName(name string) {
+	s.ddspan.SetOperationName(name)
+}
+
+// SetAttributes sets the given attributes as tags of the span.
+func (s *span) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.ddspan.SetTag(string(a.Key), tagValue(a.Value))
+	}
+}
+
+// TracerProvider returns the provider of the tracer which started the span.
+func (s *span) TracerProvider() trace.TracerProvider {
+	return s.provider
+}
@@ -0,0 +1,29 @@
+package zap_test
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	zaptrace "github.com/signalfx/signalfx-go-tracing/contrib/go.uber.org/zap"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func Example() {
+	logger, _ := zap.NewProduction()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	defer span.Finish()
+
+	// Every entry will have the trace_id and span_id fields of span.
+	zaptrace.WithContext(ctx, logger).Info("handling request")
+}
+
+func ExampleFields() {
+	logger, _ := zap.NewProduction()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	defer span.Finish()
+
+	logger.Info("handling request", zaptrace.Fields(ctx)...)
+}
@@ -0,0 +1,11 @@
+module github.com/signalfx/signalfx-go-tracing/contrib/go.uber.org/zap
+
+go 1.12
+
+require (
+	github.com/signalfx/signalfx-go-tracing v1.12.0
+	github.com/stretchr/testify v1.7.0
+	go.uber.org/zap v1.21.0
+)
+
+replace github.com/signalfx/signalfx-go-tracing => ../../../
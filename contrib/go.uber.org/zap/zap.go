@@ -0,0 +1,36 @@
+// Package zap provides helpers linking zap (https://github.com/uber-go/zap)
+// log entries with the span active in a context.
+package zap // import "github.com/signalfx/signalfx-go-tracing/contrib/go.uber.org/zap"
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/signalfx/signalfx-go-tracing/tracing/correlation"
+)
+
+// Fields returns the trace_id, span_id, service.name and deployment.environment
+// fields of the span found in ctx. It returns nil if ctx holds no span.
+func Fields(ctx context.Context) []zap.Field {
+	f, ok := correlation.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	kvs := f.KeyValues()
+	fields := make([]zap.Field, 0, len(kvs)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		fields = append(fields, zap.String(kvs[i].(string), kvs[i+1].(string)))
+	}
+	return fields
+}
+
+// WithContext returns a child of l which adds the fields of the span found in
+// ctx to every entry. It returns l unchanged if ctx holds no span.
+func WithContext(ctx context.Context, l *zap.Logger) *zap.Logger {
+	fields := Fields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
@@ -0,0 +1,39 @@
+package zap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func TestWithContext(t *testing.T) {
+	assert := assert.New(t)
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+	tracer.Start(tracer.WithServiceName("svc"), tracer.WithZipkin("svc", zipkin.URL(), ""))
+	defer tracer.Stop()
+
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(core)
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "op")
+	defer span.Finish()
+
+	WithContext(ctx, l).Info("traced")
+	l.Info("fields", Fields(ctx)...)
+	for _, entry := range logs.TakeAll() {
+		fields := entry.ContextMap()
+		assert.Equal(tracer.TraceIDHex(span.Context()), fields["trace_id"])
+		assert.Equal(tracer.SpanIDHex(span.Context()), fields["span_id"])
+		assert.Equal("svc", fields["service.name"])
+	}
+
+	assert.Nil(Fields(context.Background()))
+	assert.Equal(l, WithContext(context.Background(), l))
+}
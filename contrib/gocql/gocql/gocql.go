@@ -82,6 +82,7 @@ func (tq *Query) newChildSpan(ctx context.Context) ddtrace.Span {
 	p := tq.params
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeCassandra),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ServiceName(p.config.serviceName),
 		tracer.ResourceName(p.config.resourceName),
 		tracer.Tag(ext.CassandraPaginated, fmt.Sprintf("%t", p.paginated)),
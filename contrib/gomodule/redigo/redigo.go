@@ -94,7 +94,7 @@ func (tc Conn) newChildSpan(ctx context.Context) ddtrace.Span {
 		tracer.SpanType(ext.SpanTypeRedis),
 		tracer.ServiceName(p.config.serviceName),
 		tracer.Tag(ext.DBType, "redis"),
-		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.SpanKind(tracer.KindClient),
 	}, p.config.spanOpts...)
 	if rate := p.config.analyticsRate; rate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
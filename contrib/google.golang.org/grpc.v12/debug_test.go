@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDebugHeader(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRigWithOpts(false, WithServiceName("grpc"), WithDebugHeader("X-Debug", nil))
+	require.NoError(t, err)
+	defer rig.Close()
+
+	for name, debug := range map[string]bool{"debug": true, "regular": false} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			defer mt.Reset()
+			md := metadata.Pairs("x-user", "alice")
+			if debug {
+				md["x-debug"] = []string{"1"}
+			}
+			var header metadata.MD
+			resp, err := rig.client.Ping(metadata.NewContext(context.Background(), md), &FixtureRequest{Name: "pass"}, grpc.Header(&header))
+			require.NoError(t, err)
+			assert.Equal("passed", resp.Message)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			if !debug {
+				assert.Nil(span.Tag(ext.DebugRequest))
+				assert.Nil(span.Tag("grpc.metadata.x-user"))
+				assert.Empty(header["x-sf-trace-id"])
+				return
+			}
+			assert.Equal(true, span.Tag(ext.ManualKeep))
+			assert.Equal(true, span.Tag(ext.DebugRequest))
+			assert.Equal("alice", span.Tag("grpc.metadata.x-user"))
+			assert.Contains(span.Tag("grpc.request"), `name:"pass"`)
+			assert.Contains(span.Tag("grpc.response"), `message:"passed"`)
+			assert.Equal([]string{fmt.Sprintf("%016x", span.TraceID())}, header["x-sf-trace-id"])
+		})
+	}
+}
@@ -4,9 +4,13 @@
 package grpc // import "github.com/signalfx/signalfx-go-tracing/contrib/google.golang.org/grpc.v12"
 
 import (
+	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/signalfx/signalfx-go-tracing/contrib/google.golang.org/grpc.v12/internal/grpcutil"
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
@@ -18,6 +22,9 @@ import (
 )
 
 // UnaryServerInterceptor will trace requests to the given grpc server.
+// Calls carrying the trusted debug header configured by the environment or WithDebugHeader
+// are force-sampled with their metadata and messages captured, and their trace ID is
+// echoed in the x-sf-trace-id header metadata.
 func UnaryServerInterceptor(opts ...InterceptorOption) grpc.UnaryServerInterceptor {
 	cfg := new(interceptorConfig)
 	defaults(cfg)
@@ -27,29 +34,63 @@ func UnaryServerInterceptor(opts ...InterceptorOption) grpc.UnaryServerIntercept
 	if cfg.serviceName == "" {
 		cfg.serviceName = "grpc.server"
 	}
+	dbg := cfg.debug
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		span, ctx := startSpanFromContext(ctx, info.FullMethod, cfg.serviceName, cfg.analyticsRate)
+		span, ctx, debug := startSpanFromContext(ctx, info.FullMethod, cfg.serviceName, cfg.analyticsRate, dbg)
+		if debug {
+			md, _ := metadata.FromContext(ctx)
+			dbg.SetHeaderTags(span, "grpc.metadata.", md)
+			grpc.SetHeader(ctx, metadata.Pairs(debugtrace.ResponseHeader, debugtrace.TraceID(span.Context())))
+			setMessageTag(span, dbg, "grpc.request", req)
+		}
 		resp, err := handler(ctx, req)
+		if debug {
+			setMessageTag(span, dbg, "grpc.response", resp)
+		}
 		span.FinishWithOptionsExt(tracer.WithError(err))
 		return resp, err
 	}
 }
 
-func startSpanFromContext(ctx context.Context, method, service string, rate float64) (ddtrace.Span, context.Context) {
+// startSpanFromContext starts the span of a server call. It reports whether
+// the call carries the trusted debug header, its span being force-sampled.
+func startSpanFromContext(
+	ctx context.Context, method, service string, rate float64, dbg debugtrace.Config,
+) (ddtrace.Span, context.Context, bool) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(service),
 		tracer.ResourceName(method),
 		tracer.Tag(tagMethod, method),
 		tracer.SpanType(ext.AppTypeRPC),
+		tracer.SpanKind(tracer.KindServer),
 	}
 	if rate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
 	}
 	md, _ := metadata.FromContext(ctx) // nil is ok
-	if sctx, err := tracer.Extract(grpcutil.MDCarrier(md)); err == nil {
+	sctx, err := tracer.Extract(grpcutil.MDCarrier(md))
+	if err == nil {
 		opts = append(opts, tracer.ChildOf(sctx))
+	} else {
+		sctx = nil
+	}
+	debug := dbg.Trusted(md[strings.ToLower(dbg.Header)], time.Now())
+	if debug {
+		opts = append(opts, debugtrace.StartOptions(sctx)...)
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, "grpc.server", opts...)
+	return span, ctx, debug
+}
+
+// setMessageTag sets the tag key of span to the text of a message of a
+// debugged call, within the body limit.
+func setMessageTag(span ddtrace.Span, dbg debugtrace.Config, key string, msg interface{}) {
+	if dbg.BodyLimit == 0 || msg == nil {
+		return
 	}
-	return tracer.StartSpanFromContext(ctx, "grpc.server", opts...)
+	b := debugtrace.NewBody(dbg.BodyLimit)
+	fmt.Fprint(b, msg)
+	b.SetTag(span, key)
 }
 
 // UnaryClientInterceptor will add tracing to a gprc client.
@@ -70,6 +111,7 @@ func UnaryClientInterceptor(opts ...InterceptorOption) grpc.UnaryClientIntercept
 		spanopts := []ddtrace.StartSpanOption{
 			tracer.Tag(tagMethod, method),
 			tracer.SpanType(ext.AppTypeRPC),
+			tracer.SpanKind(tracer.KindClient),
 		}
 		if cfg.analyticsRate > 0 {
 			spanopts = append(spanopts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
@@ -1,8 +1,11 @@
 package grpc
 
+import "github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
+
 type interceptorConfig struct {
 	serviceName   string
 	analyticsRate float64
+	debug         debugtrace.Config
 }
 
 // InterceptorOption represents an option that can be passed to the grpc unary
@@ -12,6 +15,7 @@ type InterceptorOption func(*interceptorConfig)
 func defaults(cfg *interceptorConfig) {
 	// cfg.serviceName default set in interceptor
 	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.debug = debugtrace.Env()
 }
 
 // WithServiceName sets the given service name for the intercepted client.
@@ -36,3 +40,15 @@ func WithAnalyticsRate(rate float64) InterceptorOption {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithDebugHeader force-samples the calls carrying the given debug header metadata,
+// capturing their metadata and messages and echoing their trace ID in the
+// x-sf-trace-id header metadata. When secret is not empty, the header values must be
+// signed with it. It overrides the SIGNALFX_TRACE_DEBUG_HEADER and
+// SIGNALFX_TRACE_DEBUG_SECRET environment variables; an empty header disables
+// debugging. This option only applies to the server interceptor.
+func WithDebugHeader(header string, secret []byte) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		cfg.debug = debugtrace.New(header, secret)
+	}
+}
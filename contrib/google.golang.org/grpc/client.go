@@ -26,6 +26,7 @@ func (cs *clientStream) RecvMsg(m interface{}) (err error) {
 			cs.Context(),
 			cs.method,
 			"grpc.message",
+			tracer.KindInternal,
 			cs.cfg.clientServiceName(),
 			cs.cfg.analyticsRate,
 		)
@@ -45,6 +46,7 @@ func (cs *clientStream) SendMsg(m interface{}) (err error) {
 			cs.Context(),
 			cs.method,
 			"grpc.message",
+			tracer.KindInternal,
 			cs.cfg.clientServiceName(),
 			cs.cfg.analyticsRate,
 		)
@@ -141,12 +143,11 @@ func doClientRequest(
 		ctx,
 		method,
 		"grpc.client",
+		tracer.KindClient,
 		cfg.clientServiceName(),
 		cfg.analyticsRate,
 	)
 
-	span.SetTag(ext.SpanKind, ext.SpanKindClient)
-
 	ctx = injectSpanIntoContext(ctx)
 
 	// fill in the peer so we can add it to the tags
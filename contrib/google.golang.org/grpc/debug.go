@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/contrib/google.golang.org/grpc/internal/grpcutil"
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// debugOptions returns the options starting the server span of a call
+// carrying the trusted debug header, or nil if the call is not debugged.
+func debugOptions(ctx context.Context, dbg debugtrace.Config) []ddtrace.StartSpanOption {
+	if !dbg.Enabled() {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx) // nil is ok
+	if !dbg.Trusted(md.Get(dbg.Header), time.Now()) {
+		return nil
+	}
+	parent, err := tracer.Extract(grpcutil.MDCarrier(md))
+	if err != nil {
+		parent = nil
+	}
+	return debugtrace.StartOptions(parent)
+}
+
+// startDebug captures the metadata of a debugged call and echoes its trace ID
+// with setHeader.
+func startDebug(ctx context.Context, span ddtrace.Span, dbg debugtrace.Config, setHeader func(metadata.MD) error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	dbg.SetHeaderTags(span, "grpc.metadata.", md)
+	setHeader(metadata.Pairs(debugtrace.ResponseHeader, debugtrace.TraceID(span.Context())))
+}
+
+// setMessageTag sets the tag key of span to the text of a message of a
+// debugged call, within the body limit.
+func setMessageTag(span ddtrace.Span, dbg debugtrace.Config, key string, msg interface{}) {
+	if dbg.BodyLimit == 0 || msg == nil {
+		return
+	}
+	b := debugtrace.NewBody(dbg.BodyLimit)
+	fmt.Fprint(b, msg)
+	b.SetTag(span, key)
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
+
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDebugHeader(t *testing.T) {
+	debug := WithDebugHeader("X-Debug", []byte("secret"))
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(false, debug)
+	require.NoError(t, err)
+	defer rig.Close()
+
+	serverSpan := func() mocktracer.Span {
+		waitForSpans(mt, 1, time.Second)
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		mt.Reset()
+		return spans[0]
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			"x-debug", debugtrace.Sign([]byte("secret"), time.Now()),
+			"x-user", "alice")
+		var header metadata.MD
+		resp, err := rig.client.Ping(ctx, &FixtureRequest{Name: "pass"}, grpc.Header(&header))
+		require.NoError(t, err)
+		assert.Equal("passed", resp.Message)
+
+		span := serverSpan()
+		assert.Equal("grpc.server", span.OperationName())
+		assert.Equal(true, span.Tag(ext.ManualKeep))
+		assert.Equal(true, span.Tag(ext.DebugRequest))
+		assert.Equal("alice", span.Tag("grpc.metadata.x-user"))
+		assert.Equal("<redacted>", span.Tag("grpc.metadata.x-debug"))
+		assert.Contains(span.Tag("grpc.request"), `name:"pass"`)
+		assert.Contains(span.Tag("grpc.response"), `message:"passed"`)
+		assert.Equal([]string{fmt.Sprintf("%016x", span.TraceID())}, header.Get(debugtrace.ResponseHeader))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-debug", debugtrace.Sign([]byte("secret"), time.Now()))
+		rig, err := newRig(false, debug, WithStreamMessages(false))
+		require.NoError(t, err)
+		defer rig.Close()
+
+		stream, err := rig.client.StreamPing(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&FixtureRequest{Name: "pass"}))
+		_, err = stream.Recv()
+		require.NoError(t, err)
+		header, err := stream.Header()
+		require.NoError(t, err)
+		stream.CloseSend()
+		// to flush the spans
+		stream.Recv()
+
+		span := serverSpan()
+		assert.Equal(true, span.Tag(ext.DebugRequest))
+		assert.Equal([]string{fmt.Sprintf("%016x", span.TraceID())}, header.Get(debugtrace.ResponseHeader))
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-debug", "1")
+		var header metadata.MD
+		_, err := rig.client.Ping(ctx, &FixtureRequest{Name: "pass"}, grpc.Header(&header))
+		require.NoError(t, err)
+
+		span := serverSpan()
+		assert.Nil(span.Tag(ext.ManualKeep))
+		assert.Nil(span.Tag(ext.DebugRequest))
+		assert.Nil(span.Tag("grpc.request"))
+		assert.Empty(header.Get(debugtrace.ResponseHeader))
+	})
+}
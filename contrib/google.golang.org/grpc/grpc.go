@@ -18,9 +18,10 @@ import (
 )
 
 func startSpanFromContext(
-	ctx context.Context, method, operation, service string, rate float64,
+	ctx context.Context, method, operation string, kind tracer.Kind, service string, rate float64, extra ...ddtrace.StartSpanOption,
 ) (ddtrace.Span, context.Context) {
 	opts := []ddtrace.StartSpanOption{
+		tracer.SpanKind(kind),
 		tracer.ServiceName(service),
 		tracer.ResourceName(method),
 		tracer.Tag(tagMethod, method),
@@ -33,6 +34,7 @@ func startSpanFromContext(
 	if sctx, err := tracer.Extract(grpcutil.MDCarrier(md)); err == nil {
 		opts = append(opts, tracer.ChildOf(sctx))
 	}
+	opts = append(opts, extra...)
 	return tracer.StartSpanFromContext(ctx, operation, opts...)
 }
 
@@ -1,6 +1,8 @@
 package grpc
 
 import (
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
+
 	"google.golang.org/grpc/codes"
 )
 
@@ -15,6 +17,7 @@ type config struct {
 	traceStreamCalls    bool
 	traceStreamMessages bool
 	noDebugStack        bool
+	debug               debugtrace.Config
 }
 
 func (cfg *config) serverServiceName() string {
@@ -41,6 +44,7 @@ func defaults(cfg *config) {
 	cfg.traceStreamCalls = true
 	cfg.traceStreamMessages = true
 	cfg.nonErrorCodes = map[codes.Code]bool{codes.Canceled: true}
+	cfg.debug = debugtrace.Env()
 	// cfg.analyticsRate = globalconfig.AnalyticsRate()
 }
 
@@ -101,3 +105,15 @@ func WithAnalyticsRate(rate float64) Option {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithDebugHeader force-samples the calls carrying the given debug header metadata,
+// capturing their metadata and messages and echoing their trace ID in the
+// x-sf-trace-id header metadata. When secret is not empty, the header values must be
+// signed with it. It overrides the SIGNALFX_TRACE_DEBUG_HEADER and
+// SIGNALFX_TRACE_DEBUG_SECRET environment variables; an empty header disables
+// debugging. This option only applies to the server interceptors.
+func WithDebugHeader(header string, secret []byte) Option {
+	return func(cfg *config) {
+		cfg.debug = debugtrace.New(header, secret)
+	}
+}
@@ -2,9 +2,10 @@ package grpc
 
 import (
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
-	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 type serverStream struct {
@@ -32,6 +33,7 @@ func (ss *serverStream) RecvMsg(m interface{}) (err error) {
 			ss.ctx,
 			ss.method,
 			"grpc.message",
+			tracer.KindInternal,
 			ss.cfg.serverServiceName(),
 			ss.cfg.analyticsRate,
 		)
@@ -48,6 +50,7 @@ func (ss *serverStream) SendMsg(m interface{}) (err error) {
 			ss.ctx,
 			ss.method,
 			"grpc.message",
+			tracer.KindInternal,
 			ss.cfg.serverServiceName(),
 			ss.cfg.analyticsRate,
 		)
@@ -59,6 +62,9 @@ func (ss *serverStream) SendMsg(m interface{}) (err error) {
 }
 
 // StreamServerInterceptor will trace streaming requests to the given gRPC server.
+// Calls carrying the trusted debug header configured by the environment or WithDebugHeader
+// are force-sampled with their metadata captured, and their trace ID is echoed in the
+// x-sf-trace-id header metadata.
 func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 	cfg := new(config)
 	defaults(cfg)
@@ -68,21 +74,26 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 	if cfg.serviceName == "" {
 		cfg.serviceName = "grpc.server"
 	}
+	dbg := cfg.debug
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
 		ctx := ss.Context()
 
 		// if we've enabled call tracing, create a span
 		if cfg.traceStreamCalls {
 			var span ddtrace.Span
+			debugOpts := debugOptions(ctx, dbg)
 			span, ctx = startSpanFromContext(
 				ctx,
 				info.FullMethod,
 				"grpc.server",
+				tracer.KindServer,
 				cfg.serviceName,
 				cfg.analyticsRate,
+				debugOpts...,
 			)
-
-			span.SetTag(ext.SpanKind, ext.SpanKindServer)
+			if debugOpts != nil {
+				startDebug(ctx, span, dbg, ss.SetHeader)
+			}
 
 			defer func() { finishWithError(span, err, cfg) }()
 		}
@@ -101,24 +112,36 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 }
 
 // UnaryServerInterceptor will trace requests to the given grpc server.
+// Calls carrying the trusted debug header configured by the environment or WithDebugHeader
+// are force-sampled with their metadata and messages captured, and their trace ID is
+// echoed in the x-sf-trace-id header metadata.
 func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
 	cfg := new(config)
 	defaults(cfg)
 	for _, fn := range opts {
 		fn(cfg)
 	}
+	dbg := cfg.debug
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		debugOpts := debugOptions(ctx, dbg)
 		span, ctx := startSpanFromContext(
 			ctx,
 			info.FullMethod,
 			"grpc.server",
+			tracer.KindServer,
 			cfg.serverServiceName(),
 			cfg.analyticsRate,
+			debugOpts...,
 		)
-
-		span.SetTag(ext.SpanKind, ext.SpanKindServer)
+		if debugOpts != nil {
+			startDebug(ctx, span, dbg, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) })
+			setMessageTag(span, dbg, "grpc.request", req)
+		}
 
 		resp, err := handler(ctx, req)
+		if debugOpts != nil {
+			setMessageTag(span, dbg, "grpc.response", resp)
+		}
 		finishWithError(span, err, cfg)
 		return resp, err
 	}
@@ -26,14 +26,14 @@ type clientStatsHandler struct{ cfg *config }
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *clientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	span, ctx := startSpanFromContext(
+	_, ctx = startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
 		"grpc.client",
+		tracer.KindClient,
 		h.cfg.clientServiceName(),
 		h.cfg.analyticsRate,
 	)
-	span.SetTag(ext.SpanKind, ext.SpanKindClient)
 	ctx = injectSpanIntoContext(ctx)
 	return ctx
 }
@@ -4,7 +4,6 @@ import (
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc/stats"
 
-	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
 )
 
@@ -26,14 +25,14 @@ type serverStatsHandler struct {
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *serverStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	span, ctx := startSpanFromContext(
+	_, ctx = startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
 		"grpc.server",
+		tracer.KindServer,
 		h.cfg.serverServiceName(),
 		h.cfg.analyticsRate,
 	)
-	span.SetTag(ext.SpanKind, ext.SpanKindServer)
 	return ctx
 }
 
@@ -101,5 +101,5 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 	spanopts = append(spanopts, r.config.spanOpts...)
-	httputil.TraceAndServe(r.Router, w, req, r.config.serviceName, route, spanopts...)
+	httputil.TraceAndServeWithDebug(r.Router, w, req, r.config.serviceName, route, r.config.debug, spanopts...)
 }
@@ -112,6 +112,23 @@ func TestSpanOptions(t *testing.T) {
 	assert.Equal(2, spans[0].Tag(ext.SamplingPriority))
 }
 
+func TestWithDebugHeader(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	mux := NewRouter(WithDebugHeader("X-Debug", nil))
+	mux.Handle("/200", okHandler())
+	r := httptest.NewRequest("GET", "http://localhost/200", nil)
+	r.Header.Set("X-Debug", "1")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	spans := mt.FinishedSpans()
+	assert.Equal(1, len(spans))
+	assert.Equal(true, spans[0].Tag(ext.DebugRequest))
+	assert.NotEmpty(w.Header().Get("X-SF-Trace-Id"))
+}
+
 // TestImplementingMethods is a regression tests asserting that all the mux.Router methods
 // returning the router will return the modified traced version of it and not the original
 // router.
@@ -1,6 +1,7 @@
 package mux
 
 import (
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/internal/globalconfig"
 )
@@ -9,6 +10,7 @@ type routerConfig struct {
 	serviceName   string
 	spanOpts      []ddtrace.StartSpanOption // additional span options to be applied
 	analyticsRate float64
+	debug         debugtrace.Config
 }
 
 // RouterOption represents an option that can be passed to NewRouter.
@@ -17,6 +19,7 @@ type RouterOption func(*routerConfig)
 func defaults(cfg *routerConfig) {
 	cfg.analyticsRate = globalconfig.AnalyticsRate()
 	cfg.serviceName = "mux.router"
+	cfg.debug = debugtrace.Env()
 }
 
 // WithServiceName sets the given service name for the router.
@@ -49,3 +52,12 @@ func WithAnalyticsRate(rate float64) RouterOption {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithDebugHeader force-samples the requests carrying the given debug header,
+// as documented by the net/http integration, instead of the header and secret
+// set by the environment. An empty header disables debugging.
+func WithDebugHeader(header string, secret []byte) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.debug = debugtrace.New(header, secret)
+	}
+}
@@ -36,6 +36,7 @@ var _ trace.Tracer = (*Tracer)(nil)
 func (t *Tracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, trace.TraceQueryFinishFunc) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(t.cfg.serviceName),
+		tracer.SpanKind(tracer.KindInternal),
 		tracer.Tag(tagGraphqlQuery, queryString),
 	}
 	if t.cfg.analyticsRate > 0 {
@@ -61,6 +62,7 @@ func (t *Tracer) TraceQuery(ctx context.Context, queryString string, operationNa
 func (t *Tracer) TraceField(ctx context.Context, label string, typeName string, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(t.cfg.serviceName),
+		tracer.SpanKind(tracer.KindInternal),
 		tracer.Tag(tagGraphqlField, fieldName),
 		tracer.Tag(tagGraphqlType, typeName),
 	}
@@ -0,0 +1,244 @@
+// Package debugtrace force-samples the server requests carrying a trusted
+// debug header and captures their details, to debug production requests.
+//
+// By default, the feature is disabled unless SIGNALFX_TRACE_DEBUG_HEADER names
+// the debug header. When SIGNALFX_TRACE_DEBUG_SECRET is set, the header must
+// hold a value signed with it, as returned by Sign, otherwise any value is
+// trusted. SIGNALFX_TRACE_DEBUG_BODY_LIMIT bounds the size of the captured
+// bodies. Integrations may override the header and secret with an option.
+package debugtrace // import "github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+const (
+	envHeader    = "SIGNALFX_TRACE_DEBUG_HEADER"
+	envSecret    = "SIGNALFX_TRACE_DEBUG_SECRET"
+	envBodyLimit = "SIGNALFX_TRACE_DEBUG_BODY_LIMIT"
+)
+
+// DefaultBodyLimit is the default number of bytes of bodies captured.
+const DefaultBodyLimit = 4096
+
+// MaxSkew is how far from the current time the signature of a debug header
+// may have been made.
+const MaxSkew = 5 * time.Minute
+
+// ResponseHeader is the response header, or gRPC header metadata, echoing the
+// trace ID of debugged requests.
+const ResponseHeader = "X-SF-Trace-Id"
+
+// redacted holds the lowercase names of the headers whose values are not
+// captured.
+var redacted = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-sf-token":          true,
+}
+
+// Config configures the debugging of requests.
+type Config struct {
+	// Header is the name of the debug header. Debugging is disabled when
+	// it is empty.
+	Header string
+
+	// Secret, when set, is the secret the values of the debug header are
+	// signed with.
+	Secret []byte
+
+	// BodyLimit is the number of bytes of bodies captured.
+	BodyLimit int
+}
+
+// New returns the configuration debugging the requests carrying header, whose
+// values must be signed with secret unless it is empty. The body limit is set
+// by the environment.
+func New(header string, secret []byte) Config {
+	cfg := Config{Header: strings.TrimSpace(header), BodyLimit: DefaultBodyLimit}
+	if len(secret) > 0 {
+		cfg.Secret = secret
+	}
+	if n, err := strconv.Atoi(os.Getenv(envBodyLimit)); err == nil && n >= 0 {
+		cfg.BodyLimit = n
+	}
+	return cfg
+}
+
+// FromEnv returns the configuration set by the environment.
+func FromEnv() Config {
+	return New(os.Getenv(envHeader), []byte(os.Getenv(envSecret)))
+}
+
+var (
+	envOnce   sync.Once
+	envConfig Config
+)
+
+// Env returns the configuration set by the environment, read on first use.
+func Env() Config {
+	envOnce.Do(func() { envConfig = FromEnv() })
+	return envConfig
+}
+
+// Enabled reports whether requests can be debugged.
+func (c Config) Enabled() bool {
+	return c.Header != ""
+}
+
+// Trusted reports whether a request whose debug header holds the given values
+// is to be debugged.
+func (c Config) Trusted(values []string, now time.Time) bool {
+	if !c.Enabled() {
+		return false
+	}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if len(c.Secret) == 0 || verify(c.Secret, v, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns a value of the debug header signed with secret at t, made of
+// the Unix time in seconds and of the hex encoded HMAC-SHA256 of it,
+// separated by a colon.
+func Sign(secret []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return ts + ":" + hex.EncodeToString(signature(secret, ts))
+}
+
+func signature(secret []byte, ts string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	return mac.Sum(nil)
+}
+
+// verify reports whether value was signed with secret within MaxSkew of now.
+func verify(secret []byte, value string, now time.Time) bool {
+	i := strings.IndexByte(value, ':')
+	if i < 0 {
+		return false
+	}
+	ts, sig := value[:i], value[i+1:]
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > MaxSkew || d < -MaxSkew {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	return err == nil && hmac.Equal(got, signature(secret, ts))
+}
+
+// StartOptions returns the options starting the server span of a debugged
+// request with a PriorityUserKeep sampling priority. They take precedence
+// over the options preceding them, such as a tracer.ChildOf option for
+// parent, which may be nil.
+func StartOptions(parent ddtrace.SpanContext) []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.Tag(ext.ManualKeep, true),
+		tracer.Tag(ext.DebugRequest, true),
+	}
+	if parent != nil && tracer.TraceID(parent) != 0 {
+		// the sampling priority of remote parents can't be changed once
+		// extracted, start from a copy kept by the user instead
+		priority := ext.PriorityUserKeep
+		cfg := tracer.SpanContextConfig{
+			TraceIDHigh:      tracer.TraceIDHigh(parent),
+			TraceID:          tracer.TraceID(parent),
+			SpanID:           tracer.SpanID(parent),
+			SamplingPriority: &priority,
+		}
+		parent.ForeachBaggageItem(func(k, v string) bool {
+			if cfg.Baggage == nil {
+				cfg.Baggage = make(map[string]string)
+			}
+			cfg.Baggage[k] = v
+			return true
+		})
+		opts = append(opts, tracer.ChildOf(tracer.NewSpanContext(cfg)))
+	}
+	return opts
+}
+
+// TraceID returns the hex encoded trace ID of ctx, echoed in ResponseHeader.
+func TraceID(ctx ddtrace.SpanContext) string {
+	if id := tracer.TraceIDHex(ctx); id != "" {
+		return id
+	}
+	if c, ok := ctx.(interface{ TraceID() uint64 }); ok {
+		// foreign contexts, such as the ones of the mock tracer
+		return fmt.Sprintf("%016x", c.TraceID())
+	}
+	return ""
+}
+
+// SetHeaderTags sets a tag on span for each header, named after prefix and
+// the lowercase name of the header. Credentials and the debug header are
+// redacted.
+func (c Config) SetHeaderTags(span ddtrace.Span, prefix string, header map[string][]string) {
+	for k, v := range header {
+		name := strings.ToLower(k)
+		value := strings.Join(v, ",")
+		if redacted[name] || strings.EqualFold(k, c.Header) {
+			value = "<redacted>"
+		}
+		span.SetTag(prefix+name, value)
+	}
+}
+
+// Body captures the first bytes of a body.
+type Body struct {
+	limit     int
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// NewBody returns a Body capturing up to limit bytes.
+func NewBody(limit int) *Body {
+	return &Body{limit: limit}
+}
+
+// Write captures the bytes of p within the limit. It never fails.
+func (b *Body) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.buf.Len(); n > room {
+		p = p[:room]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// SetTag sets the captured bytes as the tag key of span, along with a
+// "<key>.truncated" tag when bytes were not captured.
+func (b *Body) SetTag(span ddtrace.Span, key string) {
+	if b.buf.Len() == 0 && !b.truncated {
+		return
+	}
+	span.SetTag(key, b.buf.String())
+	if b.truncated {
+		span.SetTag(key+".truncated", true)
+	}
+}
@@ -0,0 +1,168 @@
+package debugtrace
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func TestFromEnv(t *testing.T) {
+	defer os.Unsetenv(envHeader)
+	defer os.Unsetenv(envSecret)
+	defer os.Unsetenv(envBodyLimit)
+
+	assert.False(t, FromEnv().Enabled())
+
+	os.Setenv(envHeader, "X-Debug")
+	assert.Equal(t, Config{Header: "X-Debug", BodyLimit: DefaultBodyLimit}, FromEnv())
+
+	os.Setenv(envSecret, "secret")
+	os.Setenv(envBodyLimit, "0")
+	assert.Equal(t, Config{Header: "X-Debug", Secret: []byte("secret")}, FromEnv())
+
+	os.Setenv(envBodyLimit, "-1")
+	assert.Equal(t, DefaultBodyLimit, FromEnv().BodyLimit)
+}
+
+func TestNew(t *testing.T) {
+	defer os.Unsetenv(envBodyLimit)
+
+	assert.Equal(t, Config{Header: "X-Debug", BodyLimit: DefaultBodyLimit}, New(" X-Debug ", nil))
+	assert.False(t, New("", []byte("secret")).Enabled())
+
+	os.Setenv(envBodyLimit, "10")
+	assert.Equal(t, Config{Header: "X-Debug", Secret: []byte("secret"), BodyLimit: 10}, New("X-Debug", []byte("secret")))
+}
+
+func TestTrusted(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	secret := []byte("secret")
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.False(t, Config{}.Trusted([]string{"1"}, now))
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		cfg := Config{Header: "X-Debug"}
+		assert.True(t, cfg.Trusted([]string{"1"}, now))
+		assert.False(t, cfg.Trusted([]string{" "}, now))
+		assert.False(t, cfg.Trusted(nil, now))
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		cfg := Config{Header: "X-Debug", Secret: secret}
+		value := Sign(secret, now)
+		assert.True(t, strings.HasPrefix(value, "1600000000:"))
+		assert.True(t, cfg.Trusted([]string{value}, now))
+		assert.True(t, cfg.Trusted([]string{"1", value}, now.Add(MaxSkew)))
+		assert.True(t, cfg.Trusted([]string{value}, now.Add(-MaxSkew)))
+
+		for name, values := range map[string][]string{
+			"unsigned":       {"1"},
+			"expired":        {Sign(secret, now.Add(-MaxSkew-time.Second))},
+			"future":         {Sign(secret, now.Add(MaxSkew+time.Second))},
+			"other-secret":   {Sign([]byte("other"), now)},
+			"bad-signature":  {value[:len(value)-2] + "zz"},
+			"bad-timestamp":  {"x" + value},
+			"other-time-sig": {"1600000001" + value[strings.IndexByte(value, ':'):]},
+		} {
+			assert.False(t, cfg.Trusted(values, now), name)
+		}
+	})
+}
+
+func TestStartOptions(t *testing.T) {
+	tr := tracer.New(tracer.WithConsoleExporter(ioutil.Discard, tracer.ConsoleJSON))
+	defer tr.Stop()
+
+	t.Run("root", func(t *testing.T) {
+		span := tr.StartSpan("root", StartOptions(nil)...)
+		defer span.Finish()
+		p, ok := tracer.SamplingPriority(span.Context())
+		assert.True(t, ok)
+		assert.Equal(t, ext.PriorityUserKeep, p)
+	})
+
+	t.Run("remote-parent", func(t *testing.T) {
+		reject := ext.PriorityUserReject
+		parent := tracer.NewSpanContext(tracer.SpanContextConfig{
+			TraceIDHigh:      1,
+			TraceID:          2,
+			SpanID:           3,
+			SamplingPriority: &reject,
+			Baggage:          map[string]string{"user": "alice"},
+		})
+		// the priority of the remote parent is not changed by tags
+		span := tr.StartSpan("child", tracer.ChildOf(parent), tracer.Tag(ext.ManualKeep, true))
+		p, _ := tracer.SamplingPriority(span.Context())
+		assert.Equal(t, ext.PriorityUserReject, p)
+		span.Finish()
+
+		opts := append([]tracer.StartSpanOption{tracer.ChildOf(parent)}, StartOptions(parent)...)
+		span = tr.StartSpan("child", opts...)
+		defer span.Finish()
+		p, _ = tracer.SamplingPriority(span.Context())
+		assert.Equal(t, ext.PriorityUserKeep, p)
+		assert.Equal(t, uint64(1), tracer.TraceIDHigh(span.Context()))
+		assert.Equal(t, uint64(2), tracer.TraceID(span.Context()))
+		assert.Equal(t, "alice", span.BaggageItem("user"))
+		assert.Equal(t, "00000000000000010000000000000002", TraceID(span.Context()))
+	})
+}
+
+func TestSetHeaderTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("op")
+	Config{Header: "X-Debug"}.SetHeaderTags(span, "http.request.header.", http.Header{
+		"Accept":        {"text/plain", "text/html"},
+		"Authorization": {"Bearer token"},
+		"X-Debug":       {"1"},
+	})
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	assert.Equal(t, "text/plain,text/html", s.Tag("http.request.header.accept"))
+	assert.Equal(t, "<redacted>", s.Tag("http.request.header.authorization"))
+	assert.Equal(t, "<redacted>", s.Tag("http.request.header.x-debug"))
+	assert.Equal(t, fmt.Sprintf("%016x", s.TraceID()), TraceID(s.Context()))
+}
+
+func TestBody(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	span := tracer.StartSpan("op")
+
+	b := NewBody(5)
+	n, err := b.Write([]byte("abc"))
+	assert.Equal(t, 3, n)
+	assert.NoError(t, err)
+	n, _ = b.Write([]byte("defg"))
+	assert.Equal(t, 4, n)
+	b.Write([]byte("h"))
+	b.SetTag(span, "body")
+
+	NewBody(5).SetTag(span, "empty")
+	full := NewBody(3)
+	full.Write([]byte("abc"))
+	full.SetTag(span, "full")
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	assert.Equal(t, "abcde", s.Tag("body"))
+	assert.Equal(t, true, s.Tag("body.truncated"))
+	assert.Nil(t, s.Tag("empty"))
+	assert.Equal(t, "abc", s.Tag("full"))
+	assert.Nil(t, s.Tag("full.truncated"))
+}
@@ -31,6 +31,7 @@ var tpl = `// Code generated by make_responsewriter.go DO NOT EDIT
 package httputil
 
 import (
+	"io"
 	"net/http"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 )
@@ -39,16 +40,17 @@ import (
 // trace the http response codes. It also checks for various http interfaces
 // (Flusher, Pusher, CloseNotifier, Hijacker) and if the underlying
 // http.ResponseWriter implements them it generates an unnamed struct with the
-// appropriate fields.
+// appropriate fields. When body is not nil, the response body is also written
+// to it.
 //
 // This code is generated because we have to account for all the permutations
 // of the interfaces.
-func wrapResponseWriter(w http.ResponseWriter, span ddtrace.Span) http.ResponseWriter {
+func wrapResponseWriter(w http.ResponseWriter, span ddtrace.Span, body io.Writer) http.ResponseWriter {
 {{- range .Interfaces }}
 	h{{.}}, ok{{.}} := w.(http.{{.}})
 {{- end }}
 
-	w = newResponseWriter(w, span)
+	w = newResponseWriter(w, span, body)
 	switch {
 {{- range .Combinations }}
 	{{- range . }}
@@ -4,19 +4,29 @@ package httputil // import "github.com/signalfx/signalfx-go-tracing/contrib/inte
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
 )
 
 // TraceAndServe will apply tracing to the given http.Handler using the passed tracer under the given service and resource.
+// Requests carrying the trusted debug header configured by the environment, as described in package debugtrace, are
+// force-sampled with their headers and bodies captured, and their trace ID is echoed in the X-SF-Trace-Id header.
 func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, service, resource string, spanopts ...ddtrace.StartSpanOption) {
+	TraceAndServeWithDebug(h, w, r, service, resource, debugtrace.Env(), spanopts...)
+}
+
+// TraceAndServeWithDebug is TraceAndServe, debugging the requests as configured by dbg rather than by the environment.
+func TraceAndServeWithDebug(h http.Handler, w http.ResponseWriter, r *http.Request, service, resource string, dbg debugtrace.Config, spanopts ...ddtrace.StartSpanOption) {
 	originalURL := url.URL{
 		Scheme:   "http",
 		Host:     r.Host,
@@ -30,18 +40,44 @@ func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, servi
 
 	opts := append([]ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeWeb),
+		tracer.SpanKind(tracer.KindServer),
 		tracer.ServiceName(service),
 		tracer.ResourceName(resource),
 		tracer.Tag(ext.HTTPMethod, r.Method),
 		tracer.Tag(ext.HTTPURL, originalURL.String()),
 	}, spanopts...)
-	if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header)); err == nil {
+	spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header))
+	if err == nil {
 		opts = append(opts, tracer.ChildOf(spanctx))
 	}
+	debug := dbg.Trusted(r.Header[http.CanonicalHeaderKey(dbg.Header)], time.Now())
+	if debug {
+		opts = append(opts, debugtrace.StartOptions(spanctx)...)
+	}
 	span, ctx := tracer.StartSpanFromContext(r.Context(), "http.request", opts...)
 	defer span.Finish()
 
-	w = wrapResponseWriter(w, span)
+	var body io.Writer // copy of the response body, for debugged requests
+	if debug {
+		dbg.SetHeaderTags(span, "http.request.header.", r.Header)
+		w.Header().Set(debugtrace.ResponseHeader, debugtrace.TraceID(span.Context()))
+		if dbg.BodyLimit > 0 {
+			reqBody := debugtrace.NewBody(dbg.BodyLimit)
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+			respBody := debugtrace.NewBody(dbg.BodyLimit)
+			body = respBody
+			defer reqBody.SetTag(span, "http.request.body")
+			defer respBody.SetTag(span, "http.response.body")
+		}
+		defer dbg.SetHeaderTags(span, "http.response.header.", w.Header())
+	}
+
+	w = wrapResponseWriter(w, span, body)
 
 	if v := os.Getenv("SIGNALFX_TRACE_RESPONSE_HEADER_ENABLED"); !strings.EqualFold(v, "false") {
 		if traceParent, ok := tracer.FormatAsTraceParent(span.Context()); ok {
@@ -59,10 +95,11 @@ type responseWriter struct {
 	http.ResponseWriter
 	span   ddtrace.Span
 	status int
+	body   io.Writer // copy of the response body, if not nil
 }
 
-func newResponseWriter(w http.ResponseWriter, span ddtrace.Span) *responseWriter {
-	return &responseWriter{w, span, 0}
+func newResponseWriter(w http.ResponseWriter, span ddtrace.Span, body io.Writer) *responseWriter {
+	return &responseWriter{w, span, 0, body}
 }
 
 // Write writes the data to the connection as part of an HTTP reply.
@@ -72,7 +109,11 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.WriteHeader(http.StatusOK)
 	}
-	return w.ResponseWriter.Write(b)
+	n, err := w.ResponseWriter.Write(b)
+	if w.body != nil {
+		w.body.Write(b[:n])
+	}
+	return n, err
 }
 
 // WriteHeader sends an HTTP response header with status code.
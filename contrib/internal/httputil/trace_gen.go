@@ -4,6 +4,7 @@ package httputil
 
 import (
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"io"
 	"net/http"
 )
 
@@ -11,17 +12,18 @@ import (
 // trace the http response codes. It also checks for various http interfaces
 // (Flusher, Pusher, CloseNotifier, Hijacker) and if the underlying
 // http.ResponseWriter implements them it generates an unnamed struct with the
-// appropriate fields.
+// appropriate fields. When body is not nil, the response body is also written
+// to it.
 //
 // This code is generated because we have to account for all the permutations
 // of the interfaces.
-func wrapResponseWriter(w http.ResponseWriter, span ddtrace.Span) http.ResponseWriter {
+func wrapResponseWriter(w http.ResponseWriter, span ddtrace.Span, body io.Writer) http.ResponseWriter {
 	hFlusher, okFlusher := w.(http.Flusher)
 	hPusher, okPusher := w.(http.Pusher)
 	hCloseNotifier, okCloseNotifier := w.(http.CloseNotifier)
 	hHijacker, okHijacker := w.(http.Hijacker)
 
-	w = newResponseWriter(w, span)
+	w = newResponseWriter(w, span, body)
 	switch {
 	case okFlusher && okPusher && okCloseNotifier && okHijacker:
 		w = struct {
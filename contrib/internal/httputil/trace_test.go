@@ -5,10 +5,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
@@ -85,7 +88,7 @@ func TestTraceAndServe(t *testing.T) {
 		_, ok = w.(http.Pusher)
 		assert.True(t, ok)
 
-		w = wrapResponseWriter(w, nil)
+		w = wrapResponseWriter(w, nil, nil)
 		_, ok = w.(http.ResponseWriter)
 		assert.True(t, ok)
 		_, ok = w.(http.Pusher)
@@ -157,4 +160,58 @@ func TestTraceAndServe(t *testing.T) {
 		assert.True(called)
 		assert.Equal(c.ParentID(), p.SpanID())
 	})
+	t.Run("debug", func(t *testing.T) {
+		dbg := debugtrace.Config{Header: "X-Debug", Secret: []byte("secret"), BodyLimit: 5}
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(body)
+		}
+		serve := func(debug string) (mocktracer.Span, *httptest.ResponseRecorder) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			r := httptest.NewRequest("POST", "/", strings.NewReader("hello, world"))
+			r.Header.Set("Accept", "text/plain")
+			r.Header.Set("Authorization", "Bearer token")
+			if debug != "" {
+				r.Header.Set("X-Debug", debug)
+			}
+			w := httptest.NewRecorder()
+			TraceAndServeWithDebug(http.HandlerFunc(handler), w, r, "service", "resource", dbg)
+			return mt.FinishedSpans()[0], w
+		}
+
+		t.Run("trusted", func(t *testing.T) {
+			assert := assert.New(t)
+			span, w := serve(debugtrace.Sign([]byte("secret"), time.Now()))
+			assert.Equal("hello, world", w.Body.String())
+			assert.Equal(true, span.Tag(ext.ManualKeep))
+			assert.Equal(true, span.Tag(ext.DebugRequest))
+			assert.Equal(fmt.Sprintf("%016x", span.TraceID()), w.Header().Get("X-SF-Trace-Id"))
+			assert.Equal("text/plain", span.Tag("http.request.header.accept"))
+			assert.Equal("<redacted>", span.Tag("http.request.header.authorization"))
+			assert.Equal("<redacted>", span.Tag("http.request.header.x-debug"))
+			assert.Equal("text/plain", span.Tag("http.response.header.content-type"))
+			assert.Equal("hello", span.Tag("http.request.body"))
+			assert.Equal(true, span.Tag("http.request.body.truncated"))
+			assert.Equal("hello", span.Tag("http.response.body"))
+			assert.Equal(true, span.Tag("http.response.body.truncated"))
+		})
+
+		for name, debug := range map[string]string{
+			"untrusted": "1",
+			"absent":    "",
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert := assert.New(t)
+				span, w := serve(debug)
+				assert.Equal("hello, world", w.Body.String())
+				assert.Nil(span.Tag(ext.ManualKeep))
+				assert.Nil(span.Tag(ext.DebugRequest))
+				assert.Empty(w.Header().Get("X-SF-Trace-Id"))
+				assert.Nil(span.Tag("http.request.header.accept"))
+				assert.Nil(span.Tag("http.request.body"))
+			})
+		}
+	})
 }
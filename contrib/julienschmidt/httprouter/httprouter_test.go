@@ -68,6 +68,24 @@ func TestHttpTracer500(t *testing.T) {
 	assert.Equal("500: Internal Server Error", s.Tag(ext.Error).(error).Error())
 }
 
+func TestWithDebugHeader(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := New(WithDebugHeader("X-Debug", nil))
+	router.GET("/200", handler200)
+	r := httptest.NewRequest("GET", "/200", nil)
+	r.Header.Set("X-Debug", "1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	spans := mt.FinishedSpans()
+	assert.Equal(1, len(spans))
+	assert.Equal(true, spans[0].Tag(ext.DebugRequest))
+	assert.NotEmpty(w.Header().Get("X-SF-Trace-Id"))
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...RouterOption) {
 		router := New(opts...)
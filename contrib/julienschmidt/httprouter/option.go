@@ -1,6 +1,7 @@
 package httprouter
 
 import (
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/internal/globalconfig"
 )
@@ -9,6 +10,7 @@ type routerConfig struct {
 	serviceName   string
 	spanOpts      []ddtrace.StartSpanOption
 	analyticsRate float64
+	debug         debugtrace.Config
 }
 
 // RouterOption represents an option that can be passed to New.
@@ -17,6 +19,7 @@ type RouterOption func(*routerConfig)
 func defaults(cfg *routerConfig) {
 	cfg.analyticsRate = globalconfig.AnalyticsRate()
 	cfg.serviceName = "http.router"
+	cfg.debug = debugtrace.Env()
 }
 
 // WithServiceName sets the given service name for the returned router.
@@ -48,3 +51,13 @@ func WithAnalyticsRate(rate float64) RouterOption {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithDebugHeader sets the header marking the requests to debug, which are
+// force-sampled and captured like with the option of the same name of the
+// net/http integration. A non-empty secret must sign the header values. It
+// takes precedence over the environment; an empty header disables debugging.
+func WithDebugHeader(header string, secret []byte) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.debug = debugtrace.New(header, secret)
+	}
+}
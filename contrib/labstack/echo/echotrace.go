@@ -27,7 +27,7 @@ func Middleware(opts ...Option) echo.MiddlewareFunc {
 				tracer.ServiceName(cfg.serviceName),
 				tracer.ResourceName(operationName),
 				tracer.SpanType(ext.SpanTypeEcho),
-				tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+				tracer.SpanKind(tracer.KindServer),
 				tracer.Tag(ext.HTTPMethod, request.Method),
 				tracer.Tag(ext.HTTPURL, utils.GetURL(request)),
 			}
@@ -38,7 +38,7 @@ func WrapHandler(handler dns.Handler) *Handler {
 // ServeDNS dispatches requests to the underlying Handler. All requests will be
 // traced.
 func (h *Handler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
-	span, _ := startSpan(context.Background(), r.Opcode)
+	span, _ := startSpan(context.Background(), r.Opcode, tracer.KindServer)
 	rw := &responseWriter{ResponseWriter: w}
 	h.Handler.ServeDNS(rw, r)
 	span.FinishWithOptionsExt(tracer.WithError(rw.err))
@@ -60,7 +60,7 @@ func (rw *responseWriter) WriteMsg(msg *dns.Msg) error {
 
 // Exchange calls dns.Exchange and traces the request.
 func Exchange(m *dns.Msg, addr string) (r *dns.Msg, err error) {
-	span, _ := startSpan(context.Background(), m.Opcode)
+	span, _ := startSpan(context.Background(), m.Opcode, tracer.KindClient)
 	r, err = dns.Exchange(m, addr)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return r, err
@@ -68,7 +68,7 @@ func Exchange(m *dns.Msg, addr string) (r *dns.Msg, err error) {
 
 // ExchangeConn calls dns.ExchangeConn and traces the request.
 func ExchangeConn(c net.Conn, m *dns.Msg) (r *dns.Msg, err error) {
-	span, _ := startSpan(context.Background(), m.Opcode)
+	span, _ := startSpan(context.Background(), m.Opcode, tracer.KindClient)
 	r, err = dns.ExchangeConn(c, m)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return r, err
@@ -76,7 +76,7 @@ func ExchangeConn(c net.Conn, m *dns.Msg) (r *dns.Msg, err error) {
 
 // ExchangeContext calls dns.ExchangeContext and traces the request.
 func ExchangeContext(ctx context.Context, m *dns.Msg, addr string) (r *dns.Msg, err error) {
-	span, ctx := startSpan(ctx, m.Opcode)
+	span, ctx := startSpan(ctx, m.Opcode, tracer.KindClient)
 	r, err = dns.ExchangeContext(ctx, m, addr)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return r, err
@@ -89,7 +89,7 @@ type Client struct {
 
 // Exchange calls the underlying Client.Exchange and traces the request.
 func (c *Client) Exchange(m *dns.Msg, addr string) (r *dns.Msg, rtt time.Duration, err error) {
-	span, _ := startSpan(context.Background(), m.Opcode)
+	span, _ := startSpan(context.Background(), m.Opcode, tracer.KindClient)
 	r, rtt, err = c.Client.Exchange(m, addr)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return r, rtt, err
@@ -97,14 +97,15 @@ func (c *Client) Exchange(m *dns.Msg, addr string) (r *dns.Msg, rtt time.Duratio
 
 // ExchangeContext calls the underlying Client.ExchangeContext and traces the request.
 func (c *Client) ExchangeContext(ctx context.Context, m *dns.Msg, addr string) (r *dns.Msg, rtt time.Duration, err error) {
-	span, ctx := startSpan(ctx, m.Opcode)
+	span, ctx := startSpan(ctx, m.Opcode, tracer.KindClient)
 	r, rtt, err = c.Client.ExchangeContext(ctx, m, addr)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return r, rtt, err
 }
 
-func startSpan(ctx context.Context, opcode int) (ddtrace.Span, context.Context) {
+func startSpan(ctx context.Context, opcode int, kind tracer.Kind) (ddtrace.Span, context.Context) {
 	return tracer.StartSpanFromContext(ctx, "dns.request",
+		tracer.SpanKind(kind),
 		tracer.ServiceName("dns"),
 		tracer.ResourceName(dns.OpcodeToString[opcode]),
 		tracer.SpanType(ext.SpanTypeDNS))
@@ -34,6 +34,7 @@ func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeMongoDB),
 		tracer.ServiceName(m.cfg.serviceName),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ResourceName("mongo." + evt.CommandName),
 		tracer.SpanType(ext.SpanTypeMongoDB),
 		tracer.Tag(ext.DBInstance, evt.DatabaseName),
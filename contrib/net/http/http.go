@@ -40,7 +40,7 @@ func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if mux.cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, mux.cfg.analyticsRate))
 	}
-	httputil.TraceAndServe(mux.ServeMux, w, r, mux.cfg.serviceName, route, opts...)
+	httputil.TraceAndServeWithDebug(mux.ServeMux, w, r, mux.cfg.serviceName, route, mux.cfg.debug, opts...)
 }
 
 // WrapHandler wraps an http.Handler with tracing using the given service and resource.
@@ -51,6 +51,6 @@ func WrapHandler(h http.Handler, service, resource string, opts ...Option) http.
 		fn(cfg)
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		httputil.TraceAndServe(h, w, req, service, resource, cfg.spanOpts...)
+		httputil.TraceAndServeWithDebug(h, w, req, service, resource, cfg.debug, cfg.spanOpts...)
 	})
 }
@@ -4,9 +4,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/contrib/internal/testutil"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
@@ -173,6 +175,30 @@ func TestWrapHandler200(t *testing.T) {
 	assert.Equal("bar", s.Tag("foo"))
 }
 
+func TestWithDebugHeader(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	assert := assert.New(t)
+
+	mux := NewServeMux(WithDebugHeader("X-Debug", []byte("secret")))
+	mux.HandleFunc("/200", handler200)
+	handler := WrapHandler(http.HandlerFunc(handler200), "my-service", "my-resource", WithDebugHeader("X-Debug", nil))
+	for _, h := range []http.Handler{mux, handler} {
+		r := httptest.NewRequest("GET", "/200", nil)
+		r.Header.Set("X-Debug", debugtrace.Sign([]byte("secret"), time.Now()))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.NotEmpty(w.Header().Get(debugtrace.ResponseHeader))
+	}
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	for _, s := range spans {
+		assert.Equal(true, s.Tag(ext.DebugRequest))
+		assert.Equal("OK\n", s.Tag("http.response.body"))
+	}
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		mux := NewServeMux(opts...)
@@ -231,6 +257,23 @@ func TestAnalyticsSettings(t *testing.T) {
 	})
 }
 
+func TestIntegrationSettings(t *testing.T) {
+	assert := assert.New(t)
+	globalconfig.SetIntegrationSettings(map[string]map[string]string{
+		integrationName: {"service_name": "from-config", "analytics_rate": "0.5"},
+	})
+	defer globalconfig.SetIntegrationSettings(nil)
+
+	var cfg config
+	defaults(&cfg)
+	assert.Equal("from-config", cfg.serviceName)
+	assert.Equal(0.5, cfg.analyticsRate)
+
+	// code options take precedence
+	WithServiceName("from-code")(&cfg)
+	assert.Equal("from-code", cfg.serviceName)
+}
+
 func router() http.Handler {
 	mux := NewServeMux(WithServiceName("my-service"), WithSpanOptions(tracer.Tag("foo", "bar")))
 	mux.HandleFunc("/200", handler200)
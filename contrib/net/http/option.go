@@ -2,7 +2,9 @@ package http
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/signalfx/signalfx-go-tracing/contrib/internal/debugtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/internal/globalconfig"
 )
@@ -11,6 +13,7 @@ type config struct {
 	serviceName   string
 	analyticsRate float64
 	spanOpts      []ddtrace.StartSpanOption
+	debug         debugtrace.Config
 }
 
 // MuxOption has been deprecated in favor of Option.
@@ -19,9 +22,22 @@ type MuxOption func(*config)
 // Option represents an option that can be passed to NewServeMux or WrapHandler.
 type Option = MuxOption
 
+// integrationName is the name under which the settings of this integration are
+// looked up in the global configuration.
+const integrationName = "net/http"
+
 func defaults(cfg *config) {
 	cfg.analyticsRate = globalconfig.AnalyticsRate()
 	cfg.serviceName = "http.router"
+	cfg.debug = debugtrace.Env()
+	if name, ok := globalconfig.IntegrationSetting(integrationName, "service_name"); ok {
+		cfg.serviceName = name
+	}
+	if v, ok := globalconfig.IntegrationSetting(integrationName, "analytics_rate"); ok {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.analyticsRate = rate
+		}
+	}
 }
 
 // WithServiceName sets the given service name for the returned ServeMux.
@@ -55,6 +71,18 @@ func WithSpanOptions(opts ...ddtrace.StartSpanOption) Option {
 	}
 }
 
+// WithDebugHeader force-samples the requests carrying the given debug header,
+// capturing their headers and bodies and echoing their trace ID in the
+// X-SF-Trace-Id response header. When secret is not empty, the header values
+// must be signed with it. It overrides the SIGNALFX_TRACE_DEBUG_HEADER and
+// SIGNALFX_TRACE_DEBUG_SECRET environment variables; an empty header disables
+// debugging.
+func WithDebugHeader(header string, secret []byte) Option {
+	return func(cfg *config) {
+		cfg.debug = debugtrace.New(header, secret)
+	}
+}
+
 // A RoundTripperBeforeFunc can be used to modify a span before an http
 // RoundTrip is made.
 type RoundTripperBeforeFunc func(*http.Request, ddtrace.Span)
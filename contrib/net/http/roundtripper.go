@@ -21,6 +21,7 @@ type roundTripper struct {
 func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err error) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ResourceName(defaultResourceName),
 		tracer.Tag(ext.HTTPMethod, req.Method),
 		tracer.Tag(ext.HTTPURL, req.URL.String()),
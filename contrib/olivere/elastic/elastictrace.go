@@ -41,6 +41,7 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(t.config.serviceName),
 		tracer.SpanType(ext.SpanTypeElasticSearch),
+		tracer.SpanKind(tracer.KindClient),
 		tracer.ResourceName(resource),
 		tracer.Tag("elasticsearch.method", method),
 		tracer.Tag("elasticsearch.url", url),
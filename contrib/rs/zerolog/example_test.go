@@ -0,0 +1,29 @@
+package zerolog_test
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog"
+
+	zerologtrace "github.com/signalfx/signalfx-go-tracing/contrib/rs/zerolog"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func Example() {
+	logger := zerolog.New(os.Stdout).Hook(zerologtrace.NewHook())
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	defer span.Finish()
+
+	// The event will have the trace_id and span_id fields of span.
+	logger.Info().Ctx(ctx).Msg("handling request")
+}
+
+func ExampleWithContext() {
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	defer span.Finish()
+
+	logger := zerologtrace.WithContext(ctx, zerolog.New(os.Stdout))
+	logger.Info().Msg("handling request")
+}
@@ -0,0 +1,11 @@
+module github.com/signalfx/signalfx-go-tracing/contrib/rs/zerolog
+
+go 1.12
+
+require (
+	github.com/rs/zerolog v1.30.0
+	github.com/signalfx/signalfx-go-tracing v1.12.0
+	github.com/stretchr/testify v1.7.0
+)
+
+replace github.com/signalfx/signalfx-go-tracing => ../../../
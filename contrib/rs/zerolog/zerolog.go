@@ -0,0 +1,40 @@
+// Package zerolog provides helpers linking zerolog (https://github.com/rs/zerolog)
+// events with the span active in a context.
+package zerolog // import "github.com/signalfx/signalfx-go-tracing/contrib/rs/zerolog"
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/signalfx/signalfx-go-tracing/tracing/correlation"
+)
+
+var _ zerolog.Hook = (*Hook)(nil)
+
+// Hook is a zerolog.Hook which adds the trace_id, span_id, service.name and
+// deployment.environment fields to events with a context holding a span, for
+// example events created with Ctx(ctx).
+type Hook struct{}
+
+// NewHook returns a new Hook, ready to be added to a logger using Hook.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// Run implements zerolog.Hook.
+func (*Hook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if f, ok := correlation.FromContext(e.GetCtx()); ok {
+		e.Fields(f.KeyValues())
+	}
+}
+
+// WithContext returns a child of l which adds the fields of the span found in
+// ctx to every event. It returns l unchanged if ctx holds no span.
+func WithContext(ctx context.Context, l zerolog.Logger) zerolog.Logger {
+	f, ok := correlation.FromContext(ctx)
+	if !ok {
+		return l
+	}
+	return l.With().Fields(f.KeyValues()).Logger()
+}
@@ -0,0 +1,51 @@
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func TestZerolog(t *testing.T) {
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+	tracer.Start(tracer.WithServiceName("svc"), tracer.WithZipkin("svc", zipkin.URL(), ""))
+	defer tracer.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "op")
+	defer span.Finish()
+
+	check := func(t *testing.T, data []byte) {
+		assert := assert.New(t)
+		var event map[string]interface{}
+		assert.NoError(json.Unmarshal(data, &event))
+		assert.Equal(tracer.TraceIDHex(span.Context()), event["trace_id"])
+		assert.Equal(tracer.SpanIDHex(span.Context()), event["span_id"])
+		assert.Equal("svc", event["service.name"])
+	}
+
+	t.Run("hook", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := zerolog.New(&buf).Hook(NewHook())
+		l.Info().Ctx(ctx).Msg("traced")
+		check(t, buf.Bytes())
+
+		buf.Reset()
+		l.Info().Msg("untraced")
+		assert.NotContains(t, buf.String(), "trace_id")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := WithContext(ctx, zerolog.New(&buf))
+		l.Info().Msg("traced")
+		check(t, buf.Bytes())
+	})
+}
@@ -0,0 +1,20 @@
+package logrus_test
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	logrustrace "github.com/signalfx/signalfx-go-tracing/contrib/sirupsen/logrus"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func Example() {
+	logrus.AddHook(logrustrace.NewHook())
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	defer span.Finish()
+
+	// The entry will have the trace_id and span_id fields of span.
+	logrus.WithContext(ctx).Info("handling request")
+}
@@ -0,0 +1,11 @@
+module github.com/signalfx/signalfx-go-tracing/contrib/sirupsen/logrus
+
+go 1.12
+
+require (
+	github.com/signalfx/signalfx-go-tracing v1.12.0
+	github.com/sirupsen/logrus v1.8.1
+	github.com/stretchr/testify v1.7.0
+)
+
+replace github.com/signalfx/signalfx-go-tracing => ../../../
@@ -0,0 +1,41 @@
+// Package logrus provides a hook linking logrus (https://github.com/sirupsen/logrus)
+// entries with the span active in their context.
+package logrus // import "github.com/signalfx/signalfx-go-tracing/contrib/sirupsen/logrus"
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/signalfx/signalfx-go-tracing/tracing/correlation"
+)
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// Hook is a logrus.Hook which adds the trace_id, span_id, service.name and
+// deployment.environment fields to entries logged with a context holding a span,
+// for example through logrus.WithContext.
+type Hook struct{}
+
+// NewHook returns a new Hook, ready to be added to a logger using AddHook.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// Levels implements logrus.Hook.
+func (*Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (*Hook) Fire(e *logrus.Entry) error {
+	if e.Context == nil {
+		return nil
+	}
+	f, ok := correlation.FromContext(e.Context)
+	if !ok {
+		return nil
+	}
+	for k, v := range f.Map() {
+		e.Data[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func TestHook(t *testing.T) {
+	assert := assert.New(t)
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+	tracer.Start(tracer.WithServiceName("svc"), tracer.WithZipkin("svc", zipkin.URL(), ""))
+	defer tracer.Stop()
+
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.AddHook(NewHook())
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "op")
+	defer span.Finish()
+
+	l.WithContext(ctx).Info("traced")
+	var entry map[string]interface{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(tracer.TraceIDHex(span.Context()), entry["trace_id"])
+	assert.Equal(tracer.SpanIDHex(span.Context()), entry["span_id"])
+	assert.Equal("svc", entry["service.name"])
+
+	buf.Reset()
+	l.Info("untraced")
+	entry = nil
+	assert.NoError(json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(entry, "trace_id")
+}
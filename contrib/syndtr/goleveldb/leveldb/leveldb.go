@@ -260,6 +260,7 @@ func (it *Iterator) Release() {
 func startSpan(cfg *config, name string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeLevelDB),
+		tracer.SpanKind(tracer.KindInternal),
 		tracer.ServiceName(cfg.serviceName),
 		tracer.ResourceName(name),
 	}
@@ -88,6 +88,7 @@ func WrapTx(tx *buntdb.Tx, opts ...Option) *Tx {
 func (tx *Tx) startSpan(name string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.AppTypeDB),
+		tracer.SpanKind(tracer.KindInternal),
 		tracer.ServiceName(tx.cfg.serviceName),
 		tracer.ResourceName(name),
 	}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
 )
 
 // Tracer specifies an implementation of the Datadog tracer which allows starting
@@ -40,11 +41,63 @@ type Tracer interface {
 // Span represents a chunk of computation time. Spans have names, durations,
 // timestamps and other metadata. A Tracer is used to create hierarchies of
 // spans in a request, buffer and submit them to the server.
+//
+// Optional capabilities, such as ErrorRecorder, StatusSetter and TimedLogger,
+// are separate interfaces so that existing implementations of Span are not
+// affected. The spans of the tracer and mocktracer packages implement them all.
 type Span interface {
 	opentracing.Span
 	FinishWithOptionsExt(opts ...FinishOption)
 }
 
+// ErrorRecorder is implemented by spans which can record error events.
+type ErrorRecorder interface {
+	// RecordError appends an event describing err to the logs of the span.
+	// Unlike finishing with an error, it keeps the events of previous calls
+	// and does not mark the span as failed unless requested with an option.
+	RecordError(err error, opts ...ErrorOption)
+}
+
+// TimedLogger is implemented by spans which can log events at a given time.
+type TimedLogger interface {
+	// LogFieldsAt logs the given fields as an event which occurred at time t.
+	LogFieldsAt(t time.Time, fields ...log.Field)
+}
+
+// StatusSetter is implemented by spans which can hold the status of their operation.
+type StatusSetter interface {
+	// SetStatus sets the status of the operation represented by the span.
+	// StatusError marks the span as failed with the given description and
+	// StatusOK marks it as successful, overriding any error set before.
+	SetStatus(code StatusCode, description string)
+}
+
+// StatusCode is the status of the operation represented by a span.
+type StatusCode int
+
+const (
+	// StatusUnset is the default status of a span, failed only when it has
+	// an error.
+	StatusUnset StatusCode = iota
+	// StatusOK marks the operation as successful.
+	StatusOK
+	// StatusError marks the operation as failed.
+	StatusError
+)
+
+// String returns the name of the status code, as exported in the
+// "otel.status_code" tag.
+func (c StatusCode) String() string {
+	switch c {
+	case StatusOK:
+		return "OK"
+	case StatusError:
+		return "ERROR"
+	default:
+		return "UNSET"
+	}
+}
+
 // SpanContext represents a span state that can propagate to descendant spans
 // and across process boundaries. It contains all the information needed to
 // spawn a direct descendant of the span that it belongs to. It can be used
@@ -74,6 +127,36 @@ type FinishConfig struct {
 
 	// SkipStackFrames specifies the offset at which to start reporting stack frames from the stack.
 	SkipStackFrames uint
+
+	// ErrorStack, when set, returns the stack trace attached to spans finishing
+	// with an error. When nil or returning the empty string, the stack trace
+	// carried by the error, if any, or the current one is used.
+	ErrorStack func(err error) string
+}
+
+// ErrorOption is a configuration option that can be used with a Span's RecordError method.
+type ErrorOption func(cfg *ErrorConfig)
+
+// ErrorConfig holds the configuration for recording an error event on a span. It is
+// usually passed around by reference to one or more ErrorOption functions which shape
+// it into its final form.
+type ErrorConfig struct {
+	// Time holds the time of the event. Implementations should use the current
+	// time when Time.IsZero().
+	Time time.Time
+
+	// MarkFailed also marks the span as failed, setting its error tags as
+	// finishing it with the error would.
+	MarkFailed bool
+
+	// NoDebugStack prevents the event from holding a stack trace.
+	NoDebugStack bool
+
+	// StackFrames specifies the number of stack frames attached to the event.
+	StackFrames uint
+
+	// SkipStackFrames specifies the offset at which to start reporting stack frames from the stack.
+	SkipStackFrames uint
 }
 
 // StartSpanConfig holds the configuration for starting a new span. It is usually passed
@@ -84,6 +167,14 @@ type StartSpanConfig struct {
 	// new span. If nil, implementations should return a root span.
 	Parent SpanContext
 
+	// FollowsFrom is true when the new span follows from Parent, which does
+	// not depend on its outcome, rather than being its child.
+	FollowsFrom bool
+
+	// Links holds the contexts of the spans related to the new span other
+	// than its parent.
+	Links []SpanContext
+
 	// StartTime holds the time that should be used as the start time of the span.
 	// Implementations should use the current time when StartTime.IsZero().
 	StartTime time.Time
@@ -96,6 +187,11 @@ type StartSpanConfig struct {
 	// then this will also set the TraceID to the same value.
 	SpanID uint64
 
+	// TraceID and TraceIDHigh force-set the low and high bits of the trace ID of
+	// new root spans. They are ignored when a Parent SpanContext is present.
+	TraceID     uint64
+	TraceIDHigh uint64
+
 	// RecordedValueMaxLength determines the maximum allowed length a tag/log can have.
 	RecordedValueMaxLength *int
 }
@@ -76,10 +76,17 @@ const (
 )
 
 // Span kinds have Either client or server for the appropriate roles in an RPC,
-// and producer or consumer for the appropriate roles in a messaging scenario.
+// producer or consumer for the appropriate roles in a messaging scenario, and
+// internal for operations which do not cross a process boundary.
 const (
 	// SpanKindServer marks a span as a server span
 	SpanKindServer = "SERVER"
-	// SpanKindClient marks a span as a server span
+	// SpanKindClient marks a span as a client span
 	SpanKindClient = "CLIENT"
+	// SpanKindProducer marks a span as a message producer span
+	SpanKindProducer = "PRODUCER"
+	// SpanKindConsumer marks a span as a message consumer span
+	SpanKindConsumer = "CONSUMER"
+	// SpanKindInternal marks a span as an internal operation span
+	SpanKindInternal = "INTERNAL"
 )
@@ -45,9 +45,28 @@ const (
 	// SpanType defines the Span type (web, db, cache).
 	SpanType = "span.type"
 
-	// SpanKind defines the Span kind (SERVER, CLIENT).
+	// SpanKind defines the Span kind (SERVER, CLIENT, PRODUCER, CONSUMER, INTERNAL).
 	SpanKind = "span.kind"
 
+	// SpanLinks holds the spans a span is linked to, other than its parent,
+	// such as the ones it follows from, as a comma separated list of
+	// "<trace ID>:<span ID>" pairs of hexadecimal IDs.
+	SpanLinks = "sfx.links"
+
+	// FollowsFrom is set to true on spans which follow from their parent
+	// rather than being its child.
+	FollowsFrom = "sfx.follows_from"
+
+	// DebugRequest is set to true on server spans of requests carrying a
+	// trusted debug header, which are force-sampled.
+	DebugRequest = "sfx.debug"
+
+	// StatusCode holds the status set on a span with SetStatus (OK, ERROR).
+	StatusCode = "otel.status_code"
+
+	// StatusDescription holds the description of an error status.
+	StatusDescription = "otel.status_description"
+
 	// ServiceName defines the Service name for this Span.
 	ServiceName = "service.name"
 
@@ -72,9 +91,17 @@ const (
 	// ErrorObject is the error object string
 	ErrorObject = "sfx.error.object"
 
+	// ErrorCauses lists the types of the errors wrapped by the error, from
+	// the outermost to the innermost, separated by commas.
+	ErrorCauses = "sfx.error.causes"
+
 	// Environment specifies the environment to use with a trace.
 	Environment = "env"
 
+	// DeploymentEnvironment specifies the deployment environment, such as
+	// "production" or "staging", of the service emitting a trace.
+	DeploymentEnvironment = "deployment.environment"
+
 	// EventSampleRate specifies the rate at which this span will be sampled
 	// as an APM event.
 	EventSampleRate = "_dd1.sr.eausr"
@@ -100,6 +127,17 @@ const (
 	// Stack is a stack trace
 	Stack = "stack"
 
+	// ErrorEvent is the value of the Event log field of error events.
+	ErrorEvent = "error"
+
+	// EventErrorKind is the log field holding the type of the error of an
+	// error event.
+	EventErrorKind = "error.kind"
+
+	// EventErrorCauses is the log field listing the types of the errors
+	// wrapped by the error of an error event, separated by commas.
+	EventErrorCauses = "error.causes"
+
 	// MessageBusDestination is an address at which messages can be exchanged in a messaging
 	// system such as kafka.
 	MessageBusDestination = "message_bus.destination"
@@ -2,6 +2,7 @@ package ddtrace // import "github.com/signalfx/signalfx-go-tracing/ddtrace"
 
 import (
 	"sync"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/log"
@@ -62,7 +63,12 @@ func (NoopTracer) Inject(context SpanContext, carrier interface{}) error { retur
 // Stop implements ddtrace.Tracer.
 func (NoopTracer) Stop() {}
 
-var _ Span = (*NoopSpan)(nil)
+var (
+	_ Span          = (*NoopSpan)(nil)
+	_ ErrorRecorder = (*NoopSpan)(nil)
+	_ StatusSetter  = (*NoopSpan)(nil)
+	_ TimedLogger   = (*NoopSpan)(nil)
+)
 
 // NoopSpan is an implementation of ddtrace.Span that is a no-op.
 type NoopSpan struct{}
@@ -71,6 +77,18 @@ type NoopSpan struct{}
 func (NoopSpan) FinishWithOptionsExt(opts ...FinishOption) {
 }
 
+// RecordError implements ddtrace.ErrorRecorder.
+func (NoopSpan) RecordError(err error, opts ...ErrorOption) {
+}
+
+// LogFieldsAt implements ddtrace.TimedLogger.
+func (NoopSpan) LogFieldsAt(t time.Time, fields ...log.Field) {
+}
+
+// SetStatus implements ddtrace.StatusSetter.
+func (NoopSpan) SetStatus(code StatusCode, description string) {
+}
+
 // Finish implements ddtrace.Span.
 func (NoopSpan) Finish() {
 }
@@ -0,0 +1,311 @@
+package mocktracer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// SpanMatcher describes an expected span and, when asserting trees, its
+// children. Empty fields are not checked.
+type SpanMatcher struct {
+	// Operation is the expected operation name.
+	Operation string
+
+	// Resource is the expected resource name.
+	Resource string
+
+	// Kind is the expected span kind, such as ext.SpanKindServer.
+	Kind string
+
+	// Tags holds the expected tags. Values are equal when deeply equal or
+	// formatted the same. A nil value expects the tag not to be set.
+	Tags map[string]interface{}
+
+	// Logs holds the fields expected in the logs of the span, each entry
+	// matching a distinct log record holding at least the given fields.
+	Logs []map[string]interface{}
+
+	// Children holds the expected children of the span, in any order. Spans
+	// with other children do not match.
+	Children []SpanMatcher
+}
+
+// String returns a one line description of the matcher.
+func (m SpanMatcher) String() string {
+	var parts []string
+	if m.Operation != "" {
+		parts = append(parts, m.Operation)
+	}
+	if m.Resource != "" {
+		parts = append(parts, fmt.Sprintf("resource=%q", m.Resource))
+	}
+	if m.Kind != "" {
+		parts = append(parts, "kind="+strings.ToUpper(m.Kind))
+	}
+	for _, k := range sortedKeys(m.Tags) {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, m.Tags[k]))
+	}
+	if len(parts) == 0 {
+		return "<any span>"
+	}
+	return strings.Join(parts, " ")
+}
+
+// mismatches returns the differences between s and m, children excluded.
+func (m SpanMatcher) mismatches(s Span) []string {
+	var diffs []string
+	if m.Operation != "" && s.OperationName() != m.Operation {
+		diffs = append(diffs, fmt.Sprintf("operation is %q, want %q", s.OperationName(), m.Operation))
+	}
+	if r := s.Tag(ext.ResourceName); m.Resource != "" && r != m.Resource {
+		diffs = append(diffs, fmt.Sprintf("resource is %q, want %q", valueOr(r, ""), m.Resource))
+	}
+	if m.Kind != "" && spanKind(s) != strings.ToUpper(m.Kind) {
+		diffs = append(diffs, fmt.Sprintf("kind is %q, want %q", spanKind(s), strings.ToUpper(m.Kind)))
+	}
+	for _, k := range sortedKeys(m.Tags) {
+		got, want := s.Tag(k), m.Tags[k]
+		switch {
+		case want == nil && got != nil:
+			diffs = append(diffs, fmt.Sprintf("tag %q is %#v, want unset", k, got))
+		case want != nil && got == nil:
+			diffs = append(diffs, fmt.Sprintf("tag %q is unset, want %#v", k, want))
+		case want != nil && !tagEqual(got, want):
+			diffs = append(diffs, fmt.Sprintf("tag %q is %#v, want %#v", k, got, want))
+		}
+	}
+	for _, l := range missingLogs(s, m.Logs) {
+		diffs = append(diffs, fmt.Sprintf("no log with fields %v", l))
+	}
+	return diffs
+}
+
+// matches reports whether the span of n and its descendants match m.
+func (m SpanMatcher) matches(n *SpanNode) bool {
+	if len(m.mismatches(n.Span)) > 0 || len(m.Children) != len(n.Children) {
+		return false
+	}
+	_, missing, extra := matchNodes(n.Children, m.Children)
+	return len(missing) == 0 && len(extra) == 0
+}
+
+// matchNodes pairs the given nodes with the matchers, pairing as many as
+// possible when matchers overlap. It returns the pairs, as indexes of matchers
+// by node, the unmatched matchers and the unmatched nodes.
+func matchNodes(nodes []*SpanNode, matchers []SpanMatcher) (pairs map[int]int, missing []SpanMatcher, extra []*SpanNode) {
+	ok := make([][]bool, len(matchers))
+	for mi, m := range matchers {
+		ok[mi] = make([]bool, len(nodes))
+		for ni, n := range nodes {
+			ok[mi][ni] = m.matches(n)
+		}
+	}
+	pairs = make(map[int]int)
+	// augment pairs matcher mi with a node, moving the matchers paired before
+	// to other nodes if needed.
+	var augment func(mi int, seen []bool) bool
+	augment = func(mi int, seen []bool) bool {
+		for ni := range nodes {
+			if !ok[mi][ni] || seen[ni] {
+				continue
+			}
+			seen[ni] = true
+			if prev, used := pairs[ni]; !used || augment(prev, seen) {
+				pairs[ni] = mi
+				return true
+			}
+		}
+		return false
+	}
+	for mi, m := range matchers {
+		if !augment(mi, make([]bool, len(nodes))) {
+			missing = append(missing, m)
+		}
+	}
+	for ni, n := range nodes {
+		if _, used := pairs[ni]; !used {
+			extra = append(extra, n)
+		}
+	}
+	return pairs, missing, extra
+}
+
+// diffNodes appends to diffs the differences between nodes and matchers, the
+// children of the span described by path. Spans not fully matched are paired
+// with matchers describing them, disregarding descendants, to explain the
+// differences of their descendants.
+func diffNodes(diffs []string, path string, nodes []*SpanNode, matchers []SpanMatcher) []string {
+	_, missing, extra := matchNodes(nodes, matchers)
+	var unpaired []SpanMatcher
+	for _, m := range missing {
+		paired := false
+		for i, n := range extra {
+			if len(m.mismatches(n.Span)) == 0 {
+				diffs = diffNode(diffs, path, n, m)
+				extra = append(extra[:i:i], extra[i+1:]...)
+				paired = true
+				break
+			}
+		}
+		if !paired {
+			unpaired = append(unpaired, m)
+		}
+	}
+	if len(unpaired) == 1 && len(extra) == 1 {
+		// a single mismatch, explain it
+		return diffNode(diffs, path, extra[0], unpaired[0])
+	}
+	for _, m := range unpaired {
+		diffs = append(diffs, fmt.Sprintf("%s: missing span %s", path, m))
+	}
+	for _, n := range extra {
+		diffs = append(diffs, fmt.Sprintf("%s: unexpected span %s", path, describeSpan(n.Span)))
+	}
+	return diffs
+}
+
+func diffNode(diffs []string, path string, n *SpanNode, m SpanMatcher) []string {
+	path += " > " + n.Span.OperationName()
+	for _, d := range m.mismatches(n.Span) {
+		diffs = append(diffs, path+": "+d)
+	}
+	return diffNodes(diffs, path, n.Children, m.Children)
+}
+
+// AssertTrace asserts that the roots of tree, and their descendants, match
+// the given matchers. On failure, it reports the differences along with the
+// actual and the expected trees.
+func AssertTrace(t testing.TB, tree *TraceTree, roots ...SpanMatcher) bool {
+	t.Helper()
+	if tree == nil {
+		t.Errorf("mocktracer: no trace, want:\n%s", renderMatchers(roots))
+		return false
+	}
+	diffs := diffNodes(nil, fmt.Sprintf("trace %d", tree.TraceID), tree.Roots, roots)
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("mocktracer: trace does not match:\n  %s\nactual:\n%s\nexpected:\n%s",
+		strings.Join(diffs, "\n  "), tree, renderMatchers(roots))
+	return false
+}
+
+// AssertSpan asserts that s matches m, children excluded.
+func AssertSpan(t testing.TB, s Span, m SpanMatcher) bool {
+	t.Helper()
+	if s == nil {
+		t.Errorf("mocktracer: no span, want %s", m)
+		return false
+	}
+	diffs := m.mismatches(s)
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("mocktracer: span %s does not match:\n  %s", describeSpan(s), strings.Join(diffs, "\n  "))
+	return false
+}
+
+// AssertTags asserts that s has the given tags. A nil value expects the tag
+// not to be set.
+func AssertTags(t testing.TB, s Span, tags map[string]interface{}) bool {
+	t.Helper()
+	return AssertSpan(t, s, SpanMatcher{Tags: tags})
+}
+
+// AssertKind asserts that s is of the given kind, such as ext.SpanKindClient.
+func AssertKind(t testing.TB, s Span, kind string) bool {
+	t.Helper()
+	return AssertSpan(t, s, SpanMatcher{Kind: kind})
+}
+
+// AssertLogs asserts that s has a distinct log record holding at least the
+// fields of each of the given maps.
+func AssertLogs(t testing.TB, s Span, logs ...map[string]interface{}) bool {
+	t.Helper()
+	return AssertSpan(t, s, SpanMatcher{Logs: logs})
+}
+
+// AssertFinishedSpans waits, for up to timeout, until n spans finished and
+// fails if fewer or more spans finished. It returns the finished spans.
+func AssertFinishedSpans(t testing.TB, mt Tracer, n int, timeout time.Duration) []Span {
+	t.Helper()
+	spans, err := WaitForSpans(mt, n, timeout)
+	if err != nil || len(spans) != n {
+		var sb strings.Builder
+		for _, tree := range Trees(spans) {
+			sb.WriteString(tree.String())
+		}
+		t.Errorf("mocktracer: %d spans finished, want %d:\n%s", len(spans), n, sb.String())
+	}
+	return spans
+}
+
+// missingLogs returns the entries of want not matched by distinct log records
+// of s.
+func missingLogs(s Span, want []map[string]interface{}) []map[string]interface{} {
+	if len(want) == 0 {
+		return nil
+	}
+	var records []map[string]interface{}
+	for _, lr := range s.Logs() {
+		fields := make(map[string]interface{}, len(lr.Fields))
+		for _, f := range lr.Fields {
+			fields[f.Key()] = f.Value()
+		}
+		records = append(records, fields)
+	}
+	used := make(map[int]bool)
+	var missing []map[string]interface{}
+	for _, w := range want {
+		found := false
+		for i, r := range records {
+			if !used[i] && hasFields(r, w) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
+
+func hasFields(record, want map[string]interface{}) bool {
+	for k, v := range want {
+		got, ok := record[k]
+		if !ok || !tagEqual(got, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func renderMatchers(matchers []SpanMatcher) string {
+	var sb strings.Builder
+	var render func([]SpanMatcher, int)
+	render = func(ms []SpanMatcher, depth int) {
+		for _, m := range ms {
+			sb.WriteString(strings.Repeat("  ", depth))
+			sb.WriteString(m.String())
+			sb.WriteByte('\n')
+			render(m.Children, depth+1)
+		}
+	}
+	render(matchers, 1)
+	return sb.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package mocktracer
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a testing.TB recording the reported errors.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func fmtUint(n uint64) string { return strconv.FormatUint(n, 10) }
+
+var expectedTrace = SpanMatcher{
+	Operation: "http.request",
+	Resource:  "GET /users",
+	Kind:      ext.SpanKindServer,
+	Tags:      map[string]interface{}{ext.HTTPCode: "200", ext.Error: nil},
+	Children: []SpanMatcher{
+		{
+			Operation: "http.request",
+			Kind:      ext.SpanKindClient,
+			Children:  []SpanMatcher{{Operation: "dns.lookup"}},
+		},
+		{Operation: "db.query", Kind: ext.SpanKindClient},
+	},
+}
+
+func TestAssertTrace(t *testing.T) {
+	mt := newMockTracer()
+	root := startTrace(mt, time.Now())
+	tree := Tree(mt.FinishedSpans(), root.TraceID())
+
+	t.Run("match", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assert.True(t, AssertTrace(r, tree, expectedTrace))
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		want := expectedTrace
+		want.Tags = map[string]interface{}{ext.HTTPCode: 404}
+		want.Children = []SpanMatcher{
+			{Operation: "http.request", Kind: ext.SpanKindClient},
+			{Operation: "db.query", Resource: "DELETE FROM users"},
+			{Operation: "cache.get"},
+		}
+		r := &recorder{TB: t}
+		assert.False(t, AssertTrace(r, tree, want))
+		assert.Len(t, r.errors, 1)
+		msg := r.errors[0]
+		assert.Contains(t, msg, `> http.request: tag "http.status_code" is 200, want 404`)
+		assert.Contains(t, msg, `> http.request: missing span db.query resource="DELETE FROM users"`)
+		assert.Contains(t, msg, `> http.request: unexpected span db.query resource="SELECT * FROM users" kind=CLIENT`)
+		assert.Contains(t, msg, "> http.request > http.request: unexpected span dns.lookup")
+		assert.Contains(t, msg, "> http.request: missing span cache.get")
+		assert.Contains(t, msg, "actual:\n"+tree.String())
+		assert.Contains(t, msg, "expected:\n  http.request resource=\"GET /users\" kind=SERVER")
+	})
+
+	t.Run("single-mismatch", func(t *testing.T) {
+		want := expectedTrace
+		want.Children = []SpanMatcher{
+			expectedTrace.Children[0],
+			{Operation: "db.query", Resource: "DELETE FROM users"},
+		}
+		r := &recorder{TB: t}
+		assert.False(t, AssertTrace(r, tree, want))
+		assert.Contains(t, r.errors[0], `> http.request > db.query: resource is "SELECT * FROM users", want "DELETE FROM users"`)
+		assert.NotContains(t, r.errors[0], "missing span")
+	})
+
+	t.Run("missing-root", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assert.False(t, AssertTrace(r, tree, expectedTrace, SpanMatcher{Operation: "other"}))
+		assert.Contains(t, r.errors[0], "missing span other")
+	})
+
+	t.Run("overlapping", func(t *testing.T) {
+		mt := newMockTracer()
+		root := mt.StartSpan("root")
+		mt.StartSpan("q", tracer.ChildOf(root.Context()), tracer.Tag("x", 1)).Finish()
+		mt.StartSpan("q", tracer.ChildOf(root.Context()), tracer.Tag("x", 2)).Finish()
+		root.Finish()
+		tree := Tree(mt.FinishedSpans(), root.(Span).TraceID())
+
+		// the first matcher matches both spans, but only the second one leaves
+		// a span to the other matcher
+		r := &recorder{TB: t}
+		assert.True(t, AssertTrace(r, tree, SpanMatcher{
+			Operation: "root",
+			Children: []SpanMatcher{
+				{Operation: "q"},
+				{Operation: "q", Tags: map[string]interface{}{"x": 1}},
+			},
+		}))
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assert.False(t, AssertTrace(r, nil, expectedTrace))
+		assert.Contains(t, r.errors[0], "no trace")
+	})
+}
+
+func TestAssertSpan(t *testing.T) {
+	mt := newMockTracer()
+	s := mt.StartSpan("db.query",
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBType, "sql"),
+	)
+	s.(*mockspan).LogFields(log.String("event", "retry"), log.Int("attempt", 2))
+	s.Finish()
+	span := mt.FinishedSpans()[0]
+
+	r := &recorder{TB: t}
+	assert.True(t, AssertSpan(r, span, SpanMatcher{Operation: "db.query", Kind: "client"}))
+	assert.True(t, AssertTags(r, span, map[string]interface{}{ext.DBType: "sql", ext.Error: nil}))
+	assert.True(t, AssertKind(r, span, ext.SpanKindClient))
+	assert.True(t, AssertLogs(r, span, map[string]interface{}{"event": "retry", "attempt": 2}))
+	assert.Empty(t, r.errors)
+
+	assert.False(t, AssertTags(r, span, map[string]interface{}{ext.DBType: "nosql", ext.DBUser: "root"}))
+	assert.False(t, AssertKind(r, span, ext.SpanKindServer))
+	assert.False(t, AssertLogs(r, span, map[string]interface{}{"event": "retry"}, map[string]interface{}{"event": "retry"}))
+	assert.False(t, AssertSpan(r, nil, SpanMatcher{Operation: "db.query"}))
+	assert.Len(t, r.errors, 4)
+	assert.Contains(t, r.errors[0], `tag "db.type" is "sql", want "nosql"`)
+	assert.Contains(t, r.errors[0], `tag "db.user" is unset, want "root"`)
+	assert.Contains(t, r.errors[1], `kind is "CLIENT", want "SERVER"`)
+	assert.Contains(t, r.errors[2], "no log with fields map[event:retry]")
+	assert.Contains(t, r.errors[3], "no span")
+}
+
+func TestAssertFinishedSpans(t *testing.T) {
+	mt := newMockTracer()
+	mt.StartSpan("op").Finish()
+
+	r := &recorder{TB: t}
+	assert.Len(t, AssertFinishedSpans(r, mt, 1, time.Second), 1)
+	assert.Empty(t, r.errors)
+
+	assert.Len(t, AssertFinishedSpans(r, mt, 2, 10*time.Millisecond), 1)
+	assert.Len(t, r.errors, 1)
+	assert.Contains(t, r.errors[0], "1 spans finished, want 2")
+	assert.Contains(t, r.errors[0], "  op\n")
+}
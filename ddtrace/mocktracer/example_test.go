@@ -1,6 +1,10 @@
 package mocktracer_test
 
 import (
+	"testing"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/mocktracer"
 )
 
@@ -19,3 +23,25 @@ func Example() {
 
 	// Run assertions...
 }
+
+func ExampleAssertTrace() {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// ...run some code which generates spans.
+
+	var t testing.T // the *testing.T of the test
+	spans := mocktracer.AssertFinishedSpans(&t, mt, 2, time.Second)
+	root := mocktracer.FindOne(spans, mocktracer.Kind(ext.SpanKindServer))
+	if root == nil {
+		return
+	}
+	mocktracer.AssertTrace(&t, mocktracer.Tree(spans, root.TraceID()), mocktracer.SpanMatcher{
+		Operation: "http.request",
+		Kind:      ext.SpanKindServer,
+		Tags:      map[string]interface{}{ext.HTTPCode: 200},
+		Children: []mocktracer.SpanMatcher{
+			{Operation: "db.query", Kind: ext.SpanKindClient},
+		},
+	})
+}
@@ -2,6 +2,7 @@ package mocktracer // import "github.com/signalfx/signalfx-go-tracing/ddtrace/mo
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -14,6 +15,9 @@ import (
 )
 
 var _ ddtrace.Span = (*mockspan)(nil)
+var _ ddtrace.ErrorRecorder = (*mockspan)(nil)
+var _ ddtrace.StatusSetter = (*mockspan)(nil)
+var _ ddtrace.TimedLogger = (*mockspan)(nil)
 var _ Span = (*mockspan)(nil)
 
 // Span is an interface that allows querying a span returned by the mock tracer.
@@ -42,6 +46,10 @@ type Span interface {
 	// Tags returns a copy of all the tags in this span.
 	Tags() map[string]interface{}
 
+	// Logs returns a copy of the log records of this span, logged with
+	// LogFields, LogKV, RecordError or FinishWithOptions.
+	Logs() []opentracing.LogRecord
+
 	// Context returns the span's SpanContext.
 	Context() ddtrace.SpanContext
 
@@ -79,6 +87,7 @@ func newSpan(t *mocktracer, operationName string, cfg *ddtrace.StartSpanConfig)
 		s.context.priority = ctx.samplingPriority()
 		s.context.hasPriority = ctx.hasSamplingPriority()
 		s.context.traceID = ctx.traceID
+		s.context.traceIDHigh = ctx.traceIDHigh
 		s.context.baggage = make(map[string]string, len(ctx.baggage))
 		ctx.ForeachBaggageItem(func(k, v string) bool {
 			s.context.baggage[k] = v
@@ -98,6 +107,7 @@ type mockspan struct {
 	finishTime   time.Time
 	startTime    time.Time
 	parentID     uint64
+	logs         []opentracing.LogRecord
 	context      *spanContext
 	tracer       *mocktracer
 }
@@ -107,10 +117,25 @@ func (s *mockspan) Finish() {
 }
 
 func (s *mockspan) FinishWithOptions(opts opentracing.FinishOptions) {
+	for _, lr := range opts.LogRecords {
+		if len(lr.Fields) == 0 {
+			continue
+		}
+		if lr.Timestamp.IsZero() {
+			lr.Timestamp = time.Now()
+		}
+		s.log(lr)
+	}
 	s.FinishWithOptionsExt(tracer.FinishTime(opts.FinishTime))
 }
 
+// LogKV logs the given alternating keys and values to the span.
 func (s *mockspan) LogKV(alternatingKeyValues ...interface{}) {
+	fields, err := log.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		fields = []log.Field{log.Error(err), log.String("function", "LogKV")}
+	}
+	s.LogFields(fields...)
 }
 
 func (s *mockspan) Tracer() opentracing.Tracer {
@@ -126,8 +151,31 @@ func (s *mockspan) LogEventWithPayload(event string, payload interface{}) {
 func (s *mockspan) Log(data opentracing.LogData) {
 }
 
-// LogFields to span
+// LogFields logs the given fields to the span.
 func (s *mockspan) LogFields(fields ...log.Field) {
+	s.log(opentracing.LogRecord{Timestamp: time.Now(), Fields: fields})
+}
+
+// LogFieldsAt logs the given fields with the timestamp t.
+func (s *mockspan) LogFieldsAt(t time.Time, fields ...log.Field) {
+	s.log(opentracing.LogRecord{Timestamp: t, Fields: fields})
+}
+
+func (s *mockspan) log(lr opentracing.LogRecord) {
+	s.Lock()
+	defer s.Unlock()
+	if !s.finishTime.IsZero() {
+		return
+	}
+	lr.Fields = append([]log.Field(nil), lr.Fields...)
+	s.logs = append(s.logs, lr)
+}
+
+// Logs returns a copy of the log records of the span.
+func (s *mockspan) Logs() []opentracing.LogRecord {
+	s.RLock()
+	defer s.RUnlock()
+	return append([]opentracing.LogRecord(nil), s.logs...)
 }
 
 // SetTag sets a given tag on the span.
@@ -224,6 +272,67 @@ func (s *mockspan) FinishWithOptionsExt(opts ...ddtrace.FinishOption) {
 	s.ddfinish(t)
 }
 
+// RecordError logs an error event, without stack trace, and marks the span
+// as failed if requested.
+func (s *mockspan) RecordError(err error, opts ...ddtrace.ErrorOption) {
+	if err == nil {
+		return
+	}
+	var cfg ddtrace.ErrorConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.Time.IsZero() {
+		cfg.Time = time.Now()
+	}
+	s.log(opentracing.LogRecord{
+		Timestamp: cfg.Time,
+		Fields: []log.Field{
+			log.String(ext.Event, ext.ErrorEvent),
+			log.String(ext.EventErrorKind, reflect.TypeOf(err).String()),
+			log.String(ext.Message, err.Error()),
+		},
+	})
+	if cfg.MarkFailed {
+		s.SetTag(ext.Error, err)
+	}
+}
+
+// SetStatus sets the "otel.status_code" and "otel.status_description" tags, as
+// well as the error tags, as the spans of the tracer do: StatusError marks the
+// span as failed, recording description as its error message unless it already
+// has one, while StatusOK clears its error state. It has no effect once the
+// span is finished.
+func (s *mockspan) SetStatus(code ddtrace.StatusCode, description string) {
+	s.Lock()
+	defer s.Unlock()
+	if !s.finishTime.IsZero() {
+		return
+	}
+	if s.tags == nil {
+		s.tags = make(map[string]interface{}, 3)
+	}
+	switch code {
+	case ddtrace.StatusError:
+		s.tags[ext.Error] = true
+		s.tags[ext.StatusCode] = code.String()
+		if description == "" {
+			break
+		}
+		s.tags[ext.StatusDescription] = description
+		if _, ok := s.tags[ext.ErrorMsg]; !ok {
+			s.tags[ext.ErrorMsg] = description
+		}
+	case ddtrace.StatusOK:
+		delete(s.tags, ext.Error)
+		delete(s.tags, ext.StatusDescription)
+		s.tags[ext.StatusCode] = code.String()
+	default:
+		delete(s.tags, ext.StatusCode)
+		delete(s.tags, ext.StatusDescription)
+	}
+}
+
 func (s *mockspan) ddfinish(finishTime time.Time) {
 	s.Lock()
 	s.finishTime = finishTime
@@ -1,15 +1,20 @@
 package mocktracer
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // basicSpan returns a span with no configuration, having the set operation name.
@@ -171,3 +176,163 @@ func TestSpanFinish(t *testing.T) {
 	assert.True(s.FinishTime().Before(time.Now()))
 	assert.Equal(want, s.Tag(ext.Error))
 }
+
+func TestSpanRecordError(t *testing.T) {
+	s := basicSpan("http.request")
+	s.RecordError(errors.New("retry"))
+
+	assert := assert.New(t)
+	assert.Nil(s.Tag(ext.Error))
+
+	want := errors.New("some error")
+	s.RecordError(want, tracer.MarkFailed())
+	assert.Equal(want, s.Tag(ext.Error))
+
+	logs := s.Logs()
+	assert.Len(logs, 2)
+	assert.Equal([]log.Field{
+		log.String(ext.Event, ext.ErrorEvent),
+		log.String(ext.EventErrorKind, "*errors.errorString"),
+		log.String(ext.Message, "retry"),
+	}, logs[0].Fields)
+}
+
+func TestSpanLogs(t *testing.T) {
+	s := basicSpan("http.request")
+	s.LogFields(log.String("event", "cache.miss"), log.Int("attempt", 1))
+	s.LogKV("event", "retry")
+	at := time.Unix(10, 0)
+	s.FinishWithOptions(opentracing.FinishOptions{
+		LogRecords: []opentracing.LogRecord{{Timestamp: at, Fields: []log.Field{log.String("event", "done")}}},
+	})
+	s.LogFields(log.String("event", "late"))
+
+	assert := assert.New(t)
+	logs := s.Logs()
+	assert.Len(logs, 3)
+	assert.Equal([]log.Field{log.String("event", "cache.miss"), log.Int("attempt", 1)}, logs[0].Fields)
+	assert.False(logs[0].Timestamp.IsZero())
+	assert.Equal([]log.Field{log.String("event", "retry")}, logs[1].Fields)
+	assert.Equal(at, logs[2].Timestamp)
+}
+
+func TestSpanSetStatus(t *testing.T) {
+	s := basicSpan("http.request")
+	s.SetStatus(tracer.StatusError, "not found")
+
+	assert := assert.New(t)
+	assert.Equal(true, s.Tag(ext.Error))
+	assert.Equal("ERROR", s.Tag(ext.StatusCode))
+	assert.Equal("not found", s.Tag(ext.StatusDescription))
+
+	s.SetStatus(tracer.StatusOK, "")
+	assert.Nil(s.Tag(ext.Error))
+	assert.Equal("OK", s.Tag(ext.StatusCode))
+}
+
+// statusState holds the error state and status tags of a span.
+type statusState struct {
+	Error       bool
+	Code        string
+	Description string
+	Message     string
+}
+
+// TestSpanSetStatusMatchesTracer checks that the status of mock spans is set
+// as the status of the spans of the tracer.
+func TestSpanSetStatusMatchesTracer(t *testing.T) {
+	type statusCall struct {
+		code        ddtrace.StatusCode
+		description string
+	}
+	for name, tt := range map[string]struct {
+		calls  []statusCall
+		finish bool // make the calls after finishing the span
+		want   statusState
+	}{
+		"error": {
+			calls: []statusCall{{tracer.StatusError, "not found"}},
+			want:  statusState{Error: true, Code: "ERROR", Description: "not found", Message: "not found"},
+		},
+		"error-no-description": {
+			calls: []statusCall{{tracer.StatusError, ""}},
+			want:  statusState{Error: true, Code: "ERROR"},
+		},
+		"error-twice": {
+			calls: []statusCall{{tracer.StatusError, "first"}, {tracer.StatusError, "second"}},
+			want:  statusState{Error: true, Code: "ERROR", Description: "second", Message: "first"},
+		},
+		"ok": {
+			calls: []statusCall{{tracer.StatusError, "not found"}, {tracer.StatusOK, "ignored"}},
+			want:  statusState{Code: "OK", Message: "not found"},
+		},
+		"unset": {
+			calls: []statusCall{{tracer.StatusError, "not found"}, {tracer.StatusUnset, ""}},
+			want:  statusState{Error: true, Message: "not found"},
+		},
+		"finished": {
+			calls:  []statusCall{{tracer.StatusError, "not found"}},
+			finish: true,
+			want:   statusState{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run := func(s ddtrace.Span) {
+				if tt.finish {
+					s.Finish()
+				}
+				for _, c := range tt.calls {
+					tracer.SetStatus(s, c.code, c.description)
+				}
+				if !tt.finish {
+					s.Finish()
+				}
+			}
+
+			mt := newMockTracer()
+			run(mt.StartSpan("op"))
+			ms := mt.FinishedSpans()[0]
+			str := func(key string) string {
+				v, _ := ms.Tag(key).(string)
+				return v
+			}
+			assert.Equal(t, tt.want, statusState{
+				Error:       ms.Tag(ext.Error) == true,
+				Code:        str(ext.StatusCode),
+				Description: str(ext.StatusDescription),
+				Message:     str(ext.ErrorMsg),
+			}, "mocktracer")
+
+			var buf bytes.Buffer
+			tr := tracer.New(tracer.WithConsoleExporter(&buf, tracer.ConsoleJSON))
+			run(tr.StartSpan("op"))
+			// the trace is queued to the worker of the tracer
+			for deadline := time.Now().Add(time.Second); buf.Len() == 0 && time.Now().Before(deadline); {
+				tr.ForceFlush()
+			}
+			tr.Stop()
+			var span struct {
+				Error bool              `json:"error"`
+				Tags  map[string]string `json:"tags"`
+			}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &span))
+			assert.Equal(t, tt.want, statusState{
+				Error:       span.Error,
+				Code:        span.Tags[ext.StatusCode],
+				Description: span.Tags[ext.StatusDescription],
+				Message:     span.Tags[ext.ErrorMsg],
+			}, "tracer")
+		})
+	}
+}
+
+func TestSpanLogFieldsAt(t *testing.T) {
+	s := basicSpan("http.request")
+	at := time.Unix(10, 0)
+	s.LogFieldsAt(at, log.String("event", "cache.miss"))
+
+	logs := s.Logs()
+	assert.Len(t, logs, 1)
+	assert.Equal(t, at, logs[0].Timestamp)
+	assert.Equal(t, []log.Field{log.String("event", "cache.miss")}, logs[0].Fields)
+}
@@ -15,9 +15,10 @@ type spanContext struct {
 	priority     int
 	hasPriority  bool
 
-	spanID  uint64
-	traceID uint64
-	span    *mockspan // context owner
+	spanID      uint64
+	traceID     uint64
+	traceIDHigh uint64    // high bits of 128-bit trace IDs
+	span        *mockspan // context owner
 }
 
 func (sc *spanContext) TraceID() uint64 { return sc.traceID }
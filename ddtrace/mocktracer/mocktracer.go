@@ -5,11 +5,13 @@
 //
 // Simply call "Start" at the beginning of your tests to start and obtain an instance
 // of the mock tracer.
+//
+// Finished spans can be arranged into per trace trees using Trees, searched
+// using Find and asserted using AssertTrace, which reports the differences
+// between the expected and the actual trees.
 package mocktracer
 
 import (
-	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
@@ -38,14 +40,41 @@ type Tracer interface {
 // which allows querying it. Call Start at the beginning of your tests
 // to activate the mock tracer. When your test runs, use the returned
 // interface to query the tracer's state.
-func Start() Tracer {
-	var t mocktracer
-	ddtrace.SetGlobalTracer(&t)
+//
+// Span contexts are injected and extracted with the same propagator as the
+// tracer, tracer.NewPropagator(nil), unless set with WithPropagator.
+func Start(opts ...StartOption) Tracer {
+	t := newMockTracer(opts...)
+	ddtrace.SetGlobalTracer(t)
 	ddtrace.Testing = true
-	return &t
+	return t
+}
+
+func newMockTracer(opts ...StartOption) *mocktracer {
+	t := new(mocktracer)
+	for _, fn := range opts {
+		fn(t)
+	}
+	if t.propagator == nil {
+		t.propagator = tracer.NewPropagator(nil)
+	}
+	return t
+}
+
+// StartOption represents an option that can be passed to Start.
+type StartOption func(*mocktracer)
+
+// WithPropagator sets the propagator injecting and extracting span contexts,
+// such as one returned by tracer.NewPropagator for given propagation styles.
+func WithPropagator(p tracer.Propagator) StartOption {
+	return func(t *mocktracer) {
+		t.propagator = p
+	}
 }
 
 type mocktracer struct {
+	propagator tracer.Propagator
+
 	sync.RWMutex  // guards below spans
 	finishedSpans []Span
 }
@@ -88,74 +117,44 @@ func (t *mocktracer) addFinishedSpan(s Span) {
 	t.finishedSpans = append(t.finishedSpans, s)
 }
 
-const (
-	traceHeader    = tracer.DefaultTraceIDHeader
-	spanHeader     = tracer.DefaultParentIDHeader
-	priorityHeader = tracer.DefaultPriorityHeader
-	baggagePrefix  = tracer.DefaultBaggageHeaderPrefix
-)
-
 func (t *mocktracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
-	reader, ok := carrier.(tracer.TextMapReader)
-	if !ok {
-		return nil, tracer.ErrInvalidCarrier
-	}
-	var sc spanContext
-	err := reader.ForeachKey(func(key, v string) error {
-		k := strings.ToLower(key)
-		if k == traceHeader {
-			id, err := strconv.ParseUint(v, 10, 64)
-			if err != nil {
-				return tracer.ErrSpanContextCorrupted
-			}
-			sc.traceID = id
-		}
-		if k == spanHeader {
-			id, err := strconv.ParseUint(v, 10, 64)
-			if err != nil {
-				return tracer.ErrSpanContextCorrupted
-			}
-			sc.spanID = id
-		}
-		if k == priorityHeader {
-			p, err := strconv.Atoi(v)
-			if err != nil {
-				return tracer.ErrSpanContextCorrupted
-			}
-			sc.priority = p
-			sc.hasPriority = true
-		}
-		if strings.HasPrefix(k, baggagePrefix) {
-			sc.setBaggageItem(strings.TrimPrefix(k, baggagePrefix), v)
-		}
-		return nil
-	})
+	ctx, err := t.propagator.Extract(carrier)
 	if err != nil {
 		return nil, err
 	}
-	if sc.traceID == 0 || sc.spanID == 0 {
-		return nil, tracer.ErrSpanContextNotFound
+	sc := &spanContext{
+		traceIDHigh: tracer.TraceIDHigh(ctx),
+		traceID:     tracer.TraceID(ctx),
+		spanID:      tracer.SpanID(ctx),
 	}
-	return &sc, err
+	sc.priority, sc.hasPriority = tracer.SamplingPriority(ctx)
+	ctx.ForeachBaggageItem(func(k, v string) bool {
+		sc.setBaggageItem(k, v)
+		return true
+	})
+	return sc, nil
 }
 
 func (t *mocktracer) Inject(context ddtrace.SpanContext, carrier interface{}) error {
-	writer, ok := carrier.(tracer.TextMapWriter)
+	ctx, ok := context.(*spanContext)
 	if !ok {
-		return tracer.ErrInvalidCarrier
+		return t.propagator.Inject(context, carrier)
 	}
-	ctx, ok := context.(*spanContext)
-	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
-		return tracer.ErrInvalidSpanContext
+	cfg := tracer.SpanContextConfig{
+		TraceIDHigh: ctx.traceIDHigh,
+		TraceID:     ctx.traceID,
+		SpanID:      ctx.spanID,
 	}
-	writer.Set(traceHeader, strconv.FormatUint(ctx.traceID, 10))
-	writer.Set(spanHeader, strconv.FormatUint(ctx.spanID, 10))
 	if ctx.hasSamplingPriority() {
-		writer.Set(priorityHeader, strconv.Itoa(ctx.priority))
+		p := ctx.samplingPriority()
+		cfg.SamplingPriority = &p
 	}
 	ctx.ForeachBaggageItem(func(k, v string) bool {
-		writer.Set(baggagePrefix+k, v)
+		if cfg.Baggage == nil {
+			cfg.Baggage = make(map[string]string, 1)
+		}
+		cfg.Baggage[k] = v
 		return true
 	})
-	return nil
+	return t.propagator.Inject(tracer.NewSpanContext(cfg), carrier)
 }
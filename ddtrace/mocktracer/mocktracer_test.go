@@ -100,7 +100,7 @@ func TestTracerReset(t *testing.T) {
 
 func TestTracerInject(t *testing.T) {
 	t.Run("errors", func(t *testing.T) {
-		var mt mocktracer
+		mt := newMockTracer()
 		assert := assert.New(t)
 
 		err := mt.Inject(&spanContext{}, 2)
@@ -125,18 +125,47 @@ func TestTracerInject(t *testing.T) {
 			baggage:     map[string]string{"A": "B", "C": "D"},
 		}
 		carrier := make(map[string]string)
-		err := (&mocktracer{}).Inject(sctx, tracer.TextMapCarrier(carrier))
+		err := newMockTracer().Inject(sctx, tracer.TextMapCarrier(carrier))
 
 		assert := assert.New(t)
 		assert.Nil(err)
-		assert.Equal("1", carrier[traceHeader])
-		assert.Equal("2", carrier[spanHeader])
-		assert.Equal("-1", carrier[priorityHeader])
+		assert.Equal("0000000000000001", carrier[b3TraceHeader])
+		assert.Equal("0000000000000002", carrier[b3SpanHeader])
+		assert.Equal("0", carrier[b3SampledHeader])
 		assert.Equal("B", carrier[baggagePrefix+"A"])
 		assert.Equal("D", carrier[baggagePrefix+"C"])
 	})
+
+	t.Run("propagator", func(t *testing.T) {
+		sctx := &spanContext{
+			traceID:     1,
+			spanID:      2,
+			priority:    -1,
+			hasPriority: true,
+			baggage:     map[string]string{"A": "B"},
+		}
+		carrier := make(map[string]string)
+		mt := newMockTracer(WithPropagator(tracer.NewPropagator(&tracer.PropagatorConfig{Styles: []string{"datadog"}})))
+		err := mt.Inject(sctx, tracer.TextMapCarrier(carrier))
+
+		assert := assert.New(t)
+		assert.Nil(err)
+		assert.Equal(map[string]string{
+			tracer.DefaultTraceIDHeader:  "1",
+			tracer.DefaultParentIDHeader: "2",
+			tracer.DefaultPriorityHeader: "-1",
+			baggagePrefix + "A":          "B",
+		}, carrier)
+	})
 }
 
+const (
+	b3TraceHeader   = "x-b3-traceid"
+	b3SpanHeader    = "x-b3-spanid"
+	b3SampledHeader = "x-b3-sampled"
+	baggagePrefix   = tracer.DefaultBaggageHeaderPrefix
+)
+
 func TestTracerExtract(t *testing.T) {
 	// carry creates a tracer.TextMapCarrier containing the given sequence
 	// of key/value pairs.
@@ -171,7 +200,7 @@ func TestTracerExtract(t *testing.T) {
 		}
 	})
 
-	var mt mocktracer
+	mt := newMockTracer()
 
 	// tests error return values.
 	t.Run("errors", func(t *testing.T) {
@@ -180,13 +209,13 @@ func TestTracerExtract(t *testing.T) {
 		_, err := mt.Extract(2)
 		assert.Equal(tracer.ErrInvalidCarrier, err)
 
-		_, err = mt.Extract(carry(traceHeader, "a"))
+		_, err = mt.Extract(carry(b3TraceHeader, "z"))
 		assert.Equal(tracer.ErrSpanContextCorrupted, err)
 
-		_, err = mt.Extract(carry(spanHeader, "a", traceHeader, "2", baggagePrefix+"x", "y"))
+		_, err = mt.Extract(carry(b3SpanHeader, "z", b3TraceHeader, "2", baggagePrefix+"x", "y"))
 		assert.Equal(tracer.ErrSpanContextCorrupted, err)
 
-		_, err = mt.Extract(carry(spanHeader, "1"))
+		_, err = mt.Extract(carry(b3SpanHeader, "1"))
 		assert.Equal(tracer.ErrSpanContextNotFound, err)
 
 		_, err = mt.Extract(carry())
@@ -196,26 +225,52 @@ func TestTracerExtract(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		assert := assert.New(t)
 
-		ctx, err := mt.Extract(carry(traceHeader, "1", spanHeader, "2"))
+		ctx, err := mt.Extract(carry(b3TraceHeader, "a", b3SpanHeader, "b"))
 		assert.Nil(err)
 		sc, ok := ctx.(*spanContext)
 		assert.True(ok)
-		assert.Equal(uint64(1), sc.traceID)
-		assert.Equal(uint64(2), sc.spanID)
+		assert.Equal(uint64(10), sc.traceID)
+		assert.Equal(uint64(11), sc.spanID)
+		assert.False(sc.hasSamplingPriority())
 
-		ctx, err = mt.Extract(carry(traceHeader, "1", spanHeader, "2", baggagePrefix+"A", "B", baggagePrefix+"C", "D"))
+		ctx, err = mt.Extract(carry(b3TraceHeader, "1", b3SpanHeader, "2", baggagePrefix+"A", "B", baggagePrefix+"C", "D"))
 		assert.Nil(err)
 		sc, ok = ctx.(*spanContext)
 		assert.True(ok)
 		assert.Equal("B", sc.baggageItem("a"))
 		assert.Equal("D", sc.baggageItem("c"))
 
-		ctx, err = mt.Extract(carry(traceHeader, "1", spanHeader, "2", priorityHeader, "-1"))
+		ctx, err = mt.Extract(carry(b3TraceHeader, "1", b3SpanHeader, "2", b3SampledHeader, "1"))
 		assert.Nil(err)
 		sc, ok = ctx.(*spanContext)
 		assert.True(ok)
 		assert.True(sc.hasSamplingPriority())
+		assert.Equal(1, sc.samplingPriority())
+
+		ctx, err = mt.Extract(carry(b3TraceHeader, "0102030405060708090a0b0c0d0e0f10", b3SpanHeader, "2"))
+		assert.Nil(err)
+		sc, ok = ctx.(*spanContext)
+		assert.True(ok)
+		assert.Equal(uint64(0x0102030405060708), sc.traceIDHigh)
+		assert.Equal(uint64(0x090a0b0c0d0e0f10), sc.traceID)
+		child := newSpan(mt, "child", &ddtrace.StartSpanConfig{Parent: sc})
+		assert.Equal(sc.traceIDHigh, child.context.traceIDHigh)
+	})
+
+	t.Run("propagator", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := newMockTracer(WithPropagator(tracer.NewPropagator(&tracer.PropagatorConfig{Styles: []string{"datadog"}})))
+
+		ctx, err := mt.Extract(carry(tracer.DefaultTraceIDHeader, "1", tracer.DefaultParentIDHeader, "2", tracer.DefaultPriorityHeader, "-1"))
+		assert.Nil(err)
+		sc, ok := ctx.(*spanContext)
+		assert.True(ok)
+		assert.Equal(uint64(1), sc.traceID)
+		assert.Equal(uint64(2), sc.spanID)
 		assert.Equal(-1, sc.samplingPriority())
+
+		_, err = mt.Extract(carry(b3TraceHeader, "1", b3SpanHeader, "2"))
+		assert.Equal(tracer.ErrSpanContextNotFound, err)
 	})
 
 	t.Run("consistency", func(t *testing.T) {
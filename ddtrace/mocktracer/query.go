@@ -0,0 +1,214 @@
+package mocktracer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// TraceTree is the tree of the finished spans of a trace.
+type TraceTree struct {
+	// TraceID is the ID of the trace.
+	TraceID uint64
+
+	// Roots holds the spans of the trace whose parent is not among the
+	// finished spans, such as root spans and spans of remote parents.
+	Roots []*SpanNode
+}
+
+// SpanNode is a span of a TraceTree, along with its children.
+type SpanNode struct {
+	Span     Span
+	Children []*SpanNode
+}
+
+// Trees returns the trees of the traces of the given spans, such as the ones
+// returned by FinishedSpans. Traces are ordered by the start time of their
+// first span and the spans of a tree by start time.
+func Trees(spans []Span) []*TraceTree {
+	spans = sortedByStart(spans)
+	nodes := make(map[uint64]*SpanNode, len(spans))
+	for _, s := range spans {
+		nodes[s.SpanID()] = &SpanNode{Span: s}
+	}
+	var trees []*TraceTree
+	byTrace := make(map[uint64]*TraceTree)
+	for _, s := range spans {
+		tree, ok := byTrace[s.TraceID()]
+		if !ok {
+			tree = &TraceTree{TraceID: s.TraceID()}
+			byTrace[s.TraceID()] = tree
+			trees = append(trees, tree)
+		}
+		n := nodes[s.SpanID()]
+		if parent, ok := nodes[s.ParentID()]; ok && s.ParentID() != 0 && parent.Span.TraceID() == s.TraceID() {
+			parent.Children = append(parent.Children, n)
+		} else {
+			tree.Roots = append(tree.Roots, n)
+		}
+	}
+	return trees
+}
+
+// Tree returns the tree of the trace with the given ID among spans, or nil if
+// none of the spans belongs to it.
+func Tree(spans []Span, traceID uint64) *TraceTree {
+	for _, tree := range Trees(spans) {
+		if tree.TraceID == traceID {
+			return tree
+		}
+	}
+	return nil
+}
+
+// String renders the tree, one span per line indented by depth.
+func (t *TraceTree) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "trace %d\n", t.TraceID)
+	for _, n := range t.Roots {
+		n.write(&sb, 1)
+	}
+	return sb.String()
+}
+
+func (n *SpanNode) write(sb *strings.Builder, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString(describeSpan(n.Span))
+	sb.WriteByte('\n')
+	for _, c := range n.Children {
+		c.write(sb, depth+1)
+	}
+}
+
+// describeSpan returns a one line description of s.
+func describeSpan(s Span) string {
+	desc := s.OperationName()
+	if r, ok := s.Tag(ext.ResourceName).(string); ok && r != s.OperationName() {
+		desc += fmt.Sprintf(" resource=%q", r)
+	}
+	if k := spanKind(s); k != "" {
+		desc += " kind=" + k
+	}
+	return desc
+}
+
+// spanKind returns the upper-cased kind of s, or the empty string if unset.
+func spanKind(s Span) string {
+	return strings.ToUpper(fmt.Sprint(valueOr(s.Tag(ext.SpanKind), "")))
+}
+
+func valueOr(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
+
+func sortedByStart(spans []Span) []Span {
+	sorted := append([]Span(nil), spans...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime().Before(sorted[j].StartTime())
+	})
+	return sorted
+}
+
+// SpanFilter selects spans in Find.
+type SpanFilter func(Span) bool
+
+// Operation selects the spans with the given operation name.
+func Operation(name string) SpanFilter {
+	return func(s Span) bool { return s.OperationName() == name }
+}
+
+// Resource selects the spans with the given resource name.
+func Resource(name string) SpanFilter {
+	return func(s Span) bool { return s.Tag(ext.ResourceName) == name }
+}
+
+// Kind selects the spans of the given kind, such as ext.SpanKindServer.
+func Kind(kind string) SpanFilter {
+	return func(s Span) bool { return spanKind(s) == strings.ToUpper(kind) }
+}
+
+// Tag selects the spans with the tag key set to value. Values are equal when
+// deeply equal or formatted the same, so that 200 matches "200".
+func Tag(key string, value interface{}) SpanFilter {
+	return func(s Span) bool {
+		v := s.Tag(key)
+		return v != nil && tagEqual(v, value)
+	}
+}
+
+// HasTag selects the spans with the tag key set.
+func HasTag(key string) SpanFilter {
+	return func(s Span) bool { return s.Tag(key) != nil }
+}
+
+// ChildOf selects the children of the given span.
+func ChildOf(parent Span) SpanFilter {
+	return func(s Span) bool {
+		return s.TraceID() == parent.TraceID() && s.ParentID() == parent.SpanID()
+	}
+}
+
+func tagEqual(got, want interface{}) bool {
+	return reflect.DeepEqual(got, want) || fmt.Sprint(got) == fmt.Sprint(want)
+}
+
+// Find returns the spans selected by all the filters, in order of start time.
+func Find(spans []Span, filters ...SpanFilter) []Span {
+	var found []Span
+	for _, s := range sortedByStart(spans) {
+		if matchAll(s, filters) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// FindOne returns the first span, by start time, selected by all the filters,
+// or nil if there is none.
+func FindOne(spans []Span, filters ...SpanFilter) Span {
+	if found := Find(spans, filters...); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+func matchAll(s Span, filters []SpanFilter) bool {
+	for _, fn := range filters {
+		if !fn(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrWaitTimeout is returned by WaitForSpans when not enough spans finished
+// before the timeout.
+var ErrWaitTimeout = errors.New("mocktracer: timed out waiting for spans")
+
+// waitInterval is the interval at which WaitForSpans checks the finished spans.
+const waitInterval = 5 * time.Millisecond
+
+// WaitForSpans waits until at least n spans finished, such as the ones of
+// asynchronous operations, and returns the finished spans. It returns the
+// spans finished so far and ErrWaitTimeout when the timeout expires first.
+func WaitForSpans(mt Tracer, n int, timeout time.Duration) ([]Span, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		spans := mt.FinishedSpans()
+		if len(spans) >= n {
+			return spans, nil
+		}
+		if !time.Now().Before(deadline) {
+			return spans, ErrWaitTimeout
+		}
+		time.Sleep(waitInterval)
+	}
+}
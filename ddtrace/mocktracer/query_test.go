@@ -0,0 +1,135 @@
+package mocktracer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startTrace finishes a trace made of a server span, with a database and an
+// http client child, the latter having a child of its own.
+func startTrace(mt *mocktracer, start time.Time) Span {
+	root := mt.StartSpan("http.request",
+		tracer.StartTime(start),
+		tracer.ResourceName("GET /users"),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+		tracer.Tag(ext.HTTPCode, 200),
+	)
+	db := mt.StartSpan("db.query",
+		tracer.ChildOf(root.Context()),
+		tracer.StartTime(start.Add(time.Millisecond)),
+		tracer.ResourceName("SELECT * FROM users"),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+	)
+	db.Finish()
+	client := mt.StartSpan("http.request",
+		tracer.ChildOf(root.Context()),
+		tracer.StartTime(start.Add(2*time.Millisecond)),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+	)
+	mt.StartSpan("dns.lookup",
+		tracer.ChildOf(client.Context()),
+		tracer.StartTime(start.Add(3*time.Millisecond)),
+	).Finish()
+	client.Finish()
+	root.Finish()
+	return root.(Span)
+}
+
+func TestTrees(t *testing.T) {
+	assert := assert.New(t)
+	mt := newMockTracer()
+	start := time.Now()
+	root1 := startTrace(mt, start)
+	root2 := startTrace(mt, start.Add(-time.Second))
+
+	trees := Trees(mt.FinishedSpans())
+	assert.Len(trees, 2)
+	// ordered by start time
+	assert.Equal(root2.TraceID(), trees[0].TraceID)
+	assert.Equal(root1.TraceID(), trees[1].TraceID)
+
+	tree := Tree(mt.FinishedSpans(), root1.TraceID())
+	assert.Len(tree.Roots, 1)
+	assert.Equal(root1.SpanID(), tree.Roots[0].Span.SpanID())
+	children := tree.Roots[0].Children
+	assert.Len(children, 2)
+	assert.Equal("db.query", children[0].Span.OperationName())
+	assert.Equal("http.request", children[1].Span.OperationName())
+	assert.Len(children[1].Children, 1)
+	assert.Equal("dns.lookup", children[1].Children[0].Span.OperationName())
+
+	assert.Nil(Tree(mt.FinishedSpans(), 42))
+}
+
+func TestTreesRemoteParent(t *testing.T) {
+	mt := newMockTracer()
+	parent := mt.StartSpan("parent")
+	mt.StartSpan("child", tracer.ChildOf(parent.Context())).Finish()
+
+	trees := Trees(mt.FinishedSpans())
+	assert.Len(t, trees, 1)
+	assert.Len(t, trees[0].Roots, 1)
+	assert.Equal(t, "child", trees[0].Roots[0].Span.OperationName())
+}
+
+func TestTraceTreeString(t *testing.T) {
+	mt := newMockTracer()
+	root := startTrace(mt, time.Now())
+	tree := Tree(mt.FinishedSpans(), root.TraceID())
+	want := "trace " + fmtUint(root.TraceID()) + "\n" +
+		"  http.request resource=\"GET /users\" kind=SERVER\n" +
+		"    db.query resource=\"SELECT * FROM users\" kind=CLIENT\n" +
+		"    http.request kind=CLIENT\n" +
+		"      dns.lookup\n"
+	assert.Equal(t, want, tree.String())
+}
+
+func TestFind(t *testing.T) {
+	assert := assert.New(t)
+	mt := newMockTracer()
+	root := startTrace(mt, time.Now())
+	spans := mt.FinishedSpans()
+
+	found := Find(spans, Operation("http.request"))
+	assert.Len(found, 2)
+	assert.Equal(root.SpanID(), found[0].SpanID())
+
+	assert.Len(Find(spans, Operation("http.request"), Kind(ext.SpanKindClient)), 1)
+	assert.Len(Find(spans, Kind("server")), 1)
+	assert.Len(Find(spans, Resource("SELECT * FROM users")), 1)
+	assert.Len(Find(spans, Tag(ext.HTTPCode, "200")), 1)
+	assert.Len(Find(spans, Tag(ext.HTTPCode, 200)), 1)
+	assert.Len(Find(spans, HasTag(ext.SpanKind)), 3)
+	assert.Len(Find(spans, ChildOf(root)), 2)
+	assert.Len(Find(spans), 4)
+	assert.Empty(Find(spans, Operation("missing")))
+
+	assert.Equal("dns.lookup", FindOne(spans, Operation("dns.lookup")).OperationName())
+	assert.Nil(FindOne(spans, Operation("missing")))
+}
+
+func TestWaitForSpans(t *testing.T) {
+	t.Run("finished", func(t *testing.T) {
+		mt := newMockTracer()
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			mt.StartSpan("async").Finish()
+		}()
+		spans, err := WaitForSpans(mt, 1, time.Second)
+		assert.NoError(t, err)
+		assert.Len(t, spans, 1)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		mt := newMockTracer()
+		mt.StartSpan("sync").Finish()
+		spans, err := WaitForSpans(mt, 2, 10*time.Millisecond)
+		assert.Equal(t, ErrWaitTimeout, err)
+		assert.Len(t, spans, 1)
+	})
+}
@@ -15,6 +15,8 @@
 package opentracer
 
 import (
+	"io"
+
 	"github.com/opentracing/opentracing-go"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 )
@@ -37,10 +39,28 @@ func (t *opentracer) StartSpan(operationName string, options ...opentracing.Star
 		o.Apply(&sso)
 	}
 	opts := []ddtrace.StartSpanOption{ddtrace.WithStartTime(sso.StartTime)}
-	for _, ref := range sso.References {
-		if v, ok := ref.ReferencedContext.(ddtrace.SpanContext); ok && ref.Type == opentracing.ChildOfRef {
-			opts = append(opts, ddtrace.WithChildOf(v))
-			break // can only have one parent
+	// the parent is the first ChildOf reference, or the first FollowsFrom one
+	// if there is none; other references are links
+	parent := -1
+	for i, ref := range sso.References {
+		if ref.Type == opentracing.ChildOfRef {
+			parent = i
+			break
+		}
+		if ref.Type == opentracing.FollowsFromRef && parent < 0 {
+			parent = i
+		}
+	}
+	for i, ref := range sso.References {
+		switch {
+		case ref.ReferencedContext == nil:
+			continue
+		case i != parent:
+			opts = append(opts, ddtrace.WithLink(ref.ReferencedContext))
+		case ref.Type == opentracing.FollowsFromRef:
+			opts = append(opts, ddtrace.WithFollowsFrom(ref.ReferencedContext))
+		default:
+			opts = append(opts, ddtrace.WithChildOf(ref.ReferencedContext))
 		}
 	}
 	for k, v := range sso.Tags {
@@ -58,6 +78,11 @@ func (t *opentracer) Inject(ctx opentracing.SpanContext, format interface{}, car
 	switch format {
 	case opentracing.TextMap, opentracing.HTTPHeaders:
 		return t.Tracer.Inject(sctx, carrier)
+	case opentracing.Binary:
+		if _, ok := carrier.(io.Writer); !ok {
+			return opentracing.ErrInvalidCarrier
+		}
+		return t.Tracer.Inject(sctx, carrier)
 	default:
 		return opentracing.ErrUnsupportedFormat
 	}
@@ -68,7 +93,18 @@ func (t *opentracer) Extract(format interface{}, carrier interface{}) (opentraci
 	switch format {
 	case opentracing.TextMap, opentracing.HTTPHeaders:
 		return t.Tracer.Extract(carrier)
+	case opentracing.Binary:
+		if _, ok := carrier.(io.Reader); !ok {
+			return nil, opentracing.ErrInvalidCarrier
+		}
+		return t.Tracer.Extract(carrier)
 	default:
 		return nil, opentracing.ErrUnsupportedFormat
 	}
 }
+
+// Wrap returns an Opentracing compatible version of the given tracer, such as
+// one returned by tracer.New.
+func Wrap(t ddtrace.Tracer) opentracing.Tracer {
+	return &opentracer{t}
+}
@@ -0,0 +1,68 @@
+package opentracer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/opentracer"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func TestBinaryFormat(t *testing.T) {
+	assert := assert.New(t)
+	tr := tracer.New()
+	defer tr.Stop()
+	ot := opentracer.Wrap(tr)
+
+	span := ot.StartSpan("op")
+	span.SetBaggageItem("item", "x")
+	var buf bytes.Buffer
+	require.NoError(t, ot.Inject(span.Context(), opentracing.Binary, &buf))
+	sctx, err := ot.Extract(opentracing.Binary, &buf)
+	require.NoError(t, err)
+	assert.Equal(tracer.SpanID(span.Context()), tracer.SpanID(sctx))
+	assert.Equal(tracer.TraceID(span.Context()), tracer.TraceID(sctx))
+	sctx.ForeachBaggageItem(func(k, v string) bool {
+		assert.Equal("item", k)
+		assert.Equal("x", v)
+		return true
+	})
+
+	assert.Equal(opentracing.ErrInvalidCarrier, ot.Inject(span.Context(), opentracing.Binary, opentracing.TextMapCarrier{}))
+	_, err = ot.Extract(opentracing.Binary, opentracing.TextMapCarrier{})
+	assert.Equal(opentracing.ErrInvalidCarrier, err)
+}
+
+func TestReferences(t *testing.T) {
+	assert := assert.New(t)
+	tr := tracer.New()
+	defer tr.Stop()
+	ot := opentracer.Wrap(tr)
+
+	producer := ot.StartSpan("producer")
+	other := ot.StartSpan("other")
+	parent := ot.StartSpan("parent")
+
+	consumer := ot.StartSpan("consumer", opentracing.FollowsFrom(producer.Context()))
+	assert.Equal(tracer.TraceID(producer.Context()), tracer.TraceID(consumer.Context()))
+	assert.Equal("true", tracer.SpanTag(consumer.Context(), ext.FollowsFrom))
+	assert.Empty(tracer.SpanTag(consumer.Context(), ext.SpanLinks))
+
+	child := ot.StartSpan("child",
+		opentracing.FollowsFrom(producer.Context()),
+		opentracing.ChildOf(parent.Context()),
+		opentracing.ChildOf(other.Context()),
+	)
+	assert.Equal(tracer.TraceID(parent.Context()), tracer.TraceID(child.Context()))
+	assert.Empty(tracer.SpanTag(child.Context(), ext.FollowsFrom))
+	assert.Equal(
+		tracer.TraceIDHex(producer.Context())+":"+tracer.SpanIDHex(producer.Context())+","+
+			tracer.TraceIDHex(other.Context())+":"+tracer.SpanIDHex(other.Context()),
+		tracer.SpanTag(child.Context(), ext.SpanLinks),
+	)
+}
@@ -28,6 +28,23 @@ func WithChildOf(ctx SpanContext) StartSpanOption {
 	}
 }
 
+// WithFollowsFrom tells StartSpan to use the given span context as the parent
+// of the created span, which follows from it rather than being its child.
+func WithFollowsFrom(ctx SpanContext) StartSpanOption {
+	return func(cfg *StartSpanConfig) {
+		cfg.Parent = ctx
+		cfg.FollowsFrom = true
+	}
+}
+
+// WithLink links the created span to the span of the given context, in addition
+// to its parent.
+func WithLink(ctx SpanContext) StartSpanOption {
+	return func(cfg *StartSpanConfig) {
+		cfg.Links = append(cfg.Links, ctx)
+	}
+}
+
 // WithStartTime sets a custom time as the start time for the created span. By
 // default a span is started using the creation time.
 func WithStartTime(t time.Time) StartSpanOption {
@@ -0,0 +1,177 @@
+package tracer
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+)
+
+// binaryVersion is the version of the binary encoding of span contexts,
+// written as the first byte.
+const binaryVersion = 1
+
+// flags of the binary encoding of span contexts, written as the second byte.
+const (
+	binaryFlagTraceIDHigh = 1 << iota // the high bits of the trace ID follow
+	binaryFlagPriority                // a sampling priority is set
+)
+
+// maxBinaryStringLength is the maximum length of the baggage keys and values
+// of binary span contexts. Longer ones are considered corrupted.
+const maxBinaryStringLength = 64 << 10
+
+// injectBinary writes the binary encoding of ctx to w. It holds the version,
+// flags, trace and span IDs, sampling priority and baggage items, the latter as
+// length prefixed keys and values.
+func injectBinary(spanCtx ddtrace.SpanContext, w io.Writer) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	var flags byte
+	if ctx.traceIDHigh != 0 {
+		flags |= binaryFlagTraceIDHigh
+	}
+	if ctx.hasSamplingPriority() {
+		flags |= binaryFlagPriority
+	}
+	buf := []byte{binaryVersion, flags}
+	if ctx.traceIDHigh != 0 {
+		buf = appendUint64(buf, ctx.traceIDHigh)
+	}
+	buf = appendUint64(buf, ctx.traceID)
+	buf = appendUint64(buf, ctx.spanID)
+	if ctx.hasSamplingPriority() {
+		buf = appendVarint(buf, int64(ctx.samplingPriority()))
+	}
+	ctx.mu.RLock()
+	buf = appendUvarint(buf, uint64(len(ctx.baggage)))
+	for k, v := range ctx.baggage {
+		buf = appendString(buf, k)
+		buf = appendString(buf, v)
+	}
+	ctx.mu.RUnlock()
+	_, err := w.Write(buf)
+	return err
+}
+
+// extractBinary reads a span context encoded by injectBinary from r. It does
+// not read past the end of the span context.
+func extractBinary(r io.Reader) (ddtrace.SpanContext, error) {
+	br := &byteReader{r: r}
+	version, err := br.ReadByte()
+	if err == io.EOF {
+		return nil, ErrSpanContextNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version != binaryVersion {
+		return nil, ErrSpanContextCorrupted
+	}
+	flags, err := br.ReadByte()
+	if err != nil {
+		return nil, ErrSpanContextCorrupted
+	}
+	var ctx spanContext
+	if flags&binaryFlagTraceIDHigh != 0 {
+		if ctx.traceIDHigh, err = readUint64(br); err != nil {
+			return nil, ErrSpanContextCorrupted
+		}
+	}
+	if ctx.traceID, err = readUint64(br); err != nil {
+		return nil, ErrSpanContextCorrupted
+	}
+	if ctx.spanID, err = readUint64(br); err != nil {
+		return nil, ErrSpanContextCorrupted
+	}
+	if flags&binaryFlagPriority != 0 {
+		p, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, ErrSpanContextCorrupted
+		}
+		ctx.setSamplingPriority(int(p))
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, ErrSpanContextCorrupted
+	}
+	for i := uint64(0); i < n; i++ {
+		k, err := readString(br)
+		if err != nil {
+			return nil, ErrSpanContextCorrupted
+		}
+		v, err := readString(br)
+		if err != nil {
+			return nil, ErrSpanContextCorrupted
+		}
+		ctx.setBaggageItem(k, v)
+	}
+	if ctx.traceID == 0 || ctx.spanID == 0 {
+		return nil, ErrSpanContextCorrupted
+	}
+	return &ctx, nil
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+func readString(r *byteReader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > maxBinaryStringLength {
+		return "", ErrSpanContextCorrupted
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// byteReader implements io.ByteReader on top of an io.Reader, without reading
+// ahead.
+type byteReader struct {
+	r io.Reader
+	b [1]byte
+}
+
+func (r *byteReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r.r, r.b[:]); err != nil {
+		return 0, err
+	}
+	return r.b[0], nil
+}
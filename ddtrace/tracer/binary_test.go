@@ -0,0 +1,119 @@
+package tracer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+func TestBinaryInjectExtract(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer(WithIDGenerator(NewRandom128IDGenerator()))
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root")
+	root.SetBaggageItem("item", "x")
+	root.SetTag(ext.SamplingPriority, ext.PriorityUserReject)
+	ctx := root.Context().(*spanContext)
+
+	var buf bytes.Buffer
+	require.NoError(t, tracer.Inject(root.Context(), &buf))
+	buf.WriteString("payload")
+
+	sctx, err := tracer.Extract(&buf)
+	require.NoError(t, err)
+	xctx := sctx.(*spanContext)
+	assert.Equal(ctx.traceIDHigh, xctx.traceIDHigh)
+	assert.NotZero(xctx.traceIDHigh)
+	assert.Equal(ctx.traceID, xctx.traceID)
+	assert.Equal(ctx.spanID, xctx.spanID)
+	assert.Equal(ext.PriorityUserReject, xctx.samplingPriority())
+	assert.Equal("x", xctx.baggageItem("item"))
+	assert.Equal("payload", buf.String(), "read past the span context")
+
+	child := tracer.StartSpan("child", ChildOf(sctx)).(*span)
+	assert.Equal(ctx.spanID, child.ParentID)
+}
+
+func TestBinaryInjectExtract64(t *testing.T) {
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root")
+	var buf bytes.Buffer
+	require.NoError(t, tracer.Inject(root.Context(), &buf))
+	sctx, err := tracer.Extract(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, root.Context().(*spanContext).traceID, sctx.(*spanContext).traceID)
+	assert.Zero(t, sctx.(*spanContext).traceIDHigh)
+}
+
+// textMapBuffer is a carrier implementing both the TextMap and io interfaces.
+type textMapBuffer struct {
+	bytes.Buffer
+	TextMapCarrier
+}
+
+func TestBinaryTextMapPrecedence(t *testing.T) {
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root")
+	carrier := &textMapBuffer{TextMapCarrier: TextMapCarrier{}}
+	require.NoError(t, tracer.Inject(root.Context(), carrier))
+	assert.Zero(t, carrier.Len())
+	assert.NotEmpty(t, carrier.TextMapCarrier)
+
+	sctx, err := tracer.Extract(carrier)
+	require.NoError(t, err)
+	assert.Equal(t, root.Context().(*spanContext).traceID, sctx.(*spanContext).traceID)
+}
+
+func TestBinaryErrors(t *testing.T) {
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	var buf bytes.Buffer
+	assert.Equal(t, ErrInvalidSpanContext, tracer.Inject(&spanContext{}, &buf))
+
+	for name, tt := range map[string]struct {
+		data []byte
+		err  error
+	}{
+		"empty":     {nil, ErrSpanContextNotFound},
+		"version":   {[]byte{2, 0}, ErrSpanContextCorrupted},
+		"truncated": {[]byte{binaryVersion, 0, 1, 2, 3}, ErrSpanContextCorrupted},
+		"zero-ids":  {append([]byte{binaryVersion, 0}, make([]byte, 17)...), ErrSpanContextCorrupted},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := tracer.Extract(bytes.NewReader(tt.data))
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestBinaryBaggageLimits(t *testing.T) {
+	tracer := newTracer(WithSpanLimits(SpanLimits{MaxBaggageItems: 1}))
+	defer tracer.Stop()
+
+	other := newTracer()
+	defer other.Stop()
+	span := other.StartSpan("root")
+	span.SetBaggageItem("a", "1")
+	span.SetBaggageItem("b", "2")
+
+	var buf bytes.Buffer
+	require.NoError(t, other.Inject(span.Context(), &buf))
+	sctx, err := tracer.Extract(&buf)
+	require.NoError(t, err)
+	var n int
+	sctx.ForeachBaggageItem(func(k, v string) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,201 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// chromeEvent is an event of the Chrome Trace Event format, described at
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type chromeEvent struct {
+	Name  string                 `json:"name"`
+	Cat   string                 `json:"cat,omitempty"`
+	Phase string                 `json:"ph"`
+	TS    float64                `json:"ts"`
+	Dur   *float64               `json:"dur,omitempty"`
+	PID   int                    `json:"pid"`
+	TID   int                    `json:"tid"`
+	Scope string                 `json:"s,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+// chromeWriter writes traces as Chrome Trace Event JSON events separated by
+// commas. The consoleTransport opens the array, whose closing bracket is
+// optional, so that traces can be appended as they are flushed. Every service
+// is a process. The spans of a trace are laid out on threads of their service
+// such that spans sharing a thread nest, a child staying on the thread of its
+// parent whenever possible. Span logs are instant events on the thread of
+// their span. Processes are named again in every payload, so that the payloads
+// written to a new file, such as a rotated one, are self-contained.
+type chromeWriter struct {
+	pids    map[string]int // process IDs by service
+	nextTID int
+
+	// state of the current payload
+	events int             // number of events written
+	named  map[string]bool // services whose process was named
+}
+
+func newChromeWriter() *chromeWriter {
+	return &chromeWriter{pids: make(map[string]int), named: make(map[string]bool)}
+}
+
+// reset starts a new payload.
+func (c *chromeWriter) reset() {
+	c.events = 0
+	c.named = make(map[string]bool)
+}
+
+// chromeLane is a thread of a service within a trace, holding the end times
+// of the spans open on it.
+type chromeLane struct {
+	tid  int
+	ends []int64
+}
+
+// fits reports whether a span from start to end can be placed on the lane,
+// closing the spans which ended before start.
+func (l *chromeLane) fits(start, end int64) bool {
+	for len(l.ends) > 0 && l.ends[len(l.ends)-1] <= start {
+		l.ends = l.ends[:len(l.ends)-1]
+	}
+	return len(l.ends) == 0 || end <= l.ends[len(l.ends)-1]
+}
+
+// write writes the events of trace to w.
+func (c *chromeWriter) write(w *bytes.Buffer, trace spanList) error {
+	spans := append(spanList(nil), trace...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		// parents first
+		return spans[i].Duration > spans[j].Duration
+	})
+	traceID := traceIDToHex(spans[0].traceIDHigh, spans[0].TraceID)
+	lanes := make(map[string][]*chromeLane) // lanes by service
+	laneOf := make(map[uint64]*chromeLane)  // lanes by span ID
+
+	for _, s := range spans {
+		pid, ok := c.pids[s.Service]
+		if !ok {
+			pid = len(c.pids) + 1
+			c.pids[s.Service] = pid
+		}
+		if !c.named[s.Service] {
+			c.named[s.Service] = true
+			if err := c.event(w, &chromeEvent{Name: "process_name", Phase: "M", PID: pid,
+				Args: map[string]interface{}{"name": s.Service}}); err != nil {
+				return err
+			}
+		}
+
+		start, end := s.Start, s.Start+s.Duration
+		var lane *chromeLane
+		if parent, ok := laneOf[s.ParentID]; ok && s.ParentID != 0 && spanService(trace, s.ParentID) == s.Service && parent.fits(start, end) {
+			lane = parent
+		}
+		for _, l := range lanes[s.Service] {
+			if lane != nil {
+				break
+			}
+			if l.fits(start, end) {
+				lane = l
+			}
+		}
+		if lane == nil {
+			c.nextTID++
+			lane = &chromeLane{tid: c.nextTID}
+			lanes[s.Service] = append(lanes[s.Service], lane)
+			if err := c.event(w, &chromeEvent{Name: "thread_name", Phase: "M", PID: pid, TID: lane.tid,
+				Args: map[string]interface{}{"name": "trace " + traceID + " #" + strconv.Itoa(len(lanes[s.Service]))}}); err != nil {
+				return err
+			}
+		}
+		lane.ends = append(lane.ends, end)
+		laneOf[s.SpanID] = lane
+
+		if err := c.event(w, chromeSpanEvent(s, traceID, pid, lane.tid)); err != nil {
+			return err
+		}
+		for _, l := range s.Logs {
+			name := "log"
+			if e, ok := l.fields["event"].(string); ok {
+				name = e
+			}
+			if err := c.event(w, &chromeEvent{Name: name, Phase: "i", Scope: "t",
+				TS: chromeMicros(l.time.UnixNano()), PID: pid, TID: lane.tid, Args: l.fields}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// event writes e to w, preceded by a separator unless it is the first event
+// of the payload.
+func (c *chromeWriter) event(w *bytes.Buffer, e *chromeEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if c.events > 0 {
+		w.WriteString(",\n")
+	}
+	c.events++
+	w.Write(data)
+	return nil
+}
+
+func chromeSpanEvent(s *span, traceID string, pid, tid int) *chromeEvent {
+	dur := chromeMicros(s.Duration)
+	args := map[string]interface{}{
+		"trace_id": traceID,
+		"span_id":  idToHex(s.SpanID),
+	}
+	if s.ParentID != 0 {
+		args["parent_id"] = idToHex(s.ParentID)
+	}
+	if s.Resource != "" && s.Resource != s.Name {
+		args["resource"] = s.Resource
+	}
+	if kind := spanKindOf(s); kind != "" {
+		args["kind"] = kind
+	}
+	if s.Error != 0 {
+		args["error"] = true
+	}
+	for k, v := range s.Meta {
+		args[k] = v
+	}
+	for k, v := range s.Metrics {
+		args[k] = v
+	}
+	return &chromeEvent{
+		Name:  s.Name,
+		Cat:   s.Type,
+		Phase: "X",
+		TS:    chromeMicros(s.Start),
+		Dur:   &dur,
+		PID:   pid,
+		TID:   tid,
+		Args:  args,
+	}
+}
+
+// spanService returns the service of the span of trace with the given ID.
+func spanService(trace spanList, id uint64) string {
+	for _, s := range trace {
+		if s.SpanID == id {
+			return s.Service
+		}
+	}
+	return ""
+}
+
+// chromeMicros converts nanoseconds to the microseconds of Chrome events.
+func chromeMicros(ns int64) float64 {
+	return float64(ns) / 1e3
+}
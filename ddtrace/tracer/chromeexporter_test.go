@@ -0,0 +1,185 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseChromeEvents parses an array of events written by the Chrome exporter,
+// closing it as trace viewers do.
+func parseChromeEvents(t *testing.T, data string) []chromeEvent {
+	var events []chromeEvent
+	require.NoError(t, json.Unmarshal([]byte(data+"]"), &events))
+	return events
+}
+
+func TestChromeWriter(t *testing.T) {
+	p := newConsolePayload(ConsoleChrome)
+	require.NoError(t, p.push(consoleTestTrace()))
+	assert.Equal(t, 3, p.itemCount())
+	out := p.buf.String()
+	assert.True(t, strings.HasPrefix(out, "{"))
+
+	events := parseChromeEvents(t, "[\n"+out)
+	var summary []string
+	for _, e := range events {
+		summary = append(summary, e.Phase+" "+e.Name)
+	}
+	assert.Equal(t, []string{
+		"M process_name",
+		"M thread_name",
+		"X http.request",
+		"M process_name",
+		"M thread_name",
+		"X db.query",
+		"i retry",
+		"X dns",
+	}, summary)
+
+	root, child, log, grandchild := events[2], events[5], events[6], events[7]
+	assert.Equal(t, float64(1e6), root.TS)
+	assert.Equal(t, float64(10e3), *root.Dur)
+	assert.Equal(t, "SERVER", root.Args["kind"])
+	assert.Equal(t, "GET /users", root.Args["resource"])
+	assert.Equal(t, "GET", root.Args["http.method"])
+	assert.Equal(t, "0000000000000001", root.Args["trace_id"])
+	assert.Equal(t, map[string]interface{}{"name": "api"}, events[0].Args)
+	assert.Equal(t, map[string]interface{}{"name": "trace 0000000000000001 #1"}, events[1].Args)
+
+	// the services are distinct processes, the grandchild nests in the
+	// child on its thread
+	assert.NotEqual(t, root.PID, child.PID)
+	assert.Equal(t, child.PID, grandchild.PID)
+	assert.Equal(t, child.TID, grandchild.TID)
+	assert.Equal(t, child.TID, log.TID)
+	assert.Equal(t, "sql", child.Cat)
+	assert.Equal(t, true, child.Args["error"])
+	assert.Equal(t, float64(3), child.Args["rows"])
+	assert.Equal(t, "0000000000000001", child.Args["parent_id"])
+	assert.Equal(t, "t", log.Scope)
+	assert.Equal(t, float64(1002e3), log.TS)
+
+	// processes keep their IDs across payloads, each naming them again
+	p.reset()
+	require.NoError(t, p.push(consoleTestTrace()))
+	events2 := parseChromeEvents(t, "[\n"+p.buf.String())
+	assert.Len(t, events2, 8)
+	assert.Equal(t, events[0], events2[0])
+	assert.Equal(t, events[3], events2[3])
+	assert.NotEqual(t, events[1].TID, events2[1].TID)
+}
+
+func TestChromeWriterLanes(t *testing.T) {
+	start := time.Unix(1, 0).UnixNano()
+	ms := int64(time.Millisecond)
+	newSpan := func(id, parent uint64, offset, dur int64) *span {
+		return &span{Name: "op", Service: "svc", SpanID: id, ParentID: parent, TraceID: 1,
+			Start: start + offset*ms, Duration: dur * ms}
+	}
+	trace := spanList{
+		newSpan(1, 0, 0, 10),
+		newSpan(2, 1, 1, 5), // concurrent siblings
+		newSpan(3, 1, 2, 5),
+		newSpan(4, 3, 3, 1),
+		newSpan(5, 1, 8, 1), // after both siblings ended
+	}
+	var buf bytes.Buffer
+	require.NoError(t, newChromeWriter().write(&buf, trace))
+
+	tids := make(map[string]int)
+	for _, e := range parseChromeEvents(t, "[\n"+buf.String()) {
+		if e.Phase == "X" {
+			tids[e.Args["span_id"].(string)] = e.TID
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"0000000000000001": 1,
+		"0000000000000002": 1,
+		"0000000000000003": 2,
+		"0000000000000004": 2,
+		"0000000000000005": 1,
+	}, tids)
+}
+
+func TestChromeExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer, _, stop := startTestTracer(WithConsoleExporter(&buf, ConsoleChrome), WithServiceName("svc"))
+	defer stop()
+
+	root := tracer.StartSpan("root")
+	tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+	root.Finish()
+	tracer.ForceFlush()
+
+	var spans int
+	for _, e := range parseChromeEvents(t, buf.String()) {
+		if e.Phase == "X" {
+			spans++
+			assert.Equal(t, 1, e.PID)
+		}
+	}
+	assert.Equal(t, 2, spans)
+}
+
+func TestChromeRotatingFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "chrome")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.json")
+
+	// send writes n traces, one per payload, to a new file of the given size
+	send := func(maxSize int64, n int) {
+		f, err := NewRotatingFile(path, maxSize, 10)
+		require.NoError(err)
+		defer f.Close()
+		tr := &consoleTransport{w: f, chrome: true}
+		p := newConsolePayload(ConsoleChrome)
+		for i := 0; i < n; i++ {
+			require.NoError(p.push(consoleTestTrace()))
+			_, err := tr.send(p)
+			require.NoError(err)
+			p.reset()
+		}
+	}
+	// every payload rotates the file
+	send(100, 3)
+	// a restarted process continues the array of the current file
+	send(1<<20, 1)
+
+	// spans returns the number of spans of the file, after checking that it
+	// is a valid array naming the processes of its events
+	spans := func(name string) int {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(err)
+		require.True(bytes.HasPrefix(data, []byte("[\n")), name)
+		named := make(map[int]bool)
+		n := 0
+		for _, e := range parseChromeEvents(t, string(data)) {
+			if e.Name == "process_name" {
+				named[e.PID] = true
+			}
+			assert.True(named[e.PID], "process %d of %s is not named", e.PID, name)
+			if e.Phase == "X" {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(6, spans("traces.json"))
+	assert.Equal(3, spans("traces.json.1"))
+	assert.Equal(3, spans("traces.json.2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(os.IsNotExist(err))
+}
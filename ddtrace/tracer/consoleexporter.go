@@ -0,0 +1,409 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsoleFormat specifies how the console exporter writes traces.
+type ConsoleFormat int
+
+const (
+	// ConsoleTree writes every trace as a human-readable tree of spans,
+	// indented by parent, with their durations, tags and logs.
+	ConsoleTree ConsoleFormat = iota
+
+	// ConsoleJSON writes every span as a JSON object on its own line.
+	ConsoleJSON
+
+	// ConsoleChrome writes traces as Chrome Trace Event JSON, to be opened
+	// in chrome://tracing or Perfetto for performance analysis. Spans are
+	// complete events of the process of their service and logs are instant
+	// events. The file is a JSON array whose closing bracket is omitted, so
+	// that traces can be appended to it.
+	ConsoleChrome
+)
+
+// String returns the name of the format, "tree", "json" or "chrome".
+func (f ConsoleFormat) String() string {
+	switch f {
+	case ConsoleTree:
+		return "tree"
+	case ConsoleJSON:
+		return "json"
+	case ConsoleChrome:
+		return "chrome"
+	default:
+		return fmt.Sprintf("ConsoleFormat(%d)", int(f))
+	}
+}
+
+// ParseConsoleFormat returns the format named "tree", "json" or "chrome".
+func ParseConsoleFormat(name string) (ConsoleFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "tree":
+		return ConsoleTree, nil
+	case "json":
+		return ConsoleJSON, nil
+	case "chrome":
+		return ConsoleChrome, nil
+	default:
+		return 0, fmt.Errorf("unknown console format %q", name)
+	}
+}
+
+// WithConsoleExporter writes finished traces to w in the given format instead
+// of sending them over the network. It is meant for local development. Writes
+// happen from the tracer's worker, at every flush; use a RotatingFile to write
+// to a file of bounded size.
+func WithConsoleExporter(w io.Writer, format ConsoleFormat) StartOption {
+	return func(c *config) {
+		c.payload = newConsolePayload(format)
+		c.transport = &consoleTransport{w: w, chrome: format == ConsoleChrome}
+	}
+}
+
+var _ encoder = (*consolePayload)(nil)
+
+// consolePayload renders traces as text as they are pushed.
+type consolePayload struct {
+	format    ConsoleFormat
+	buf       bytes.Buffer
+	spanCount int
+	chrome    *chromeWriter // for ConsoleChrome
+}
+
+func newConsolePayload(format ConsoleFormat) *consolePayload {
+	p := &consolePayload{format: format}
+	if format == ConsoleChrome {
+		p.chrome = newChromeWriter()
+	}
+	return p
+}
+
+func (p *consolePayload) Read(b []byte) (n int, err error) {
+	return p.buf.Read(b)
+}
+
+func (p *consolePayload) push(t spanList) error {
+	if len(t) == 0 {
+		return nil
+	}
+	switch p.format {
+	case ConsoleJSON:
+		for _, s := range t {
+			data, err := json.Marshal(newConsoleSpan(s))
+			if err != nil {
+				return err
+			}
+			p.buf.Write(data)
+			p.buf.WriteByte('\n')
+		}
+	case ConsoleChrome:
+		if err := p.chrome.write(&p.buf, t); err != nil {
+			return err
+		}
+	default:
+		writeTree(&p.buf, t)
+	}
+	p.spanCount += len(t)
+	return nil
+}
+
+func (p *consolePayload) itemCount() int {
+	return p.spanCount
+}
+
+func (p *consolePayload) size() int {
+	return p.buf.Len()
+}
+
+func (p *consolePayload) reset() {
+	p.buf.Reset()
+	p.spanCount = 0
+	if p.chrome != nil {
+		p.chrome.reset()
+	}
+}
+
+// consoleSpan is the JSON representation of a span written by ConsoleJSON.
+type consoleSpan struct {
+	TraceID    string             `json:"trace_id"`
+	SpanID     string             `json:"span_id"`
+	ParentID   string             `json:"parent_id,omitempty"`
+	Name       string             `json:"name"`
+	Service    string             `json:"service"`
+	Resource   string             `json:"resource,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Start      time.Time          `json:"start"`
+	DurationNs int64              `json:"duration_ns"`
+	Error      bool               `json:"error,omitempty"`
+	Tags       map[string]string  `json:"tags,omitempty"`
+	Metrics    map[string]float64 `json:"metrics,omitempty"`
+	Logs       []consoleLog       `json:"logs,omitempty"`
+}
+
+type consoleLog struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Fields    map[string]interface{} `json:"fields"`
+}
+
+func newConsoleSpan(s *span) *consoleSpan {
+	cs := &consoleSpan{
+		TraceID:    traceIDToHex(s.traceIDHigh, s.TraceID),
+		SpanID:     idToHex(s.SpanID),
+		Name:       s.Name,
+		Service:    s.Service,
+		Resource:   s.Resource,
+		Type:       s.Type,
+		Start:      time.Unix(0, s.Start).UTC(),
+		DurationNs: s.Duration,
+		Error:      s.Error != 0,
+		Tags:       s.Meta,
+		Metrics:    s.Metrics,
+	}
+	if s.ParentID != 0 {
+		cs.ParentID = idToHex(s.ParentID)
+	}
+	cs.Kind = spanKindOf(s)
+	for _, l := range s.Logs {
+		cs.Logs = append(cs.Logs, consoleLog{Timestamp: l.time.UTC(), Fields: l.fields})
+	}
+	return cs
+}
+
+// writeTree writes the spans of trace as a tree, children being indented
+// below their parent in the order they started.
+func writeTree(w *bytes.Buffer, trace spanList) {
+	ids := make(map[uint64]bool, len(trace))
+	for _, s := range trace {
+		ids[s.SpanID] = true
+	}
+	var roots []*span
+	children := make(map[uint64][]*span)
+	for _, s := range trace {
+		if s.ParentID != 0 && ids[s.ParentID] && s.ParentID != s.SpanID {
+			children[s.ParentID] = append(children[s.ParentID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+	fmt.Fprintf(w, "trace %s (%d spans)\n", traceIDToHex(trace[0].traceIDHigh, trace[0].TraceID), len(trace))
+	var walk func(spans []*span, depth int)
+	walk = func(spans []*span, depth int) {
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+		for _, s := range spans {
+			writeTreeSpan(w, s, depth)
+			walk(children[s.SpanID], depth+1)
+		}
+	}
+	walk(roots, 1)
+}
+
+// writeTreeSpan writes a single span of the tree with its tags and logs.
+func writeTreeSpan(w *bytes.Buffer, s *span, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s [%s]", indent, s.Name, s.Service)
+	if s.Resource != "" && s.Resource != s.Name {
+		fmt.Fprintf(w, " %s", s.Resource)
+	}
+	fmt.Fprintf(w, " %s", time.Duration(s.Duration))
+	if kind := spanKindOf(s); kind != "" {
+		fmt.Fprintf(w, " %s", kind)
+	}
+	if s.Error != 0 {
+		w.WriteString(" ERROR")
+	}
+	w.WriteByte('\n')
+
+	indent += "    "
+	keys := make([]string, 0, len(s.Meta))
+	for k := range s.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, k, s.Meta[k])
+	}
+	keys = keys[:0]
+	for k := range s.Metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s: %v\n", indent, k, s.Metrics[k])
+	}
+	for _, l := range s.Logs {
+		fmt.Fprintf(w, "%slog +%s:", indent, l.time.Sub(time.Unix(0, s.Start)))
+		keys = keys[:0]
+		for k := range l.fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, " %s=%v", k, l.fields[k])
+		}
+		w.WriteByte('\n')
+	}
+}
+
+// Chrome payloads open the JSON array of events, or continue it.
+var (
+	chromeArrayStart = []byte("[\n")
+	chromeArrayNext  = []byte(",\n")
+)
+
+// consoleTransport writes payloads to an io.Writer.
+type consoleTransport struct {
+	w io.Writer
+
+	// chrome is true for ConsoleChrome payloads, which are prefixed so that
+	// they open the array of events of every new file or continue it.
+	chrome  bool
+	started bool // whether the array was opened on w, unless a RotatingFile
+}
+
+func (t *consoleTransport) send(p encoder) (body io.ReadCloser, err error) {
+	// write the whole payload at once so that rotating files never split it
+	data, err := ioutil.ReadAll(p)
+	if err != nil {
+		return nil, err
+	}
+	if t.chrome && len(data) > 0 {
+		err = t.writeChrome(data)
+	} else {
+		_, err = t.w.Write(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot write traces: %v", err)
+	}
+	return ioutil.NopCloser(bytes.NewReader(nil)), nil
+}
+
+// writeChrome writes Chrome events, opening the array of events if they are
+// the first ones written to w, else continuing it.
+func (t *consoleTransport) writeChrome(data []byte) error {
+	if f, ok := t.w.(*RotatingFile); ok {
+		// the file knows whether it is new, once rotated or reopened
+		_, err := f.writePrefixed(chromeArrayStart, chromeArrayNext, data)
+		return err
+	}
+	prefix := chromeArrayNext
+	if !t.started {
+		prefix, t.started = chromeArrayStart, true
+	}
+	_, err := t.w.Write(append(prefix[:len(prefix):len(prefix)], data...))
+	return err
+}
+
+// RotatingFile is an io.WriteCloser appending to a file, which is renamed with
+// a ".1" suffix and replaced by an empty file whenever a write would make it
+// exceed its maximum size. Older files are shifted to ".2", ".3" and so on,
+// up to the maximum number of backups. It is safe for concurrent use.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+// NewRotatingFile opens or creates the file at path for appending. The file is
+// rotated when it would exceed maxSize bytes, keeping at most maxBackups
+// rotated files.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, errors.New("maximum size must be positive")
+	}
+	r := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, fi.Size()
+	return nil
+}
+
+// Write implements io.Writer.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	return r.writePrefixed(nil, nil, p)
+}
+
+// writePrefixed writes p preceded by first if the file is empty, once rotated
+// if needed, and by next otherwise. first and next must have the same length.
+func (r *RotatingFile) writePrefixed(first, next, p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(next)+len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	prefix := next
+	if r.size == 0 {
+		prefix = first
+	}
+	if len(prefix) > 0 {
+		p = append(prefix[:len(prefix):len(prefix)], p...)
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups and starts a new file. r.mu must be held.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+	if r.maxBackups > 0 {
+		for i := r.maxBackups - 1; i > 0; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close closes the file. Subsequent writes fail.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
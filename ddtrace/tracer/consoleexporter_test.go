@@ -0,0 +1,149 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// consoleTestTrace returns a trace made of a server root span with a client
+// child and a grandchild.
+func consoleTestTrace() spanList {
+	start := time.Unix(1, 0).UnixNano()
+	root := &span{Name: "http.request", Service: "api", Resource: "GET /users", SpanID: 1, TraceID: 1,
+		Start: start, Duration: int64(10 * time.Millisecond),
+		Meta: map[string]string{"span.kind": "server", "http.method": "GET"}, Metrics: map[string]float64{}}
+	child := &span{Name: "db.query", Service: "db", Resource: "db.query", SpanID: 2, TraceID: 1, ParentID: 1,
+		Start: start + int64(time.Millisecond), Duration: int64(2 * time.Millisecond), Type: ext.SpanTypeSQL, Error: 1,
+		Meta: map[string]string{}, Metrics: map[string]float64{"rows": 3},
+		Logs: []*logFields{{time: time.Unix(0, start+int64(2*time.Millisecond)), fields: map[string]interface{}{"event": "retry"}}}}
+	grandchild := &span{Name: "dns", Service: "db", SpanID: 3, TraceID: 1, ParentID: 2,
+		Start: start + int64(time.Millisecond), Duration: int64(time.Millisecond),
+		Meta: map[string]string{}, Metrics: map[string]float64{}}
+	return spanList{grandchild, child, root}
+}
+
+func TestConsolePayload(t *testing.T) {
+	t.Run("tree", func(t *testing.T) {
+		p := newConsolePayload(ConsoleTree)
+		require.NoError(t, p.push(consoleTestTrace()))
+		assert.Equal(t, 3, p.itemCount())
+		out, err := ioutil.ReadAll(p)
+		require.NoError(t, err)
+		assert.Equal(t, strings.Join([]string{
+			"trace 0000000000000001 (3 spans)",
+			"  http.request [api] GET /users 10ms SERVER",
+			"      http.method: GET",
+			"      span.kind: server",
+			"    db.query [db] 2ms CLIENT ERROR",
+			"        rows: 3",
+			"        log +1ms: event=retry",
+			"      dns [db] 1ms",
+			"",
+		}, "\n"), string(out))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		p := newConsolePayload(ConsoleJSON)
+		require.NoError(t, p.push(consoleTestTrace()))
+		lines := strings.Split(strings.TrimSpace(p.buf.String()), "\n")
+		require.Len(t, lines, 3)
+
+		var s map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &s))
+		assert.Equal(t, "0000000000000002", s["span_id"])
+		assert.Equal(t, "0000000000000001", s["parent_id"])
+		assert.Equal(t, "CLIENT", s["kind"])
+		assert.Equal(t, true, s["error"])
+		assert.Equal(t, float64(2*time.Millisecond), s["duration_ns"])
+		assert.Equal(t, "1970-01-01T00:00:01.001Z", s["start"])
+		assert.Equal(t, map[string]interface{}{"event": "retry"}, s["logs"].([]interface{})[0].(map[string]interface{})["fields"])
+
+		p.reset()
+		assert.Equal(t, 0, p.itemCount())
+		assert.Equal(t, 0, p.size())
+	})
+
+	t.Run("format", func(t *testing.T) {
+		f, err := ParseConsoleFormat(" JSON")
+		assert.NoError(t, err)
+		assert.Equal(t, ConsoleJSON, f)
+		assert.Equal(t, "tree", ConsoleTree.String())
+		f, err = ParseConsoleFormat("chrome")
+		assert.NoError(t, err)
+		assert.Equal(t, "chrome", f.String())
+		_, err = ParseConsoleFormat("xml")
+		assert.Error(t, err)
+	})
+}
+
+func TestConsoleExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer, _, stop := startTestTracer(WithConsoleExporter(&buf, ConsoleJSON), WithServiceName("svc"))
+	defer stop()
+
+	root := tracer.StartSpan("root")
+	tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+	root.Finish()
+	tracer.ForceFlush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, l := range lines {
+		var s consoleSpan
+		require.NoError(t, json.Unmarshal([]byte(l), &s))
+		assert.Equal(t, "svc", s.Service)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "rotating")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.log")
+
+	_, err = NewRotatingFile(path, 0, 1)
+	assert.Error(err)
+
+	f, err := NewRotatingFile(path, 10, 2)
+	require.NoError(err)
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n", "this is longer than 10\n"} {
+		_, err := f.Write([]byte(s))
+		require.NoError(err)
+	}
+	require.NoError(f.Close())
+	_, err = f.Write([]byte("x"))
+	assert.Error(err)
+
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(err)
+		return string(data)
+	}
+	assert.Equal("this is longer than 10\n", read("traces.log"))
+	assert.Equal("dddddd\n", read("traces.log.1"))
+	assert.Equal("cccccc\n", read("traces.log.2"))
+	_, err = os.Stat(filepath.Join(dir, "traces.log.3"))
+	assert.True(os.IsNotExist(err))
+
+	// reopening appends to the existing file
+	f, err = NewRotatingFile(path, 100, 2)
+	require.NoError(err)
+	_, err = f.Write([]byte("more\n"))
+	require.NoError(err)
+	require.NoError(f.Close())
+	assert.Equal("this is longer than 10\nmore\n", read("traces.log"))
+}
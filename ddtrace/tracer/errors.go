@@ -28,6 +28,16 @@ type dataLossError struct {
 	context error // any context error, if available
 }
 
+// metricsLossError is returned when datapoints could not be sent.
+type metricsLossError struct {
+	count   int   // number of datapoints lost
+	context error // any context error, if available
+}
+
+func (e *metricsLossError) Error() string {
+	return fmt.Sprintf("lost datapoints (count: %d), error: %v", e.count, e.context)
+}
+
 type closeError struct {
 	msg string
 }
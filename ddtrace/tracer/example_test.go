@@ -1,6 +1,7 @@
 package tracer
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 
@@ -32,3 +33,39 @@ func Example() {
 		log.Fatal(err)
 	}
 }
+
+// An example demonstrating how to trace functions, including the ones run
+// concurrently by a group.
+func ExampleTrace() {
+	Start(WithAgentAddr("host:port"))
+	defer Stop()
+
+	err := Trace(context.Background(), "sync.files", func(ctx context.Context) error {
+		g, _ := NewGroup(ctx)
+		for _, name := range []string{"a.json", "b.json"} {
+			name := name
+			g.Go("read.file", func(ctx context.Context) error {
+				_, err := ioutil.ReadFile(name)
+				return err
+			}, ResourceName(name))
+		}
+		return g.Wait()
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// An example demonstrating how to report the spans of a library under its own
+// service name with a tracer which is not the global one.
+func ExampleNew() {
+	t := New(WithServiceName("billing-client"), WithAgentAddr("host:port"))
+	defer t.Stop()
+
+	span := t.StartSpan("invoice.create")
+	defer span.Finish()
+
+	// Spans started as children of span are sent by t as part of its trace.
+	child := StartSpan("invoice.render", ChildOf(span.Context()))
+	child.Finish()
+}
@@ -0,0 +1,100 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// panicError is the error finishing the spans of functions which panicked.
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string { return fmt.Sprintf("panic: %v", e.value) }
+
+// Unwrap returns the panic value if it is an error.
+func (e *panicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
+// Trace calls fn within a new span with the given operation name, child of the
+// span found in ctx, if any. fn is passed a copy of ctx holding the new span.
+// The span is finished with the error returned by fn, which is also returned.
+// If fn panics, the span is finished with an error holding the panic value and
+// its stack trace, before panicking again with the same value.
+func Trace(ctx context.Context, operationName string, fn func(ctx context.Context) error, opts ...StartSpanOption) error {
+	span, ctx := StartSpanFromContext(ctx, operationName, opts...)
+	return runSpan(ctx, span, fn)
+}
+
+// runSpan calls fn with ctx, which holds span, and finishes span as documented
+// by Trace.
+func runSpan(ctx context.Context, span Span, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			span.FinishWithOptionsExt(WithError(&panicError{r}))
+			panic(r)
+		}
+		span.FinishWithOptionsExt(WithError(err))
+	}()
+	return fn(ctx)
+}
+
+// Go calls fn on a new goroutine within a new span, as Trace does. Since the
+// span may outlive the span found in ctx, if any, it follows from that span
+// rather than being its child. The error returned by fn is only recorded on the
+// span.
+func Go(ctx context.Context, operationName string, fn func(ctx context.Context) error, opts ...StartSpanOption) {
+	if s, ok := SpanFromContext(ctx); ok {
+		opts = append(opts[:len(opts):len(opts)], FollowsFrom(s.Context()))
+	}
+	span := StartSpan(operationName, opts...)
+	go runSpan(ContextWithSpan(ctx, span), span, fn)
+}
+
+// Group runs functions on separate goroutines, each within its own span, and
+// collects the first error they return, like golang.org/x/sync/errgroup. The
+// spans are children of the span found in the context the group was created
+// with. The zero value is not usable: use NewGroup.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a new Group and a context derived from ctx, which is passed
+// to the functions of the group and canceled when one of them first returns an
+// error or when Wait returns.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go calls fn on a new goroutine within a new span, as Trace does. The first
+// error returned by a function of the group cancels the context of the group
+// and is returned by Wait.
+func (g *Group) Go(operationName string, fn func(ctx context.Context) error, opts ...StartSpanOption) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := Trace(g.ctx, operationName, fn, opts...); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait waits for all the functions of the group to return, and returns the
+// first error they returned, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -0,0 +1,139 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// spanOf returns the span held by ctx.
+func spanOf(ctx context.Context) *span {
+	s, _ := SpanFromContext(ctx)
+	return s.(*span)
+}
+
+func TestTrace(t *testing.T) {
+	_, _, stop := startTestTracer()
+	defer stop()
+
+	parent, ctx := StartSpanFromContext(context.Background(), "parent")
+	defer parent.Finish()
+
+	t.Run("success", func(t *testing.T) {
+		var s *span
+		err := Trace(ctx, "work", func(ctx context.Context) error {
+			s = spanOf(ctx)
+			return nil
+		}, ResourceName("resource"))
+		assert.NoError(t, err)
+		assert.Equal(t, "work", s.Name)
+		assert.Equal(t, "resource", s.Resource)
+		assert.Equal(t, parent.(*span).SpanID, s.ParentID)
+		assert.NotZero(t, s.Duration)
+		assert.Equal(t, int32(0), s.Error)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		want := errors.New("failed")
+		var s *span
+		err := Trace(ctx, "work", func(ctx context.Context) error {
+			s = spanOf(ctx)
+			return want
+		})
+		assert.Equal(t, want, err)
+		assert.Equal(t, int32(1), s.Error)
+		assert.Equal(t, "failed", s.Meta[ext.ErrorMsg])
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var s *span
+		assert.PanicsWithValue(t, "boom", func() {
+			Trace(ctx, "work", func(ctx context.Context) error {
+				s = spanOf(ctx)
+				panic("boom")
+			})
+		})
+		assert.NotZero(t, s.Duration)
+		assert.Equal(t, int32(1), s.Error)
+		assert.Equal(t, "panic: boom", s.Meta[ext.ErrorMsg])
+		assert.Contains(t, s.Meta[ext.ErrorStack], "panic")
+		assert.Contains(t, s.Meta[ext.ErrorStack], "TestTrace")
+	})
+
+	t.Run("panic-error", func(t *testing.T) {
+		var s *span
+		assert.Panics(t, func() {
+			Trace(ctx, "work", func(ctx context.Context) error {
+				s = spanOf(ctx)
+				panic(errors.New("boom"))
+			})
+		})
+		assert.Equal(t, "*errors.errorString", s.Meta[ext.ErrorCauses])
+	})
+}
+
+func TestGo(t *testing.T) {
+	_, _, stop := startTestTracer()
+	defer stop()
+
+	parent, ctx := StartSpanFromContext(context.Background(), "parent")
+	spans := make(chan *span)
+	Go(ctx, "async", func(ctx context.Context) error {
+		spans <- spanOf(ctx)
+		return errors.New("failed")
+	})
+	s := <-spans
+	parent.Finish()
+	assert.Equal(t, "async", s.Name)
+	assert.Equal(t, parent.(*span).SpanID, s.ParentID)
+	assert.Equal(t, parent.(*span).TraceID, s.TraceID)
+	assert.Equal(t, "true", s.Meta[ext.FollowsFrom])
+}
+
+func TestGroup(t *testing.T) {
+	_, _, stop := startTestTracer()
+	defer stop()
+
+	parent, ctx := StartSpanFromContext(context.Background(), "parent")
+	defer parent.Finish()
+
+	t.Run("success", func(t *testing.T) {
+		g, gctx := NewGroup(ctx)
+		var mu sync.Mutex
+		var spans []*span
+		for i := 0; i < 3; i++ {
+			g.Go("worker", func(ctx context.Context) error {
+				mu.Lock()
+				spans = append(spans, spanOf(ctx))
+				mu.Unlock()
+				return nil
+			})
+		}
+		require.NoError(t, g.Wait())
+		assert.Error(t, gctx.Err())
+		require.Len(t, spans, 3)
+		for _, s := range spans {
+			assert.Equal(t, "worker", s.Name)
+			assert.Equal(t, parent.(*span).SpanID, s.ParentID)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		want := errors.New("failed")
+		g, _ := NewGroup(ctx)
+		g.Go("failing", func(ctx context.Context) error {
+			return want
+		})
+		g.Go("canceled", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert.Equal(t, want, g.Wait())
+	})
+}
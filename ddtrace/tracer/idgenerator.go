@@ -0,0 +1,67 @@
+package tracer
+
+import (
+	"time"
+)
+
+// IDGenerator generates the IDs of new traces and spans. Implementations must
+// be safe for concurrent use.
+type IDGenerator interface {
+	// NewTraceID returns the ID of a new trace, as its high and low 64 bits.
+	// High is 0 for 64-bit trace IDs and low must not be 0. The low bits are
+	// also used as the span ID of the root span of the trace.
+	NewTraceID() (high, low uint64)
+
+	// NewSpanID returns the ID of a new child span. It must not be 0.
+	NewSpanID() uint64
+}
+
+// NewRandomIDGenerator returns the default IDGenerator, which generates random
+// 64-bit trace and span IDs.
+func NewRandomIDGenerator() IDGenerator {
+	return randomIDGenerator{}
+}
+
+// NewRandom128IDGenerator returns an IDGenerator which generates random
+// 128-bit trace IDs and 64-bit span IDs.
+func NewRandom128IDGenerator() IDGenerator {
+	return random128IDGenerator{}
+}
+
+// NewXRayIDGenerator returns an IDGenerator which generates 128-bit trace IDs
+// compatible with AWS X-Ray: the first 32 bits hold the start time of the
+// trace in seconds since the epoch and the remaining 96 bits are random.
+func NewXRayIDGenerator() IDGenerator {
+	return xrayIDGenerator{now: time.Now}
+}
+
+type randomIDGenerator struct{}
+
+func (randomIDGenerator) NewTraceID() (high, low uint64) { return 0, randomID() }
+
+func (randomIDGenerator) NewSpanID() uint64 { return randomID() }
+
+type random128IDGenerator struct{}
+
+func (random128IDGenerator) NewTraceID() (high, low uint64) { return randomID(), randomID() }
+
+func (random128IDGenerator) NewSpanID() uint64 { return randomID() }
+
+type xrayIDGenerator struct {
+	now func() time.Time
+}
+
+func (g xrayIDGenerator) NewTraceID() (high, low uint64) {
+	return uint64(g.now().Unix())<<32 | uint64(random.Uint32()), randomID()
+}
+
+func (xrayIDGenerator) NewSpanID() uint64 { return randomID() }
+
+// randomID returns a random, non-zero ID.
+func randomID() uint64 {
+	for {
+		if id := random.Uint64(); id != 0 {
+			return id
+		}
+	}
+}
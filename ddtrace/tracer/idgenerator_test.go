@@ -0,0 +1,153 @@
+package tracer
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequentialIDGenerator generates deterministic IDs for tests.
+type sequentialIDGenerator struct {
+	high uint64
+	next uint64
+}
+
+func (g *sequentialIDGenerator) NewTraceID() (high, low uint64) {
+	return g.high, atomic.AddUint64(&g.next, 1)
+}
+
+func (g *sequentialIDGenerator) NewSpanID() uint64 {
+	return atomic.AddUint64(&g.next, 1)
+}
+
+func TestIDGenerators(t *testing.T) {
+	t.Run("random", func(t *testing.T) {
+		g := NewRandomIDGenerator()
+		high, low := g.NewTraceID()
+		assert.Zero(t, high)
+		assert.NotZero(t, low)
+		assert.NotZero(t, g.NewSpanID())
+	})
+
+	t.Run("random128", func(t *testing.T) {
+		g := NewRandom128IDGenerator()
+		high, low := g.NewTraceID()
+		assert.NotZero(t, high)
+		assert.NotZero(t, low)
+		high2, _ := g.NewTraceID()
+		assert.NotEqual(t, high, high2)
+	})
+
+	t.Run("xray", func(t *testing.T) {
+		now := time.Unix(0x5f000000, 0)
+		g := xrayIDGenerator{now: func() time.Time { return now }}
+		high, low := g.NewTraceID()
+		assert.Equal(t, uint64(0x5f000000), high>>32)
+		assert.NotZero(t, low)
+
+		high, _ = NewXRayIDGenerator().NewTraceID()
+		assert.InDelta(t, time.Now().Unix(), int64(high>>32), 2)
+	})
+}
+
+func TestWithIDGenerator(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer(WithIDGenerator(&sequentialIDGenerator{}))
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root").(*span)
+	child := tracer.StartSpan("child", ChildOf(root.Context())).(*span)
+	custom := tracer.StartSpan("custom", WithSpanID(42)).(*span)
+
+	assert.Equal(uint64(1), root.TraceID)
+	assert.Equal(uint64(1), root.SpanID)
+	assert.Equal(uint64(1), child.TraceID)
+	assert.Equal(uint64(2), child.SpanID)
+	assert.Equal(uint64(1), child.ParentID)
+	assert.Equal(uint64(42), custom.TraceID)
+	assert.Equal(uint64(42), custom.SpanID)
+
+	t.Run("nil", func(t *testing.T) {
+		tracer := newTracer(WithIDGenerator(nil))
+		defer tracer.Stop()
+
+		assert.NotNil(tracer.config.idGenerator)
+		assert.NotZero(tracer.StartSpan("op").(*span).TraceID)
+	})
+}
+
+func TestWithTraceID(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer(WithIDGenerator(NewRandom128IDGenerator()))
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root", WithTraceID(0, 7), WithSpanID(42)).(*span)
+	assert.Equal(uint64(7), root.TraceID)
+	assert.Equal(uint64(0), root.traceIDHigh)
+	assert.Equal(uint64(42), root.SpanID)
+	assert.Equal("0000000000000007", TraceIDHex(root.Context()))
+
+	child := tracer.StartSpan("child", ChildOf(root.Context()), WithTraceID(1, 2)).(*span)
+	assert.Equal(uint64(7), child.TraceID)
+	assert.Equal(uint64(0), child.traceIDHigh)
+}
+
+func Test128BitTraceID(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tracer := newTracer(WithIDGenerator(&sequentialIDGenerator{high: 0xabc}))
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root").(*span)
+	child := tracer.StartSpan("child", ChildOf(root.Context())).(*span)
+	assert.Equal(uint64(0xabc), child.traceIDHigh)
+	assert.Equal("0000000000000abc0000000000000001", TraceIDHex(child.Context()))
+	assert.Equal(uint64(0xabc), TraceIDHigh(child.Context()))
+	assert.Equal(uint64(1), TraceID(child.Context()))
+
+	tp, ok := FormatAsTraceParent(child.Context())
+	require.True(ok)
+	assert.Equal(`traceparent;desc="00-0000000000000abc0000000000000001-0000000000000002-01"`, tp)
+
+	p := newZipkinPayload("svc")
+	assert.Equal("0000000000000abc0000000000000001", p.convertSpans(spanList{child})[0].TraceID)
+
+	carrier := TextMapCarrier{}
+	require.NoError(NewPropagator(nil).Inject(child.Context(), carrier))
+	assert.Equal("0000000000000abc0000000000000001", carrier[b3TraceIDHeader])
+
+	sctx, err := NewPropagator(nil).Extract(carrier)
+	require.NoError(err)
+	remote := tracer.StartSpan("remote", ChildOf(sctx)).(*span)
+	assert.Equal(uint64(0xabc), remote.traceIDHigh)
+	assert.Equal(uint64(1), remote.TraceID)
+	assert.Equal(uint64(2), remote.ParentID)
+
+	// 64-bit trace IDs are still propagated as 16 hex digits
+	carrier = TextMapCarrier{}
+	plain := newTracer()
+	defer plain.Stop()
+	require.NoError(NewPropagator(nil).Inject(plain.StartSpan("root").Context(), carrier))
+	assert.Len(carrier[b3TraceIDHeader], 16)
+}
+
+func TestParseTraceIDHex(t *testing.T) {
+	for in, want := range map[string][2]uint64{
+		"1":                                {0, 1},
+		"00000000000000ff":                 {0, 0xff},
+		"10000000000000000":                {1, 0},
+		"0000000000000abc0000000000000001": {0xabc, 1},
+	} {
+		high, low, err := parseTraceIDHex(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, [2]uint64{high, low}, in)
+	}
+	_, _, err := parseTraceIDHex("zz0000000000000001")
+	assert.Error(t, err)
+}
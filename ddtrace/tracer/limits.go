@@ -0,0 +1,180 @@
+package tracer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// SpanLimits bounds the amount of data recorded on spans, traces and baggage,
+// protecting the application from unbounded memory growth caused by faulty
+// instrumentation. Zero or negative values mean no limit. The number of items
+// dropped because of a limit is recorded on the span in a tag.
+type SpanLimits struct {
+	// MaxTags is the maximum number of tags of a span. Once it is reached,
+	// new tags are dropped but existing ones can still be updated. Tags set
+	// by the tracer, starting with "_" or "sfx.", are never dropped.
+	MaxTags int
+
+	// MaxLogs is the maximum number of log events of a span, including the
+	// error events of RecordError. Further events are dropped.
+	MaxLogs int
+
+	// MaxLogFields is the maximum number of fields of a log event. Further
+	// fields are dropped from the event.
+	MaxLogFields int
+
+	// MaxSpansPerTrace is the maximum number of spans of a trace buffered in
+	// memory until the trace finishes. Further spans are dropped individually,
+	// the rest of the trace being sent as usual.
+	MaxSpansPerTrace int
+
+	// MaxBaggageItems is the maximum number of baggage items of a span,
+	// including the ones extracted from a carrier. Further items are dropped.
+	MaxBaggageItems int
+
+	// MaxBaggageBytes is the maximum total size of the keys and values of the
+	// baggage items of a span. Items which would exceed it are dropped.
+	MaxBaggageBytes int
+}
+
+// WithSpanLimits sets the limits applied to the spans of the tracer. No limits
+// are applied by default, apart from a hard limit of 100,000 spans per trace,
+// above which whole traces are dropped.
+func WithSpanLimits(l SpanLimits) StartOption {
+	return func(c *config) {
+		c.limits = &l
+	}
+}
+
+const (
+	// keyDroppedTags holds the number of tags dropped from a span.
+	keyDroppedTags = "sfx.dropped.tags"
+	// keyDroppedLogs holds the number of log events dropped from a span.
+	keyDroppedLogs = "sfx.dropped.logs"
+	// keyDroppedLogFields holds the number of fields dropped from the log
+	// events of a span.
+	keyDroppedLogFields = "sfx.dropped.log_fields"
+	// keyDroppedBaggage holds the number of baggage items dropped from the
+	// context of a span.
+	keyDroppedBaggage = "sfx.dropped.baggage"
+	// keyDroppedSpans holds, on the first span of a trace chunk, the number of
+	// spans dropped from the trace.
+	keyDroppedSpans = "sfx.dropped.spans"
+)
+
+// spanDropCounts counts the items dropped from a span because of its limits.
+type spanDropCounts struct {
+	tags      int
+	logs      int
+	logFields int
+}
+
+// allowTag reports whether a tag with the given key can be set, counting it as
+// dropped otherwise. This method is not safe for concurrent use.
+func (s *span) allowTag(key string) bool {
+	if s.limits == nil || s.limits.MaxTags <= 0 {
+		return true
+	}
+	if strings.HasPrefix(key, "_") || strings.HasPrefix(key, "sfx.") || key == ext.Error {
+		return true
+	}
+	if _, ok := s.Meta[key]; ok {
+		return true
+	}
+	if _, ok := s.Metrics[key]; ok {
+		return true
+	}
+	if len(s.Meta)+len(s.Metrics) < s.limits.MaxTags {
+		return true
+	}
+	s.dropped.tags++
+	return false
+}
+
+// allowLog reports whether a log event can be appended, counting it as dropped
+// otherwise. This method is not safe for concurrent use.
+func (s *span) allowLog() bool {
+	if s.limits == nil || s.limits.MaxLogs <= 0 || len(s.Logs) < s.limits.MaxLogs {
+		return true
+	}
+	s.dropped.logs++
+	return false
+}
+
+// maxLogFields returns the number of fields kept out of n in a log event,
+// counting the others as dropped. This method is not safe for concurrent use.
+func (s *span) maxLogFields(n int) int {
+	if s.limits == nil || s.limits.MaxLogFields <= 0 || n <= s.limits.MaxLogFields {
+		return n
+	}
+	s.dropped.logFields += n - s.limits.MaxLogFields
+	return s.limits.MaxLogFields
+}
+
+// setDroppedTags records the counts of dropped items on the span. This method
+// is not safe for concurrent use.
+func (s *span) setDroppedTags() {
+	for key, n := range map[string]int{
+		keyDroppedTags:      s.dropped.tags,
+		keyDroppedLogs:      s.dropped.logs,
+		keyDroppedLogFields: s.dropped.logFields,
+		keyDroppedBaggage:   s.context.droppedBaggageItems(),
+	} {
+		if n > 0 {
+			s.Meta[key] = strconv.Itoa(n)
+		}
+	}
+}
+
+// allowBaggageItem reports whether the baggage item can be set, counting it as
+// dropped otherwise. c.mu must be held.
+func (c *spanContext) allowBaggageItem(key, val string) bool {
+	if c.limits == nil {
+		return true
+	}
+	old, exists := c.baggage[key]
+	if !exists && c.limits.MaxBaggageItems > 0 && len(c.baggage) >= c.limits.MaxBaggageItems {
+		c.droppedBaggage++
+		return false
+	}
+	size := c.baggageSize + len(key) + len(val)
+	if exists {
+		size -= len(key) + len(old)
+	}
+	if c.limits.MaxBaggageBytes > 0 && size > c.limits.MaxBaggageBytes {
+		c.droppedBaggage++
+		return false
+	}
+	return true
+}
+
+// applyLimits applies the baggage limits l to a context extracted from a
+// carrier, dropping the items exceeding them in key order.
+func (c *spanContext) applyLimits(l *SpanLimits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits = l
+	if l == nil || len(c.baggage) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(c.baggage))
+	for k := range c.baggage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	baggage := c.baggage
+	c.baggage, c.baggageSize = make(map[string]string, len(baggage)), 0
+	for _, k := range keys {
+		c.setBaggageItemLocked(k, baggage[k])
+	}
+}
+
+// droppedBaggageItems returns the number of baggage items dropped from c.
+func (c *spanContext) droppedBaggageItems() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.droppedBaggage
+}
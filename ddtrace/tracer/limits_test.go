@@ -0,0 +1,190 @@
+package tracer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+func TestSpanLimitsTags(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer(WithSpanLimits(SpanLimits{MaxTags: 6}), WithoutLibraryTags())
+	defer tracer.Stop()
+
+	span := tracer.StartSpan("op").(*span)
+	// the pid and the two sampling priority metrics are already set
+	span.SetTag("a", "1")
+	span.SetTag("b", 2)
+	span.SetTag("c", true)
+	span.SetTag("d", "dropped")
+	span.SetTag("e", 5)
+	span.SetTag("a", "updated")
+	span.SetTag(ext.Error, errors.New("failed"))
+	span.Finish()
+
+	assert.Equal("updated", span.Meta["a"])
+	assert.Equal(float64(2), span.Metrics["b"])
+	assert.NotContains(span.Meta, "d")
+	assert.NotContains(span.Metrics, "e")
+	assert.Equal("failed", span.Meta[ext.ErrorMsg])
+	assert.Equal("2", span.Meta[keyDroppedTags])
+}
+
+func TestSpanLimitsLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer(WithSpanLimits(SpanLimits{MaxLogs: 2, MaxLogFields: 2}))
+	defer tracer.Stop()
+
+	span := tracer.StartSpan("op").(*span)
+	span.LogFields(log.String("a", "1"), log.String("b", "2"), log.String("c", "3"))
+	span.LogKV("d", 4)
+	span.LogFields(log.String("e", "dropped"))
+	span.RecordError(errors.New("dropped"))
+	span.Finish()
+	span.LogKV("f", "finished")
+
+	require.Len(t, span.Logs, 2)
+	assert.Equal(map[string]interface{}{"a": "1", "b": "2"}, span.Logs[0].fields)
+	assert.Equal(map[string]interface{}{"d": 4}, span.Logs[1].fields)
+	assert.Equal("2", span.Meta[keyDroppedLogs])
+	assert.Equal("1", span.Meta[keyDroppedLogFields])
+}
+
+func TestSpanLimitsNone(t *testing.T) {
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	span := tracer.StartSpan("op").(*span)
+	for i := 0; i < 100; i++ {
+		span.SetTag(string(rune('a'+i%26))+string(rune('a'+i/26)), i)
+		span.LogKV("i", i)
+	}
+	span.Finish()
+	assert.Len(t, span.Logs, 100)
+	assert.NotContains(t, span.Meta, keyDroppedTags)
+	assert.NotContains(t, span.Meta, keyDroppedLogs)
+}
+
+func TestSpanLimitsTrace(t *testing.T) {
+	assert := assert.New(t)
+	tracer, transport, stop := startTestTracer(WithSpanLimits(SpanLimits{MaxSpansPerTrace: 3}))
+	defer stop()
+
+	root := tracer.StartSpan("root")
+	var children []Span
+	for i := 0; i < 5; i++ {
+		children = append(children, tracer.StartSpan("child", ChildOf(root.Context())))
+	}
+	for _, child := range children {
+		child.Finish()
+	}
+	root.Finish()
+	tracer.ForceFlush()
+
+	traces := transport.Traces()
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0], 3)
+	assert.Equal("root", traces[0][0].Name)
+	assert.Equal("3", traces[0][0].Meta[keyDroppedSpans])
+	assert.NotContains(traces[0][1].Meta, keyDroppedSpans)
+}
+
+func TestSpanLimitsTracePartialFlush(t *testing.T) {
+	assert := assert.New(t)
+	tracer, transport, stop := startTestTracer(WithSpanLimits(SpanLimits{MaxSpansPerTrace: 2}))
+	defer stop()
+
+	root := tracer.StartSpan("root")
+	root.Finish()
+	// each of the following spans is flushed on its own, after the root
+	for i := 0; i < 3; i++ {
+		tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+	}
+	tracer.ForceFlush()
+
+	traces := transport.Traces()
+	require.Len(t, traces, 2)
+	assert.Equal("root", traces[0][0].Name)
+	assert.Equal("child", traces[1][0].Name)
+	assert.Equal(2, len(traces[0])+len(traces[1]))
+}
+
+func TestSpanLimitsTraceDescendants(t *testing.T) {
+	assert := assert.New(t)
+	tracer, transport, stop := startTestTracer(WithSpanLimits(SpanLimits{MaxSpansPerTrace: 2}))
+	defer stop()
+	// spans started by a tracer without limits in the same trace
+	other := newTracer(withTransport(newDummyTransport()))
+	defer other.Stop()
+
+	root := tracer.StartSpan("root")
+	child := tracer.StartSpan("child", ChildOf(root.Context()))
+	dropped := tracer.StartSpan("dropped", ChildOf(root.Context()))
+	grandchild := other.StartSpan("grandchild", ChildOf(dropped.Context()))
+	sibling := other.StartSpan("sibling", ChildOf(child.Context()))
+	for _, s := range []Span{sibling, grandchild, dropped, child, root} {
+		s.Finish()
+	}
+	tracer.ForceFlush()
+
+	traces := transport.Traces()
+	require.Len(t, traces, 1)
+	var names []string
+	for _, s := range traces[0] {
+		names = append(names, s.Name)
+	}
+	assert.Equal([]string{"root", "child", "sibling"}, names)
+	assert.Equal("2", traces[0][0].Meta[keyDroppedSpans])
+}
+
+func TestSpanLimitsBaggage(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer(WithSpanLimits(SpanLimits{MaxBaggageItems: 2, MaxBaggageBytes: 10}))
+	defer tracer.Stop()
+
+	t.Run("set", func(t *testing.T) {
+		parent := tracer.StartSpan("op").(*span)
+		parent.SetBaggageItem("a", "1")
+		parent.SetBaggageItem("b", "too long")
+		parent.SetBaggageItem("c", "2")
+		parent.SetBaggageItem("d", "3")
+		parent.SetBaggageItem("a", "123")
+		assert.Equal("123", parent.BaggageItem("a"))
+		assert.Empty(parent.BaggageItem("b"))
+		assert.Equal("2", parent.BaggageItem("c"))
+		assert.Empty(parent.BaggageItem("d"))
+
+		child := tracer.StartSpan("child", ChildOf(parent.Context())).(*span)
+		assert.Equal("123", child.BaggageItem("a"))
+		parent.Finish()
+		child.Finish()
+		assert.Equal("2", parent.Meta[keyDroppedBaggage])
+		assert.NotContains(child.Meta, keyDroppedBaggage)
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		carrier := TextMapCarrier{
+			b3TraceIDHeader:                  "1",
+			b3SpanIDHeader:                   "2",
+			DefaultBaggageHeaderPrefix + "a": "1",
+			DefaultBaggageHeaderPrefix + "b": "2",
+			DefaultBaggageHeaderPrefix + "c": "3",
+		}
+		sctx, err := tracer.Extract(carrier)
+		require.NoError(t, err)
+		s := tracer.StartSpan("op", ChildOf(sctx)).(*span)
+		assert.Equal("1", s.BaggageItem("a"))
+		assert.Equal("2", s.BaggageItem("b"))
+		assert.Empty(s.BaggageItem("c"))
+		s.Finish()
+		assert.Equal("1", s.Meta[keyDroppedBaggage])
+	})
+}
@@ -0,0 +1,148 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultMetricsURL is the default SignalFx ingest URL datapoints are sent to.
+	defaultMetricsURL = "http://localhost:9080/v2/datapoint"
+
+	// defaultRuntimeMetricsInterval is the default interval at which runtime
+	// metrics are sampled and reported.
+	defaultRuntimeMetricsInterval = 10 * time.Second
+)
+
+// datapoint holds a single SignalFx datapoint in the JSON format expected by
+// the /v2/datapoint ingest API.
+type datapoint struct {
+	Metric     string            `json:"metric"`
+	Value      float64           `json:"value"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	Timestamp  int64             `json:"timestamp,omitempty"` // in milliseconds since epoch
+}
+
+// datapointPayload groups datapoints by their SignalFx metric type.
+type datapointPayload struct {
+	Gauge             []*datapoint `json:"gauge,omitempty"`
+	Counter           []*datapoint `json:"counter,omitempty"`
+	CumulativeCounter []*datapoint `json:"cumulative_counter,omitempty"`
+}
+
+// len returns the total number of datapoints in the payload.
+func (p *datapointPayload) len() int {
+	return len(p.Gauge) + len(p.Counter) + len(p.CumulativeCounter)
+}
+
+// gauge adds a gauge datapoint to the payload.
+func (p *datapointPayload) gauge(metric string, value float64, dims map[string]string, ts int64) {
+	p.Gauge = append(p.Gauge, &datapoint{Metric: metric, Value: value, Dimensions: dims, Timestamp: ts})
+}
+
+// counter adds a delta counter datapoint to the payload.
+func (p *datapointPayload) counter(metric string, value float64, dims map[string]string, ts int64) {
+	p.Counter = append(p.Counter, &datapoint{Metric: metric, Value: value, Dimensions: dims, Timestamp: ts})
+}
+
+// cumulative adds a cumulative counter datapoint to the payload.
+func (p *datapointPayload) cumulative(metric string, value float64, dims map[string]string, ts int64) {
+	p.CumulativeCounter = append(p.CumulativeCounter, &datapoint{Metric: metric, Value: value, Dimensions: dims, Timestamp: ts})
+}
+
+// metricsTransport is an interface for datapoint submission.
+type metricsTransport interface {
+	// send sends the datapoints in p to the ingest endpoint.
+	send(p *datapointPayload) error
+}
+
+// sfxMetricsTransport sends datapoints to a SignalFx ingest endpoint over HTTP.
+type sfxMetricsTransport struct {
+	url     string            // the delivery URL for datapoints
+	client  *http.Client      // the HTTP client used in the POST
+	headers map[string]string // the Transport headers
+}
+
+// newMetricsTransport returns an sfxMetricsTransport for the given endpoint.
+func newMetricsTransport(url, accessToken string, roundTripper http.RoundTripper) *sfxMetricsTransport {
+	if roundTripper == nil {
+		roundTripper = defaultRoundTripper
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if accessToken != "" {
+		headers["X-SF-Token"] = accessToken
+	}
+	return &sfxMetricsTransport{
+		url: url,
+		client: &http.Client{
+			Transport: roundTripper,
+			Timeout:   defaultHTTPTimeout,
+		},
+		headers: headers,
+	}
+}
+
+func (t *sfxMetricsTransport) send(p *datapointPayload) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("cannot encode datapoints: %v", err)
+	}
+	req, err := http.NewRequest("POST", t.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot create http request: %v", err)
+	}
+	for header, value := range t.headers {
+		req.Header.Set(header, value)
+	}
+	response, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request to %s failed: %s", t.url, err)
+	}
+	defer response.Body.Close()
+	if code := response.StatusCode; code >= 400 {
+		msg, err := ioutil.ReadAll(response.Body)
+		txt := http.StatusText(code)
+		if err == nil {
+			return fmt.Errorf("%s (Status: %s, URL: %s)", msg, txt, t.url)
+		}
+		return fmt.Errorf("error reading response body: %s (Status: %s, URL: %s)", err, txt, t.url)
+	}
+	_, _ = ioutil.ReadAll(response.Body)
+	return nil
+}
+
+// metricDimensions returns the dimensions attached to every datapoint reported
+// by the tracer: the service name and the global tags, converted to strings.
+func (c *config) metricDimensions() map[string]string {
+	dims := make(map[string]string, len(c.globalTags)+1)
+	for k, v := range c.globalTags {
+		if k = sanitizeDimensionKey(k); k == "" {
+			continue
+		}
+		dims[k] = fmt.Sprint(v)
+	}
+	dims["service"] = c.serviceName
+	return dims
+}
+
+// sanitizeDimensionKey converts k into a valid SignalFx dimension key. Keys may
+// only contain letters, digits, dashes and underscores and must not start with
+// an underscore.
+func sanitizeDimensionKey(k string) string {
+	k = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, k)
+	return strings.TrimLeft(k, "_")
+}
@@ -0,0 +1,64 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsTransport(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		assert := assert.New(t)
+		var (
+			got    datapointPayload
+			header http.Header
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			assert.NoError(json.NewDecoder(r.Body).Decode(&got))
+		}))
+		defer srv.Close()
+
+		var p datapointPayload
+		p.gauge("a", 1, map[string]string{"service": "svc"}, 10)
+		p.counter("b", 2, nil, 10)
+		p.cumulative("c", 3, nil, 10)
+		assert.Equal(3, p.len())
+
+		tr := newMetricsTransport(srv.URL, "token", nil)
+		assert.NoError(tr.send(&p))
+		assert.Equal("token", header.Get("X-SF-Token"))
+		assert.Equal("application/json", header.Get("Content-Type"))
+		assert.Equal(p, got)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		tr := newMetricsTransport(srv.URL, "", nil)
+		assert.Error(t, tr.send(&datapointPayload{}))
+	})
+}
+
+func TestMetricDimensions(t *testing.T) {
+	c := &config{
+		serviceName: "svc",
+		globalTags: map[string]interface{}{
+			"env":         "prod",
+			"_dd.origin":  "x",
+			"cluster.num": 3,
+		},
+	}
+	assert.Equal(t, map[string]string{
+		"service":     "svc",
+		"env":         "prod",
+		"dd_origin":   "x",
+		"cluster_num": "3",
+	}, c.metricDimensions())
+}
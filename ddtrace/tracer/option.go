@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
@@ -51,6 +52,55 @@ type config struct {
 	disableLibraryTags bool
 
 	recordedValueMaxLength *int
+
+	// limits bounds the data recorded on spans, shared with the spans.
+	limits *SpanLimits
+
+	// zipkinAppServiceName, when true, makes the Zipkin exporter report the
+	// service name of the application as the local service of all spans.
+	zipkinAppServiceName bool
+
+	// zipkinMetricTags, when set, selects the span metrics exported as tags
+	// by the Zipkin exporter.
+	zipkinMetricTags *metricTagFilter
+
+	// dropRejected, when true, drops the traces whose sampling priority
+	// rejects them before they are encoded.
+	dropRejected bool
+
+	// idGenerator generates the IDs of new traces and spans.
+	idGenerator IDGenerator
+
+	// redactions holds the rules applied to string tag values, by tag key.
+	redactions map[string][]*redactionRule
+
+	// runtimeMetrics, when true, periodically reports Go runtime metrics.
+	runtimeMetrics bool
+
+	// runtimeMetricsInterval specifies how often runtime metrics are reported.
+	runtimeMetricsInterval time.Duration
+
+	// metricsURL specifies the SignalFx ingest URL that datapoints are sent to.
+	metricsURL string
+
+	// metricsAccessToken specifies the access token used when sending datapoints.
+	metricsAccessToken string
+
+	// metricsTransport specifies the transport used to send datapoints.
+	metricsTransport metricsTransport
+
+	// spanMetrics, when true, aggregates request rate, error and duration
+	// metrics from all finished spans, including those dropped by sampling.
+	spanMetrics bool
+
+	// spanMetricsInterval specifies how often span metrics are flushed.
+	spanMetricsInterval time.Duration
+
+	// spanMetricsMaxKeys bounds the number of distinct keys aggregated per interval.
+	spanMetricsMaxKeys int
+
+	// spanMetricsSink receives the aggregated span metrics.
+	spanMetricsSink SpanMetricsSink
 }
 
 // StartOption represents a function that can be provided as a parameter to Start.
@@ -60,8 +110,13 @@ type StartOption func(*config)
 func defaults(c *config) {
 	c.serviceName = filepath.Base(os.Args[0])
 	c.sampler = NewAllSampler()
+	c.idGenerator = NewRandomIDGenerator()
 	c.agentAddr = defaultAddress
 	c.payload = newPayload()
+	c.runtimeMetricsInterval = defaultRuntimeMetricsInterval
+	c.metricsURL = defaultMetricsURL
+	c.spanMetricsInterval = defaultSpanMetricsInterval
+	c.spanMetricsMaxKeys = defaultSpanMetricsMaxKeys
 
 	if os.Getenv("DD_TRACE_REPORT_HOSTNAME") == "true" {
 		var err error
@@ -80,6 +135,29 @@ func WithZipkin(service string, url string, accessToken string) StartOption {
 	}
 }
 
+// WithZipkinAppServiceName makes the Zipkin exporter report the service name
+// given to WithZipkin as the local service of all spans, instead of the
+// service name of every span, which integrations set to names such as "redis"
+// or "aws.s3". The service name of client and producer spans is then reported
+// as their peer.service tag, unless it is set.
+func WithZipkinAppServiceName() StartOption {
+	return func(c *config) {
+		c.zipkinAppServiceName = true
+	}
+}
+
+// WithZipkinMetricTags sets which span metrics, the numeric tags such as
+// "partition" or "offset", are exported as string tags by the Zipkin exporter.
+// By default, all metrics are exported except internal ones, whose keys start
+// with "_dd", "_sampling" or "_sample_rate". Metrics whose key starts with a
+// prefix of allow are always exported, including internal ones. Otherwise,
+// metrics whose key starts with a prefix of deny are not exported.
+func WithZipkinMetricTags(allow, deny []string) StartOption {
+	return func(c *config) {
+		c.zipkinMetricTags = &metricTagFilter{allow: allow, deny: deny}
+	}
+}
+
 // WithPrioritySampling is deprecated, and priority sampling is enabled by default.
 // When using distributed tracing, the priority sampling value is propagated in order to
 // get all the parts of a distributed trace sampled.
@@ -182,6 +260,100 @@ func WithTracerRecordedValueMaxLength(l int) StartOption {
 	}
 }
 
+// WithIDGenerator sets the generator of the IDs of new traces and spans. It
+// defaults to NewRandomIDGenerator. IDs set with WithSpanID take precedence.
+// A nil generator is ignored.
+func WithIDGenerator(g IDGenerator) StartOption {
+	return func(c *config) {
+		if g != nil {
+			c.idGenerator = g
+		}
+	}
+}
+
+// WithTagRedaction replaces all the matches of pattern found in the values of
+// the string tag key with replacement, which may reference submatches as
+// documented in regexp.Regexp.ReplaceAllString. This option may be used
+// multiple times; rules apply in order.
+func WithTagRedaction(key string, pattern *regexp.Regexp, replacement string) StartOption {
+	return func(c *config) {
+		if c.redactions == nil {
+			c.redactions = make(map[string][]*redactionRule)
+		}
+		c.redactions[key] = append(c.redactions[key], &redactionRule{pattern: pattern, replacement: replacement})
+	}
+}
+
+// WithRuntimeMetrics enables periodic reporting of Go runtime metrics, such as
+// goroutine count, heap statistics, GC pauses and scheduler latencies, as
+// SignalFx datapoints. Datapoints carry the service name and global tags as
+// dimensions.
+func WithRuntimeMetrics() StartOption {
+	return func(c *config) {
+		c.runtimeMetrics = true
+	}
+}
+
+// WithRuntimeMetricsInterval sets how often runtime metrics are sampled and
+// reported. The default is 10 seconds.
+func WithRuntimeMetricsInterval(d time.Duration) StartOption {
+	return func(c *config) {
+		if d > 0 {
+			c.runtimeMetricsInterval = d
+		}
+	}
+}
+
+// WithMetricsEndpoint sets the SignalFx ingest URL and access token used to
+// send datapoints. The default URL is http://localhost:9080/v2/datapoint.
+func WithMetricsEndpoint(url string, accessToken string) StartOption {
+	return func(c *config) {
+		c.metricsURL = url
+		c.metricsAccessToken = accessToken
+	}
+}
+
+// WithSpanMetrics enables the aggregation of request count, error count and
+// latency histogram metrics per service, operation, resource, span kind and
+// HTTP status code. All finished spans are accounted for, before sampling drops
+// any of them. By default the metrics are sent as SignalFx datapoints to the
+// metrics endpoint.
+func WithSpanMetrics() StartOption {
+	return func(c *config) {
+		c.spanMetrics = true
+	}
+}
+
+// WithSpanMetricsInterval sets how often span metrics are flushed. The default
+// is 10 seconds.
+func WithSpanMetricsInterval(d time.Duration) StartOption {
+	return func(c *config) {
+		if d > 0 {
+			c.spanMetricsInterval = d
+		}
+	}
+}
+
+// WithSpanMetricsMaxKeys bounds the number of distinct keys aggregated during
+// one interval. Spans which would exceed it are aggregated under the
+// SpanMetricsOverflow operation. The default is 1000.
+func WithSpanMetricsMaxKeys(n int) StartOption {
+	return func(c *config) {
+		if n > 0 {
+			c.spanMetricsMaxKeys = n
+		}
+	}
+}
+
+// WithSpanMetricsSink enables span metrics and sends them to s instead of the
+// metrics endpoint.
+func WithSpanMetricsSink(s SpanMetricsSink) StartOption {
+	return func(c *config) {
+		c.spanMetrics = true
+		c.spanMetricsSink = s
+	}
+}
+
 // StartSpanOption is a configuration option for StartSpan. It is aliased in order
 // to help godoc group all the functions returning it together. It is considered
 // more correct to refer to it as the type as the origin, ddtrace.StartSpanOption.
@@ -218,6 +390,15 @@ func WithSpanID(id uint64) StartSpanOption {
 	}
 }
 
+// WithTraceID sets the trace ID of the started root span, its high bits being
+// 0 for 64-bit trace IDs, instead of the ones of the IDGenerator or WithSpanID.
+// It is ignored if there is a parent span.
+func WithTraceID(high, low uint64) StartSpanOption {
+	return func(cfg *ddtrace.StartSpanConfig) {
+		cfg.TraceIDHigh, cfg.TraceID = high, low
+	}
+}
+
 // WithRecordedValueMaxLength specifies the maximum length a tag/log value
 // can have.
 // Values are completely truncated when set to 0.
@@ -234,6 +415,19 @@ func ChildOf(ctx ddtrace.SpanContext) StartSpanOption {
 	return ddtrace.WithChildOf(ctx)
 }
 
+// FollowsFrom tells StartSpan to use the given span context as the parent of
+// the created span, which follows from it rather than being its child. Such
+// spans have the ext.FollowsFrom tag.
+func FollowsFrom(ctx ddtrace.SpanContext) StartSpanOption {
+	return ddtrace.WithFollowsFrom(ctx)
+}
+
+// Link links the created span to the span of the given context, in addition to
+// its parent. Links are reported in the ext.SpanLinks tag.
+func Link(ctx ddtrace.SpanContext) StartSpanOption {
+	return ddtrace.WithLink(ctx)
+}
+
 // StartTime sets a custom time as the start time for the created span. By
 // default a span is started using the creation time.
 func StartTime(t time.Time) StartSpanOption {
@@ -271,6 +465,17 @@ func NoDebugStack() FinishOption {
 	}
 }
 
+// WithErrorStack sets the provider of the stack trace attached to a span
+// finishing with an error, for errors carrying their stack trace in a custom
+// way. By default, the stack trace returned by the StackTrace method of
+// github.com/pkg/errors errors is used, else the one of the current goroutine.
+// The provider is not called when NoDebugStack is used.
+func WithErrorStack(provider func(err error) string) FinishOption {
+	return func(cfg *ddtrace.FinishConfig) {
+		cfg.ErrorStack = provider
+	}
+}
+
 // StackFrames limits the number of stack frames included into erroneous spans to n, starting from skip.
 func StackFrames(n, skip uint) FinishOption {
 	if n == 0 {
@@ -281,3 +486,56 @@ func StackFrames(n, skip uint) FinishOption {
 		cfg.SkipStackFrames = skip
 	}
 }
+
+// StatusCode is the status of the operation of a span, set with SetStatus. It
+// is aliased from ddtrace.StatusCode.
+type StatusCode = ddtrace.StatusCode
+
+const (
+	// StatusUnset is the default status of spans.
+	StatusUnset = ddtrace.StatusUnset
+	// StatusOK marks the operation of a span as successful.
+	StatusOK = ddtrace.StatusOK
+	// StatusError marks the operation of a span as failed.
+	StatusError = ddtrace.StatusError
+)
+
+// ErrorOption is a configuration option for RecordError. It is aliased in order
+// to help godoc group all the functions returning it together. It is considered
+// more correct to refer to it as the type as the origin, ddtrace.ErrorOption.
+type ErrorOption = ddtrace.ErrorOption
+
+// ErrorTime sets the given time as the time of the error event. By default, the
+// current time is used.
+func ErrorTime(t time.Time) ErrorOption {
+	return func(cfg *ddtrace.ErrorConfig) {
+		cfg.Time = t
+	}
+}
+
+// MarkFailed also marks the span as failed when recording the error event,
+// setting the same tags as finishing it with WithError.
+func MarkFailed() ErrorOption {
+	return func(cfg *ddtrace.ErrorConfig) {
+		cfg.MarkFailed = true
+	}
+}
+
+// ErrorNoDebugStack prevents the error event from holding a stack trace.
+func ErrorNoDebugStack() ErrorOption {
+	return func(cfg *ddtrace.ErrorConfig) {
+		cfg.NoDebugStack = true
+	}
+}
+
+// ErrorStackFrames limits the number of stack frames included into the error
+// event to n, starting from skip.
+func ErrorStackFrames(n, skip uint) ErrorOption {
+	if n == 0 {
+		return ErrorNoDebugStack()
+	}
+	return func(cfg *ddtrace.ErrorConfig) {
+		cfg.StackFrames = n
+		cfg.SkipStackFrames = skip
+	}
+}
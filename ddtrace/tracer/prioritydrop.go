@@ -0,0 +1,74 @@
+package tracer
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// WithDropRejectedTraces drops the finished traces whose sampling priority is
+// PriorityAutoReject or PriorityUserReject, such as the ones marked with
+// ext.ManualDrop or rejected upstream, instead of encoding and sending them.
+// Traces with PriorityUserKeep, set with ext.ManualKeep, are always sent, even
+// when the local sampler rejected them. The dropped traces are counted, see
+// CurrentRejectedTraces, and reported with the runtime metrics when enabled.
+func WithDropRejectedTraces() StartOption {
+	return func(c *config) {
+		c.dropRejected = true
+	}
+}
+
+// RejectedTraces counts the traces, and their spans, dropped because of their
+// sampling priority since the tracer started.
+type RejectedTraces struct {
+	Traces uint64
+	Spans  uint64
+}
+
+// CurrentRejectedTraces returns the number of traces dropped by the running
+// global tracer because of their sampling priority. The second return value
+// is false if no tracer is running.
+func CurrentRejectedTraces() (RejectedTraces, bool) {
+	t, ok := ddtrace.GetGlobalTracer().(*tracer)
+	if !ok {
+		return RejectedTraces{}, false
+	}
+	return t.rejected(), true
+}
+
+// rejected returns the counts of the traces dropped because of their priority.
+func (t *tracer) rejected() RejectedTraces {
+	return RejectedTraces{
+		Traces: atomic.LoadUint64(&t.rejectedTraces),
+		Spans:  atomic.LoadUint64(&t.rejectedSpans),
+	}
+}
+
+// keepPrioritized reports whether a finished trace of n spans, kept by the
+// local sampler according to keep, is sent given its sampling priority. A
+// trace which would have been sent is counted as rejected when dropped.
+func (t *tracer) keepPrioritized(keep bool, priority *float64, n int) bool {
+	if priority == nil {
+		return keep
+	}
+	switch p := int(*priority); {
+	case p >= ext.PriorityUserKeep:
+		return true
+	case p <= ext.PriorityAutoReject && keep:
+		atomic.AddUint64(&t.rejectedTraces, 1)
+		atomic.AddUint64(&t.rejectedSpans, uint64(n))
+		return false
+	}
+	return keep
+}
+
+// sampleRejected adds the counts of rejected traces to the runtime metrics
+// payload p.
+func (t *tracer) sampleRejected(p *datapointPayload, dims map[string]string, now time.Time) {
+	ts := now.UnixNano() / int64(time.Millisecond)
+	r := t.rejected()
+	p.cumulative("sfx.tracer.rejected_traces", float64(r.Traces), dims, ts)
+	p.cumulative("sfx.tracer.rejected_spans", float64(r.Spans), dims, ts)
+}
@@ -0,0 +1,91 @@
+package tracer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+func TestDropRejectedTraces(t *testing.T) {
+	assert := assert.New(t)
+	tracer, transport, stop := startTestTracer(WithDropRejectedTraces())
+	defer stop()
+
+	kept := tracer.StartSpan("kept")
+	tracer.StartSpan("child", ChildOf(kept.Context())).Finish()
+	kept.Finish()
+
+	dropped := tracer.StartSpan("dropped")
+	child := tracer.StartSpan("child", ChildOf(dropped.Context()))
+	child.SetTag(ext.ManualDrop, true)
+	child.Finish()
+	dropped.Finish()
+
+	sctx, err := tracer.Extract(TextMapCarrier{
+		b3TraceIDHeader: "1",
+		b3SpanIDHeader:  "2",
+		b3SampledHeader: "0",
+	})
+	require.NoError(t, err)
+	tracer.StartSpan("upstream", ChildOf(sctx)).Finish()
+
+	tracer.ForceFlush()
+	traces := transport.Traces()
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0], 2)
+	assert.Equal("kept", traces[0][0].Name)
+
+	rejected, ok := CurrentRejectedTraces()
+	assert.True(ok)
+	assert.Equal(RejectedTraces{Traces: 2, Spans: 3}, rejected)
+}
+
+func TestDropRejectedTracesUserKeep(t *testing.T) {
+	tracer, transport, stop := startTestTracer(WithDropRejectedTraces(), WithSampler(NewRateSampler(0)))
+	defer stop()
+
+	tracer.StartSpan("sampled.out").Finish()
+	span := tracer.StartSpan("kept")
+	span.SetTag(ext.ManualKeep, true)
+	span.Finish()
+
+	tracer.ForceFlush()
+	traces := transport.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, "kept", traces[0][0].Name)
+	assert.Equal(t, RejectedTraces{}, tracer.rejected())
+}
+
+func TestDropRejectedTracesDisabled(t *testing.T) {
+	tracer, transport, stop := startTestTracer()
+	defer stop()
+
+	span := tracer.StartSpan("op")
+	span.SetTag(ext.ManualDrop, true)
+	span.Finish()
+
+	tracer.ForceFlush()
+	assert.Len(t, transport.Traces(), 1)
+	assert.Equal(t, RejectedTraces{}, tracer.rejected())
+}
+
+func TestSampleRejected(t *testing.T) {
+	tracer := newTracer(WithDropRejectedTraces())
+	defer tracer.Stop()
+	tracer.rejectedTraces, tracer.rejectedSpans = 2, 5
+
+	var p datapointPayload
+	tracer.sampleRejected(&p, map[string]string{"service": "test"}, time.Unix(1, 0))
+	require.Len(t, p.CumulativeCounter, 2)
+	assert.Equal(t, &datapoint{
+		Metric:     "sfx.tracer.rejected_traces",
+		Value:      2,
+		Dimensions: map[string]string{"service": "test"},
+		Timestamp:  1000,
+	}, p.CumulativeCounter[0])
+	assert.Equal(t, float64(5), p.CumulativeCounter[1].Value)
+}
@@ -0,0 +1,123 @@
+package tracer
+
+import (
+	"runtime"
+	"sort"
+	"time"
+)
+
+// runtimeMetrics samples statistics of the Go runtime and converts them into
+// SignalFx datapoints. It is not safe for concurrent use.
+type runtimeMetrics struct {
+	// dims holds the dimensions added to every datapoint.
+	dims map[string]string
+
+	// lastNumGC holds the number of completed GC cycles seen by the previous
+	// sample, used to find the pauses that happened in between.
+	lastNumGC uint32
+
+	// sched samples scheduler latencies, where the runtime supports it.
+	sched schedSampler
+}
+
+func newRuntimeMetrics(dims map[string]string) *runtimeMetrics {
+	rm := &runtimeMetrics{dims: dims}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	rm.lastNumGC = ms.NumGC
+	return rm
+}
+
+// sample reads the current runtime statistics and returns them as a payload
+// timestamped with t.
+func (rm *runtimeMetrics) sample(t time.Time) *datapointPayload {
+	var (
+		p  datapointPayload
+		ms runtime.MemStats
+		ts = t.UnixNano() / int64(time.Millisecond)
+	)
+	runtime.ReadMemStats(&ms)
+
+	p.gauge("go.goroutines", float64(runtime.NumGoroutine()), rm.dims, ts)
+	p.cumulative("go.cgo_calls", float64(runtime.NumCgoCall()), rm.dims, ts)
+
+	p.gauge("go.mem.sys", float64(ms.Sys), rm.dims, ts)
+	p.gauge("go.mem.heap_alloc", float64(ms.HeapAlloc), rm.dims, ts)
+	p.gauge("go.mem.heap_sys", float64(ms.HeapSys), rm.dims, ts)
+	p.gauge("go.mem.heap_idle", float64(ms.HeapIdle), rm.dims, ts)
+	p.gauge("go.mem.heap_inuse", float64(ms.HeapInuse), rm.dims, ts)
+	p.gauge("go.mem.heap_released", float64(ms.HeapReleased), rm.dims, ts)
+	p.gauge("go.mem.heap_objects", float64(ms.HeapObjects), rm.dims, ts)
+	p.gauge("go.mem.stack_inuse", float64(ms.StackInuse), rm.dims, ts)
+	p.cumulative("go.mem.total_alloc", float64(ms.TotalAlloc), rm.dims, ts)
+	p.cumulative("go.mem.mallocs", float64(ms.Mallocs), rm.dims, ts)
+	p.cumulative("go.mem.frees", float64(ms.Frees), rm.dims, ts)
+
+	p.cumulative("go.gc.count", float64(ms.NumGC), rm.dims, ts)
+	p.cumulative("go.gc.pause_total_ns", float64(ms.PauseTotalNs), rm.dims, ts)
+	p.gauge("go.gc.cpu_fraction", ms.GCCPUFraction, rm.dims, ts)
+	p.gauge("go.gc.next_target", float64(ms.NextGC), rm.dims, ts)
+
+	pauses := recentPauses(&ms, rm.lastNumGC)
+	rm.lastNumGC = ms.NumGC
+	p.counter("go.gc.pause.count", float64(len(pauses)), rm.dims, ts)
+	if len(pauses) > 0 {
+		p.gauge("go.gc.pause.p50", quantile(pauses, 0.5), rm.dims, ts)
+		p.gauge("go.gc.pause.p90", quantile(pauses, 0.9), rm.dims, ts)
+		p.gauge("go.gc.pause.p99", quantile(pauses, 0.99), rm.dims, ts)
+		p.gauge("go.gc.pause.max", pauses[len(pauses)-1], rm.dims, ts)
+	}
+
+	rm.sched.sample(&p, rm.dims, ts)
+	return &p
+}
+
+// recentPauses returns the sorted durations, in nanoseconds, of the GC pauses
+// which happened after the cycle numbered since. Only the last 256 pauses are
+// kept by the runtime.
+func recentPauses(ms *runtime.MemStats, since uint32) []float64 {
+	n := int(ms.NumGC - since)
+	if n > len(ms.PauseNs) {
+		n = len(ms.PauseNs)
+	}
+	pauses := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		idx := (int(ms.NumGC) - 1 - i + len(ms.PauseNs)) % len(ms.PauseNs)
+		pauses = append(pauses, float64(ms.PauseNs[idx]))
+	}
+	sort.Float64s(pauses)
+	return pauses
+}
+
+// quantile returns the q-th quantile of the sorted, non-empty list of values.
+func quantile(sorted []float64, q float64) float64 {
+	idx := int(q * float64(len(sorted)))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// reportRuntimeMetrics periodically samples the runtime and sends the results
+// to the metrics transport until the tracer is stopped.
+func (t *tracer) reportRuntimeMetrics() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.config.runtimeMetricsInterval)
+	defer ticker.Stop()
+
+	rm := newRuntimeMetrics(t.config.metricDimensions())
+	for {
+		select {
+		case now := <-ticker.C:
+			p := rm.sample(now)
+			if t.config.dropRejected {
+				t.sampleRejected(p, rm.dims, now)
+			}
+			if err := t.config.metricsTransport.send(p); err != nil {
+				t.pushError(&metricsLossError{context: err, count: p.len()})
+			}
+		case <-t.stopped:
+			return
+		}
+	}
+}
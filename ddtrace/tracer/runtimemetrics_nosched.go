@@ -0,0 +1,9 @@
+//go:build !go1.16
+// +build !go1.16
+
+package tracer
+
+// schedSampler is a no-op on runtimes which do not provide runtime/metrics.
+type schedSampler struct{}
+
+func (s *schedSampler) sample(p *datapointPayload, dims map[string]string, ts int64) {}
@@ -0,0 +1,65 @@
+//go:build go1.16
+// +build go1.16
+
+package tracer
+
+import (
+	"math"
+	"runtime/metrics"
+)
+
+// schedLatencyMetric is the runtime/metrics name of the histogram holding the
+// time goroutines spent runnable before actually running.
+const schedLatencyMetric = "/sched/latencies:seconds"
+
+// schedSampler reports scheduler latency quantiles computed from the runtime
+// histogram, over the interval since the previous sample.
+type schedSampler struct {
+	prev []uint64 // bucket counts seen by the previous sample
+}
+
+func (s *schedSampler) sample(p *datapointPayload, dims map[string]string, ts int64) {
+	samples := []metrics.Sample{{Name: schedLatencyMetric}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindFloat64Histogram {
+		// not supported by this runtime
+		return
+	}
+	h := samples[0].Value.Float64Histogram()
+	counts := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, c := range h.Counts {
+		if i < len(s.prev) {
+			counts[i] = c - s.prev[i]
+		} else {
+			counts[i] = c
+		}
+		total += counts[i]
+	}
+	s.prev = append(s.prev[:0], h.Counts...)
+	if total == 0 {
+		return
+	}
+	p.gauge("go.sched.latency.p50", histogramQuantile(h.Buckets, counts, total, 0.5)*1e9, dims, ts)
+	p.gauge("go.sched.latency.p90", histogramQuantile(h.Buckets, counts, total, 0.9)*1e9, dims, ts)
+	p.gauge("go.sched.latency.p99", histogramQuantile(h.Buckets, counts, total, 0.99)*1e9, dims, ts)
+	p.gauge("go.sched.latency.max", histogramQuantile(h.Buckets, counts, total, 1)*1e9, dims, ts)
+}
+
+// histogramQuantile returns an upper estimate of the q-th quantile, in seconds, of
+// the histogram with the given bucket boundaries and counts.
+func histogramQuantile(buckets []float64, counts []uint64, total uint64, q float64) float64 {
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, c := range counts {
+		seen += c
+		if seen < rank || c == 0 {
+			continue
+		}
+		if upper := buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		}
+		return buckets[i]
+	}
+	return 0
+}
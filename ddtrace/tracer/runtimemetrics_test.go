@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeMetricsSample(t *testing.T) {
+	assert := assert.New(t)
+	dims := map[string]string{"service": "svc"}
+	rm := newRuntimeMetrics(dims)
+	runtime.GC()
+	runtime.GC()
+
+	p := rm.sample(time.Unix(1, 0))
+	metrics := map[string]*datapoint{}
+	for _, list := range [][]*datapoint{p.Gauge, p.Counter, p.CumulativeCounter} {
+		for _, dp := range list {
+			metrics[dp.Metric] = dp
+			assert.Equal(dims, dp.Dimensions)
+			assert.Equal(int64(1000), dp.Timestamp)
+		}
+	}
+	for _, name := range []string{
+		"go.goroutines",
+		"go.cgo_calls",
+		"go.mem.heap_alloc",
+		"go.gc.count",
+		"go.gc.pause.count",
+		"go.gc.pause.p99",
+		"go.gc.pause.max",
+	} {
+		assert.Contains(metrics, name)
+	}
+	assert.True(metrics["go.goroutines"].Value > 0)
+	assert.True(metrics["go.gc.pause.count"].Value >= 2)
+
+	// pauses are only reported once
+	p = rm.sample(time.Unix(2, 0))
+	for _, dp := range p.Counter {
+		if dp.Metric == "go.gc.pause.count" {
+			assert.True(dp.Value < 2)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	assert := assert.New(t)
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(6., quantile(sorted, 0.5))
+	assert.Equal(10., quantile(sorted, 0.99))
+	assert.Equal(10., quantile(sorted, 1))
+	assert.Equal(3., quantile([]float64{3}, 0.5))
+}
+
+func TestRecentPauses(t *testing.T) {
+	var ms runtime.MemStats
+	ms.NumGC = 300
+	for i := range ms.PauseNs {
+		ms.PauseNs[i] = uint64(i)
+	}
+	// the last pause is stored at (NumGC+255)%256
+	assert.Equal(t, []float64{42, 43}, recentPauses(&ms, 298))
+	assert.Len(t, recentPauses(&ms, 0), 256)
+	assert.Empty(t, recentPauses(&ms, 300))
+}
+
+func TestTracerRuntimeMetrics(t *testing.T) {
+	assert := assert.New(t)
+	received := make(chan datapointPayload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p datapointPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err == nil {
+			received <- p
+		}
+	}))
+	defer srv.Close()
+
+	tracer, _, stop := startTestTracer(
+		WithServiceName("runtime-svc"),
+		WithGlobalTag("env", "test"),
+		WithRuntimeMetrics(),
+		WithRuntimeMetricsInterval(10*time.Millisecond),
+		WithMetricsEndpoint(srv.URL, "token"),
+	)
+	defer stop()
+	assert.NotNil(tracer.config.metricsTransport)
+
+	select {
+	case p := <-received:
+		assert.NotEmpty(p.Gauge)
+		assert.Equal(map[string]string{"service": "runtime-svc", "env": "test"}, p.Gauge[0].Dimensions)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for datapoints")
+	}
+}
+
+func TestTracerRuntimeMetricsDisabled(t *testing.T) {
+	tracer, _, stop := startTestTracer()
+	defer stop()
+	assert.Nil(t, tracer.config.metricsTransport)
+}
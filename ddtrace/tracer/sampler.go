@@ -75,6 +75,57 @@ func (r *rateSampler) Sample(spn ddtrace.Span) bool {
 	return sampledByRate(s.TraceID, r.rate)
 }
 
+// SamplingRule specifies the sampling rate applied to the root spans matching
+// a service and operation name.
+type SamplingRule struct {
+	// Service is the service name to match. It matches any service when empty.
+	Service string
+
+	// Operation is the operation name to match. It matches any operation when empty.
+	Operation string
+
+	// Rate is the sampling rate, between 0 and 1, applied to matching spans.
+	Rate float64
+}
+
+// match reports whether the rule applies to the given span.
+func (r *SamplingRule) match(s *span) bool {
+	return (r.Service == "" || r.Service == s.Service) && (r.Operation == "" || r.Operation == s.Name)
+}
+
+// ruleSampler samples spans using the rate of the first matching rule, or the
+// default rate when no rule matches.
+type ruleSampler struct {
+	rules       []SamplingRule
+	defaultRate float64
+}
+
+// NewRuleSampler returns a Sampler which samples root spans using the rate of
+// the first rule matching their service and operation name. Spans matching no
+// rule are sampled using defaultRate.
+func NewRuleSampler(defaultRate float64, rules ...SamplingRule) Sampler {
+	return &ruleSampler{rules: rules, defaultRate: defaultRate}
+}
+
+// Sample returns true if the given span should be sampled.
+func (r *ruleSampler) Sample(spn ddtrace.Span) bool {
+	s, ok := spn.(*span)
+	if !ok {
+		return false
+	}
+	return sampledByRate(s.TraceID, r.rate(s))
+}
+
+// rate returns the sampling rate applying to the given span.
+func (r *ruleSampler) rate(s *span) float64 {
+	for i := range r.rules {
+		if r.rules[i].match(s) {
+			return r.rules[i].Rate
+		}
+	}
+	return r.defaultRate
+}
+
 // sampledByRate verifies if the number n should be sampled at the specified
 // rate.
 func sampledByRate(n uint64, rate float64) bool {
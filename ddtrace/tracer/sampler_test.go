@@ -173,3 +173,24 @@ func TestRateSamplerSetting(t *testing.T) {
 	rs.SetRate(0.5)
 	assert.Equal(float64(0.5), rs.Rate())
 }
+
+func TestRuleSampler(t *testing.T) {
+	assert := assert.New(t)
+	sampler := NewRuleSampler(1,
+		SamplingRule{Service: "db", Rate: 0},
+		SamplingRule{Operation: "health", Rate: 0},
+		SamplingRule{Service: "web", Operation: "web.request", Rate: 1},
+	).(*ruleSampler)
+
+	mk := func(service, name string) *span {
+		return &span{Service: service, Name: name, TraceID: random.Uint64()}
+	}
+	assert.Equal(0., sampler.rate(mk("db", "query")))
+	assert.Equal(0., sampler.rate(mk("web", "health")))
+	assert.Equal(1., sampler.rate(mk("web", "web.request")))
+	assert.Equal(1., sampler.rate(mk("other", "op")))
+
+	assert.False(sampler.Sample(mk("db", "query")))
+	assert.True(sampler.Sample(mk("web", "web.request")))
+	assert.False(sampler.Sample(ddtrace.NoopSpan{}))
+}
@@ -0,0 +1,88 @@
+package tracer
+
+import (
+	"errors"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+)
+
+// Settings holds the tracer settings which can be changed while the tracer is
+// running, using Reconfigure. Spans started after a change use the new
+// settings; spans already started keep the ones they were created with.
+type Settings struct {
+	// Sampler decides which new traces are kept. It must not be nil.
+	Sampler Sampler
+
+	// GlobalTags holds the tags set on every new span. Runtime metric
+	// dimensions keep the global tags the tracer was started with.
+	GlobalTags map[string]interface{}
+
+	// Debug enables more verbose logging.
+	Debug bool
+
+	// RecordedValueMaxLength is the maximum length of tag and log values of
+	// new spans, or nil for no limit. See WithTracerRecordedValueMaxLength.
+	RecordedValueMaxLength *int
+}
+
+// clone returns a copy of s which shares no mutable state with it.
+func (s *Settings) clone() *Settings {
+	c := *s
+	if s.GlobalTags != nil {
+		c.GlobalTags = make(map[string]interface{}, len(s.GlobalTags))
+		for k, v := range s.GlobalTags {
+			c.GlobalTags[k] = v
+		}
+	}
+	if s.RecordedValueMaxLength != nil {
+		l := *s.RecordedValueMaxLength
+		c.RecordedValueMaxLength = &l
+	}
+	return &c
+}
+
+// errNotStarted is returned when reconfiguring while no tracer is running.
+var errNotStarted = errors.New("tracer not started")
+
+// errNilSampler is returned when a reconfiguration removes the sampler.
+var errNilSampler = errors.New("sampler must not be nil")
+
+// CurrentSettings returns the settings of the running global tracer. The
+// second return value is false if no tracer is running.
+func CurrentSettings() (Settings, bool) {
+	t, ok := ddtrace.GetGlobalTracer().(*tracer)
+	if !ok {
+		return Settings{}, false
+	}
+	return *t.settings().clone(), true
+}
+
+// Reconfigure atomically changes the settings of the running global tracer:
+// update is called with a copy of the current settings, which it modifies, and
+// the result replaces them all at once. Buffered spans are not lost. It
+// returns an error if no tracer is running or the result is invalid, in which
+// case the settings are left unchanged.
+func Reconfigure(update func(*Settings)) error {
+	t, ok := ddtrace.GetGlobalTracer().(*tracer)
+	if !ok {
+		return errNotStarted
+	}
+	return t.reconfigure(update)
+}
+
+// settings returns the current settings. The result must not be modified.
+func (t *tracer) settings() *Settings {
+	return t.dynamic.Load().(*Settings)
+}
+
+func (t *tracer) reconfigure(update func(*Settings)) error {
+	t.reconfigureMu.Lock()
+	defer t.reconfigureMu.Unlock()
+	s := t.settings().clone()
+	update(s)
+	if s.Sampler == nil {
+		return errNilSampler
+	}
+	t.dynamic.Store(s.clone())
+	return nil
+}
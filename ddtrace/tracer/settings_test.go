@@ -0,0 +1,100 @@
+package tracer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconfigure(t *testing.T) {
+	t.Run("not-started", func(t *testing.T) {
+		_, ok := CurrentSettings()
+		assert.False(t, ok)
+		assert.Equal(t, errNotStarted, Reconfigure(func(s *Settings) { s.Debug = true }))
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		tracer, _, stop := startTestTracer(WithGlobalTag("k", "v"), WithTracerRecordedValueMaxLength(5))
+		defer stop()
+
+		s, ok := CurrentSettings()
+		require.True(ok)
+		assert.Equal(map[string]interface{}{"k": "v"}, s.GlobalTags)
+		assert.Equal(5, *s.RecordedValueMaxLength)
+		assert.False(s.Debug)
+
+		before := tracer.StartSpan("before").(*span)
+		assert.Equal("v", before.Meta["k"])
+
+		require.NoError(Reconfigure(func(s *Settings) {
+			s.Sampler = NewRateSampler(0)
+			s.GlobalTags = map[string]interface{}{"k2": "v2"}
+			s.Debug = true
+			l := 2
+			s.RecordedValueMaxLength = &l
+		}))
+		s, _ = CurrentSettings()
+		assert.True(s.Debug)
+		assert.Equal(2, *s.RecordedValueMaxLength)
+
+		after := tracer.StartSpan("after").(*span)
+		after.SetTag("long", "value")
+		assert.Equal("v2", after.Meta["k2"])
+		assert.NotContains(after.Meta, "k")
+		assert.Equal("va", after.Meta["long"])
+		assert.True(after.context.drop)
+
+		// spans started before keep their settings
+		before.SetTag("long", "value")
+		assert.Equal("value", before.Meta["long"])
+		assert.False(before.context.drop)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tracer, _, stop := startTestTracer()
+		defer stop()
+
+		assert.Equal(t, errNilSampler, Reconfigure(func(s *Settings) {
+			s.Debug = true
+			s.Sampler = nil
+		}))
+		assert.False(t, tracer.settings().Debug)
+		assert.NotNil(t, tracer.settings().Sampler)
+	})
+
+	t.Run("isolation", func(t *testing.T) {
+		tracer, _, stop := startTestTracer(WithGlobalTag("k", "v"))
+		defer stop()
+
+		var kept *Settings
+		require.NoError(t, Reconfigure(func(s *Settings) { kept = s }))
+		kept.GlobalTags["k"] = "changed"
+		assert.Equal(t, "v", tracer.settings().GlobalTags["k"])
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		tracer, _, stop := startTestTracer()
+		defer stop()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, Reconfigure(func(s *Settings) {
+					s.GlobalTags = map[string]interface{}{"i": i}
+				}))
+			}(i)
+			go func() {
+				defer wg.Done()
+				tracer.StartSpan("op")
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, tracer.settings().GlobalTags, 1)
+	})
+}
@@ -5,8 +5,8 @@ package tracer
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"runtime"
-	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,16 +31,20 @@ type (
 )
 
 var (
-	_ ddtrace.Span   = (*span)(nil)
-	_ msgp.Encodable = (*spanList)(nil)
-	_ msgp.Decodable = (*spanLists)(nil)
+	_ ddtrace.Span          = (*span)(nil)
+	_ ddtrace.ErrorRecorder = (*span)(nil)
+	_ ddtrace.StatusSetter  = (*span)(nil)
+	_ ddtrace.TimedLogger   = (*span)(nil)
+	_ msgp.Encodable        = (*spanList)(nil)
+	_ msgp.Decodable        = (*spanLists)(nil)
 )
 
 // errorConfig holds customization options for setting error tags.
 type errorConfig struct {
-	noDebugStack bool
-	stackFrames  uint
-	stackSkip    uint
+	noDebugStack  bool
+	stackFrames   uint
+	stackSkip     uint
+	stackProvider func(err error) string
 }
 
 // logFields holds the results of one invocation of LogFields
@@ -69,8 +73,13 @@ type span struct {
 	Logs     []*logFields
 
 	recordedValueMaxLength *int
-	finished               bool         `msg:"-"` // true if the span has been submitted to a tracer.
-	context                *spanContext `msg:"-"` // span propagation context
+	redactions             map[string][]*redactionRule // rules applied to string tags, shared with the tracer config
+	limits                 *SpanLimits                 `msg:"-"` // limits of the span, shared with the tracer config
+	tracer                 *tracer                     `msg:"-"` // tracer which started the span and receives its trace
+	dropped                spanDropCounts              `msg:"-"` // counts of items dropped because of limits
+	finished               bool                        `msg:"-"` // true if the span has been submitted to a tracer.
+	context                *spanContext                `msg:"-"` // span propagation context
+	traceIDHigh            uint64                      `msg:"-"` // high bits of 128-bit trace IDs; 0 for 64-bit ones
 }
 
 // LogKV pairs
@@ -82,11 +91,15 @@ func (s *span) LogKV(alternatingKeyValues ...interface{}) {
 	s.LogFields(fields...)
 }
 
-// Tracer returns the global tracer
+// Tracer returns the tracer which started the span, or the global tracer for
+// spans created otherwise.
 func (s *span) Tracer() opentracing.Tracer {
-	// return ddtrace.GetGlobalTracer() wrapped to make it
-	// a compatible opentracing Tracer
-	return opentracer.New()
+	if s.tracer == nil {
+		// return ddtrace.GetGlobalTracer() wrapped to make it
+		// a compatible opentracing Tracer
+		return opentracer.New()
+	}
+	return opentracer.Wrap(s.tracer)
 }
 
 func (s *span) truncate(val string) string {
@@ -105,7 +118,34 @@ func (s *span) truncate(val string) string {
 
 // LogFields field to span
 func (s *span) LogFields(fields ...log.Field) {
-	m := map[string]interface{}{}
+	s.logFieldsAt(time.Now(), fields)
+}
+
+// LogFieldsAt logs the given fields as an event which occurred at time t, if s
+// implements ddtrace.TimedLogger, as the spans of this package do, or at the
+// current time otherwise.
+func LogFieldsAt(s ddtrace.Span, t time.Time, fields ...log.Field) {
+	if l, ok := s.(ddtrace.TimedLogger); ok {
+		l.LogFieldsAt(t, fields...)
+		return
+	}
+	s.LogFields(fields...)
+}
+
+// LogFieldsAt implements ddtrace.TimedLogger.
+func (s *span) LogFieldsAt(t time.Time, fields ...log.Field) {
+	s.logFieldsAt(t, fields)
+}
+
+// logFieldsAt logs the given fields as an event which occurred at time t.
+func (s *span) logFieldsAt(t time.Time, fields []log.Field) {
+	s.Lock()
+	defer s.Unlock()
+	if s.finished || !s.allowLog() {
+		return
+	}
+	fields = fields[:s.maxLogFields(len(fields))]
+	m := make(map[string]interface{}, len(fields))
 	for _, field := range fields {
 		val := field.Value()
 		if vStr, ok := val.(string); ok {
@@ -113,7 +153,7 @@ func (s *span) LogFields(fields ...log.Field) {
 		}
 		m[field.Key()] = val
 	}
-	s.Logs = append(s.Logs, &logFields{m, time.Now()})
+	s.Logs = append(s.Logs, &logFields{m, t})
 }
 
 // LogEvent is deprecated
@@ -197,18 +237,8 @@ func (s *span) setTagError(value interface{}, cfg *errorConfig) {
 		// and provide all the benefits.
 		s.Error = 1
 
-		s.setTagString(ext.ErrorKind, reflect.TypeOf(v).String())
-		s.setTagString(ext.ErrorObject, fmt.Sprintf("%#v", v))
-		s.setTagString(ext.ErrorMsg, v.Error())
-
-		if !cfg.noDebugStack {
-			if cfg.stackFrames == 0 {
-				// TODO: maybe support xerrors and/or https://godoc.org/github.com/pkg/errors?
-				s.setTagString(ext.ErrorStack, string(debug.Stack()))
-			} else {
-				s.setTagString(ext.ErrorStack, takeStacktrace(cfg.stackFrames, cfg.stackSkip))
-			}
-		}
+		chain := errorChain(v)
+		s.setErrorTags(v, chain, errorStack(chain, cfg))
 	case nil:
 		// no error
 		s.Error = 0
@@ -223,6 +253,61 @@ func (s *span) setTagError(value interface{}, cfg *errorConfig) {
 	}
 }
 
+// SetStatus sets the status of the operation of s if it implements
+// ddtrace.StatusSetter, as the spans of this package do, and has no effect
+// otherwise.
+func SetStatus(s ddtrace.Span, code ddtrace.StatusCode, description string) {
+	if ss, ok := s.(ddtrace.StatusSetter); ok {
+		ss.SetStatus(code, description)
+	}
+}
+
+// SetStatus sets the status of the operation of the span. StatusError marks the
+// span as failed, recording description as its error message unless it already
+// has one, while StatusOK clears its error state.
+func (s *span) SetStatus(code ddtrace.StatusCode, description string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	switch code {
+	case ddtrace.StatusError:
+		s.setTagError(true, nil)
+		s.setTagString(ext.StatusCode, code.String())
+		if description == "" {
+			break
+		}
+		s.setTagString(ext.StatusDescription, description)
+		if _, ok := s.Meta[ext.ErrorMsg]; !ok {
+			s.setTagString(ext.ErrorMsg, description)
+		}
+	case ddtrace.StatusOK:
+		s.setTagError(false, nil)
+		delete(s.Meta, ext.Error)
+		delete(s.Meta, ext.StatusDescription)
+		s.setTagString(ext.StatusCode, code.String())
+	default:
+		delete(s.Meta, ext.StatusCode)
+		delete(s.Meta, ext.StatusDescription)
+	}
+}
+
+// setErrorTags sets the tags describing err, which wraps the errors of chain
+// and was raised with the given stack trace. This method is not safe for
+// concurrent use.
+func (s *span) setErrorTags(err error, chain []error, stack string) {
+	s.setTagString(ext.ErrorKind, reflect.TypeOf(err).String())
+	s.setTagString(ext.ErrorObject, fmt.Sprintf("%#v", err))
+	s.setTagString(ext.ErrorMsg, err.Error())
+	if causes := errorCauses(chain); causes != "" {
+		s.setTagString(ext.ErrorCauses, causes)
+	}
+	if stack != "" {
+		s.setTagString(ext.ErrorStack, stack)
+	}
+}
+
 // takeStacktrace takes stacktrace
 func takeStacktrace(n, skip uint) string {
 	var builder strings.Builder
@@ -264,10 +349,27 @@ func (s *span) setTagString(key, v string) {
 	case ext.SpanType:
 		s.Type = v
 	default:
-		s.Meta[key] = s.truncate(v)
+		if !s.allowTag(key) {
+			return
+		}
+		s.Meta[key] = s.truncate(s.redact(key, v))
 	}
 }
 
+// redactionRule replaces the matches of a pattern in tag values.
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redact applies the redaction rules configured for key to v.
+func (s *span) redact(key, v string) string {
+	for _, r := range s.redactions[key] {
+		v = r.pattern.ReplaceAllString(v, r.replacement)
+	}
+	return v
+}
+
 // setTagBool sets a boolean tag on the span.
 func (s *span) setTagBool(key string, v bool) {
 	switch key {
@@ -303,6 +405,9 @@ func (s *span) setTagNumeric(key string, v float64) {
 		s.Metrics[keySamplingPriority] = v
 		s.context.setSamplingPriority(int(v))
 	default:
+		if !s.allowTag(key) {
+			return
+		}
 		s.Metrics[key] = v
 	}
 }
@@ -323,9 +428,10 @@ func (s *span) FinishWithOptionsExt(opts ...ddtrace.FinishOption) {
 	if cfg.Error != nil {
 		s.Lock()
 		s.setTagError(cfg.Error, &errorConfig{
-			noDebugStack: cfg.NoDebugStack,
-			stackFrames:  cfg.StackFrames,
-			stackSkip:    cfg.SkipStackFrames,
+			noDebugStack:  cfg.NoDebugStack,
+			stackFrames:   cfg.StackFrames,
+			stackSkip:     cfg.SkipStackFrames,
+			stackProvider: cfg.ErrorStack,
 		})
 		s.Unlock()
 	}
@@ -335,9 +441,13 @@ func (s *span) FinishWithOptionsExt(opts ...ddtrace.FinishOption) {
 // FinishWithOptions closes this span
 func (s *span) FinishWithOptions(opts opentracing.FinishOptions) {
 	for _, lr := range opts.LogRecords {
-		if len(lr.Fields) > 0 {
-			s.LogFields(lr.Fields...)
+		if len(lr.Fields) == 0 {
+			continue
+		}
+		if lr.Timestamp.IsZero() {
+			lr.Timestamp = time.Now()
 		}
+		s.logFieldsAt(lr.Timestamp, lr.Fields)
 	}
 
 	s.internalFinish(opts.FinishTime.UnixNano())
@@ -371,11 +481,10 @@ func (s *span) internalFinish(finishTime int64) {
 		s.Duration = finishTime - s.Start
 	}
 	s.finished = true
+	s.setDroppedTags()
 
-	if s.context.drop {
-		// not sampled by local sampler
-		return
-	}
+	// spans dropped by the local sampler still go through the trace, so that
+	// they can be accounted for in span metrics
 	s.context.finish()
 }
 
@@ -2,10 +2,15 @@ package tracer
 
 import (
 	"errors"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 
 	"github.com/stretchr/testify/assert"
@@ -13,7 +18,9 @@ import (
 
 // newSpan creates a new span. This is a low-level function, required for testing and advanced usage.
 // Most of the time one should prefer the Tracer NewRootSpan or NewChildSpan methods.
+// The span belongs to the global tracer, if started.
 func newSpan(name, service, resource string, spanID, traceID, parentID uint64) *span {
+	tracer, _ := ddtrace.GetGlobalTracer().(*tracer)
 	span := &span{
 		Name:     name,
 		Service:  service,
@@ -24,6 +31,7 @@ func newSpan(name, service, resource string, spanID, traceID, parentID uint64) *
 		TraceID:  traceID,
 		ParentID: parentID,
 		Start:    now(),
+		tracer:   tracer,
 	}
 	span.context = newSpanContext(span, nil)
 	return span
@@ -428,3 +436,54 @@ func BenchmarkSetTagField(b *testing.B) {
 type boomError struct{}
 
 func (e *boomError) Error() string { return "boom" }
+
+func TestSpanTagRedaction(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer(
+		withTransport(newDefaultTransport()),
+		WithTagRedaction(ext.HTTPURL, regexp.MustCompile(`token=[^&]*`), "token=?"),
+		WithTagRedaction(ext.HTTPURL, regexp.MustCompile(`^http:`), "https:"),
+	)
+	defer tracer.Stop()
+	span := tracer.StartSpan("web.request").(*span)
+	span.SetTag(ext.HTTPURL, "http://host/path?token=secret&a=b")
+	span.SetTag("other", "token=secret")
+	assert.Equal("https://host/path?token=?&a=b", span.Meta[ext.HTTPURL])
+	assert.Equal("token=secret", span.Meta["other"])
+}
+
+func TestSpanFinishWithLogRecords(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer(withTransport(newDefaultTransport()))
+	span := tracer.newRootSpan("pylons.request", "pylons", "/")
+
+	at := time.Unix(10, 0)
+	span.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: time.Unix(20, 0),
+		LogRecords: []opentracing.LogRecord{
+			{Timestamp: at, Fields: []log.Field{log.String("event", "timed")}},
+			{Fields: []log.Field{log.String("event", "now")}},
+			{Timestamp: at},
+		},
+	})
+	assert.Len(span.Logs, 2)
+	assert.Equal(at, span.Logs[0].time)
+	assert.Equal("timed", span.Logs[0].fields["event"])
+	assert.False(span.Logs[1].time.IsZero())
+}
+
+func TestLogFieldsAt(t *testing.T) {
+	assert := assert.New(t)
+
+	span := newBasicSpan("web.request")
+	at := time.Unix(5, 0)
+	LogFieldsAt(span, at, log.String("event", "cache.miss"))
+	assert.Len(span.Logs, 1)
+	assert.Equal(at, span.Logs[0].time)
+	assert.Equal("cache.miss", span.Logs[0].fields["event"])
+
+	// spans which can not log at a given time are left alone
+	assert.NotPanics(func() {
+		LogFieldsAt(struct{ ddtrace.Span }{ddtrace.NoopSpan{}}, at, log.String("event", "cache.miss"))
+	})
+}
@@ -1,6 +1,8 @@
 package tracer
 
 import (
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
@@ -19,14 +21,22 @@ type spanContext struct {
 	span  *span  // reference to the span that hosts this context
 	drop  bool   // when true, the span will not be sent to the agent
 
+	// notBuffered is true when the span was dropped because its trace
+	// reached the maximum number of spans, or because its parent was dropped.
+	notBuffered bool
+
 	// the below group should propagate cross-process
 
-	traceID uint64
-	spanID  uint64
+	traceID     uint64
+	traceIDHigh uint64 // high bits of 128-bit trace IDs; 0 for 64-bit ones
+	spanID      uint64
 
-	mu      sync.RWMutex // guards below fields
-	baggage map[string]string
-	origin  string // e.g. "synthetics"
+	mu             sync.RWMutex // guards below fields
+	baggage        map[string]string
+	baggageSize    int         // total size of the keys and values of baggage
+	droppedBaggage int         // number of baggage items dropped because of limits
+	limits         *SpanLimits // limits of the baggage, shared with the tracer config
+	origin         string      // e.g. "synthetics"
 }
 
 // newSpanContext creates a new SpanContext to serve as context for the given
@@ -36,14 +46,21 @@ type spanContext struct {
 // for the same span.
 func newSpanContext(span *span, parent *spanContext) *spanContext {
 	context := &spanContext{
-		traceID: span.TraceID,
-		spanID:  span.SpanID,
-		span:    span,
+		traceID:     span.TraceID,
+		traceIDHigh: span.traceIDHigh,
+		spanID:      span.SpanID,
+		span:        span,
+		limits:      span.limits,
 	}
 	if parent != nil {
 		context.trace = parent.trace
 		context.drop = parent.drop
 		context.origin = parent.origin
+		if parent.span == nil {
+			// remote parent, the first local span reports the baggage
+			// dropped on extraction
+			context.droppedBaggage = parent.droppedBaggageItems()
+		}
 		parent.ForeachBaggageItem(func(k, v string) bool {
 			context.setBaggageItem(k, v)
 			return true
@@ -56,11 +73,55 @@ func newSpanContext(span *span, parent *spanContext) *spanContext {
 		// first span in the trace can safely be assumed to be the root
 		context.trace.root = span
 	}
+	if parent != nil && parent.notBuffered {
+		// the descendants of dropped spans are dropped too, so that the
+		// recorded spans never refer to a parent which was not recorded
+		context.trace.dropOne()
+		context.notBuffered = true
+		return context
+	}
 	// put span in context's trace
-	context.trace.push(span)
+	context.notBuffered = !context.trace.push(span)
 	return context
 }
 
+// SpanContextConfig holds the values of a span context created with
+// NewSpanContext.
+type SpanContextConfig struct {
+	// TraceIDHigh holds the high bits of 128-bit trace IDs, and 0 otherwise.
+	TraceIDHigh uint64
+
+	// TraceID and SpanID hold the IDs of the trace and of the span.
+	TraceID uint64
+	SpanID  uint64
+
+	// SamplingPriority holds the sampling priority of the trace, if a sampling
+	// decision was made.
+	SamplingPriority *int
+
+	// Baggage holds the baggage items of the span context.
+	Baggage map[string]string
+}
+
+// NewSpanContext returns a span context with the given values, as if it was
+// extracted from a carrier. It can be injected with a Propagator or used as
+// the parent of spans of a remote span, for example by alternate tracer
+// implementations.
+func NewSpanContext(cfg SpanContextConfig) ddtrace.SpanContext {
+	ctx := &spanContext{
+		traceIDHigh: cfg.TraceIDHigh,
+		traceID:     cfg.TraceID,
+		spanID:      cfg.SpanID,
+	}
+	if cfg.SamplingPriority != nil {
+		ctx.setSamplingPriority(*cfg.SamplingPriority)
+	}
+	for k, v := range cfg.Baggage {
+		ctx.setBaggageItem(k, v)
+	}
+	return ctx
+}
+
 // SpanID implements ddtrace.SpanContext.
 func (c *spanContext) SpanID() uint64 { return c.spanID }
 
@@ -99,10 +160,23 @@ func (c *spanContext) hasSamplingPriority() bool {
 func (c *spanContext) setBaggageItem(key, val string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.setBaggageItemLocked(key, val)
+}
+
+// setBaggageItemLocked sets a baggage item within the limits of c. c.mu must
+// be held.
+func (c *spanContext) setBaggageItemLocked(key, val string) {
+	if !c.allowBaggageItem(key, val) {
+		return
+	}
 	if c.baggage == nil {
 		c.baggage = make(map[string]string, 1)
 	}
+	if old, ok := c.baggage[key]; ok {
+		c.baggageSize -= len(key) + len(old)
+	}
 	c.baggage[key] = val
+	c.baggageSize += len(key) + len(val)
 }
 
 func (c *spanContext) baggageItem(key string) string {
@@ -112,7 +186,12 @@ func (c *spanContext) baggageItem(key string) string {
 }
 
 // finish marks this span as finished in the trace.
-func (c *spanContext) finish() { c.trace.finishedOne(c.span) }
+func (c *spanContext) finish() {
+	if c.notBuffered {
+		return
+	}
+	c.trace.finishedOne(c.span)
+}
 
 // trace contains shared context information about a trace, such as sampling
 // priority, the root reference and a buffer of the spans which are part of the
@@ -122,6 +201,8 @@ type trace struct {
 	spans    []*span      // all the spans that are part of this trace
 	finished int          // the number of finished spans
 	full     bool         // signifies that the span buffer is full
+	pushed   int          // the number of spans pushed, including the flushed ones
+	dropped  int          // the number of spans dropped because of SpanLimits
 	priority *float64     // sampling priority
 	locked   bool         // specifies if the sampling priority can be altered
 
@@ -186,28 +267,43 @@ func (t *trace) setSamplingPriorityLocked(p float64) {
 	*t.priority = p
 }
 
-// push pushes a new span into the trace. If the buffer is full, it returns
-// a errBufferFull error.
-func (t *trace) push(sp *span) {
+// push pushes a new span into the trace. It returns false if the span was
+// dropped because the trace reached the maximum number of spans of its limits,
+// counting the spans of the partial flushes of the trace.
+// If the buffer is full, it submits a spanBufferFullError error.
+func (t *trace) push(sp *span) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	if t.full {
-		return
+		return true
+	}
+	if l := sp.limits; l != nil && l.MaxSpansPerTrace > 0 && t.pushed >= l.MaxSpansPerTrace {
+		t.dropped++
+		return false
 	}
 	if len(t.spans) >= traceMaxSize {
 		// capacity is reached, we will not be able to complete this trace.
 		t.full = true
 		t.spans = nil // GC
-		if tr, ok := ddtrace.GetGlobalTracer().(*tracer); ok {
+		if sp.tracer != nil {
 			// we have a tracer we can submit errors too.
-			tr.pushError(&spanBufferFullError{})
+			sp.tracer.pushError(&spanBufferFullError{})
 		}
-		return
+		return true
 	}
 	if v, ok := sp.Metrics[keySamplingPriority]; ok {
 		t.setSamplingPriorityLocked(v)
 	}
 	t.spans = append(t.spans, sp)
+	t.pushed++
+	return true
+}
+
+// dropOne accounts for a span dropped without being pushed.
+func (t *trace) dropOne() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropped++
 }
 
 // finishedOne aknowledges that another span in the trace has finished, and checks
@@ -234,10 +330,46 @@ func (t *trace) finishedOne(s *span) {
 	if len(t.spans) != t.finished {
 		return
 	}
-	if tr, ok := ddtrace.GetGlobalTracer().(*tracer); ok {
+	if t.dropped > 0 {
+		// all the spans are finished and can no longer be modified
+		t.spans[0].Meta[keyDroppedSpans] = strconv.Itoa(t.dropped)
+		t.dropped = 0
+	}
+	if tr := t.owner(s); tr != nil {
 		// we have a tracer that can receive completed traces.
-		tr.pushTrace(t.spans)
+		keep := !s.context.drop
+		if tr.dropRejected {
+			keep = tr.keepPrioritized(keep, t.priority, len(t.spans))
+		}
+		if keep {
+			tr.pushTrace(t.spans)
+		} else if tr.spanMetrics != nil {
+			// not sampled, only account for it in metrics
+			tr.pushDroppedTrace(t.spans)
+		}
 	}
 	t.spans = nil
 	t.finished = 0 // important, because a buffer can be used for several flushes
 }
+
+// owner returns the tracer receiving the trace once finished: the one which
+// started its root span, else the one which started s. It returns nil for
+// spans which were not started by a tracer. t.mu must be held.
+func (t *trace) owner(s *span) *tracer {
+	if t.root != nil && t.root.tracer != nil {
+		return t.root.tracer
+	}
+	return s.tracer
+}
+
+// spanLinks returns the value of the ext.SpanLinks tag of the given span
+// contexts. Foreign contexts are skipped.
+func spanLinks(links []ddtrace.SpanContext) string {
+	var refs []string
+	for _, l := range links {
+		if c, ok := l.(*spanContext); ok && c.traceID != 0 && c.spanID != 0 {
+			refs = append(refs, traceIDToHex(c.traceIDHigh, c.traceID)+":"+toHex(c.spanID))
+		}
+	}
+	return strings.Join(refs, ",")
+}
@@ -416,3 +416,51 @@ func TestSpanContextIteratorBreak(t *testing.T) {
 
 	assert.Len(t, got, 0)
 }
+
+func TestSpanLinksAndFollowsFrom(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("root")
+	other := &spanContext{traceIDHigh: 1, traceID: 2, spanID: 3}
+	sp := tracer.StartSpan("op",
+		FollowsFrom(root.Context()),
+		Link(other),
+		Link(&externalSpanContext{}),
+	).(*span)
+	assert.Equal(SpanID(root.Context()), sp.ParentID)
+	assert.Equal("true", sp.Meta[ext.FollowsFrom])
+	assert.Equal("00000000000000010000000000000002:0000000000000003", sp.Meta[ext.SpanLinks])
+
+	child := tracer.StartSpan("child", ChildOf(root.Context())).(*span)
+	assert.NotContains(child.Meta, ext.FollowsFrom)
+	assert.NotContains(child.Meta, ext.SpanLinks)
+
+	orphan := tracer.StartSpan("orphan", FollowsFrom(nil)).(*span)
+	assert.NotContains(orphan.Meta, ext.FollowsFrom)
+}
+
+func TestNewSpanContextFromConfig(t *testing.T) {
+	assert := assert.New(t)
+	priority := ext.PriorityUserKeep
+	ctx := NewSpanContext(SpanContextConfig{
+		TraceIDHigh:      1,
+		TraceID:          2,
+		SpanID:           3,
+		SamplingPriority: &priority,
+		Baggage:          map[string]string{"a": "b"},
+	})
+	carrier := TextMapCarrier{}
+	assert.NoError(NewPropagator(&PropagatorConfig{Styles: []string{"b3"}}).Inject(ctx, carrier))
+	assert.Equal(TextMapCarrier{
+		b3TraceIDHeader:                  "00000000000000010000000000000002",
+		b3SpanIDHeader:                   "0000000000000003",
+		b3SampledHeader:                  "1",
+		DefaultBaggageHeaderPrefix + "a": "b",
+	}, carrier)
+
+	ctx = NewSpanContext(SpanContextConfig{TraceID: 2, SpanID: 3})
+	_, ok := SamplingPriority(ctx)
+	assert.False(ok)
+}
@@ -0,0 +1,168 @@
+package tracer
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// RecordError records err on s if it implements ddtrace.ErrorRecorder, as the
+// spans of this package do, and has no effect otherwise.
+func RecordError(s ddtrace.Span, err error, opts ...ddtrace.ErrorOption) {
+	r, ok := s.(ddtrace.ErrorRecorder)
+	if !ok {
+		return
+	}
+	// skip this function in the stack trace
+	r.RecordError(err, append(opts[:len(opts):len(opts)], func(cfg *ddtrace.ErrorConfig) {
+		cfg.SkipStackFrames++
+	})...)
+}
+
+// RecordError appends an error event to the logs of the span, holding the type,
+// message and stack trace of err, and the types of the errors it wraps. Events
+// of previous calls are kept, so that all the errors of retried operations are
+// reported. The span is only marked as failed with the MarkFailed option. It
+// has no effect if err is nil or the span is finished.
+func (s *span) RecordError(err error, opts ...ddtrace.ErrorOption) {
+	if err == nil {
+		return
+	}
+	var cfg ddtrace.ErrorConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.Time.IsZero() {
+		cfg.Time = time.Now()
+	}
+	chain := errorChain(err)
+	// +1 to start at the caller of RecordError
+	stack := errorStack(chain, &errorConfig{
+		noDebugStack: cfg.NoDebugStack,
+		stackFrames:  cfg.StackFrames,
+		stackSkip:    cfg.SkipStackFrames + 1,
+	})
+
+	s.Lock()
+	defer s.Unlock()
+	if s.finished || !s.allowLog() {
+		return
+	}
+	fields := map[string]interface{}{
+		ext.Event:          ext.ErrorEvent,
+		ext.EventErrorKind: reflect.TypeOf(err).String(),
+		ext.Message:        s.truncate(err.Error()),
+	}
+	if causes := errorCauses(chain); causes != "" {
+		fields[ext.EventErrorCauses] = causes
+	}
+	if stack != "" {
+		fields[ext.Stack] = s.truncate(stack)
+	}
+	s.Logs = append(s.Logs, &logFields{fields, cfg.Time})
+	if cfg.MarkFailed {
+		s.Error = 1
+		s.setTagString(ext.Error, "true")
+		s.setErrorTags(err, chain, stack)
+	}
+}
+
+// maxErrorChain bounds the number of wrapped errors inspected, protecting
+// against cyclic chains.
+const maxErrorChain = 32
+
+// errorChain returns err followed by the errors it wraps, found through the
+// Unwrap method of the standard library or the Cause method of
+// github.com/pkg/errors. The errors wrapped by an Unwrap method returning a
+// slice, as implemented by errors.Join, are all walked depth first, in order.
+func errorChain(err error) []error {
+	chain := make([]error, 0, 1)
+	pending := []error{err}
+	for len(pending) > 0 && len(chain) < maxErrorChain {
+		err = pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if err == nil {
+			continue
+		}
+		chain = append(chain, err)
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			pending = append(pending, e.Unwrap())
+		case interface{ Unwrap() []error }:
+			errs := e.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				pending = append(pending, errs[i])
+			}
+		case interface{ Cause() error }:
+			pending = append(pending, e.Cause())
+		}
+	}
+	return chain
+}
+
+// errorCauses returns the types of the errors wrapped by the first error of
+// chain, outermost first, or the empty string if it wraps none.
+func errorCauses(chain []error) string {
+	if len(chain) < 2 {
+		return ""
+	}
+	types := make([]string, len(chain)-1)
+	for i, err := range chain[1:] {
+		types[i] = reflect.TypeOf(err).String()
+	}
+	return strings.Join(types, ", ")
+}
+
+// errorStack returns the stack trace to attach to err, in order of preference:
+// the one returned by the custom provider of cfg, the one carried by the
+// innermost error of chain implementing the StackTrace method of
+// github.com/pkg/errors, or the stack of the caller. It returns the empty
+// string when stacks are disabled.
+func errorStack(chain []error, cfg *errorConfig) string {
+	if cfg.noDebugStack {
+		return ""
+	}
+	if cfg.stackProvider != nil {
+		if stack := cfg.stackProvider(chain[0]); stack != "" {
+			return stack
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if stack, ok := carriedStack(chain[i], cfg.stackFrames); ok {
+			return stack
+		}
+	}
+	if cfg.stackFrames == 0 {
+		return string(debug.Stack())
+	}
+	// +1 to start at setTagError, which records the error
+	return takeStacktrace(cfg.stackFrames, cfg.stackSkip+1)
+}
+
+// carriedStack formats the stack trace returned by the StackTrace method of
+// err, as implemented by github.com/pkg/errors, keeping at most n frames if n
+// is not 0. Reflection avoids depending on that package: the method must take
+// no argument and return a slice of frames, each formatted with %+v.
+func carriedStack(err error, n uint) (string, bool) {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 || m.Type().Out(0).Kind() != reflect.Slice {
+		return "", false
+	}
+	st := m.Call(nil)[0]
+	if st.Len() == 0 {
+		return "", false
+	}
+	if n > 0 && uint(st.Len()) > n {
+		st = st.Slice(0, int(n))
+	}
+	frames := make([]string, st.Len())
+	for i := range frames {
+		frames[i] = fmt.Sprintf("%+v", st.Index(i).Interface())
+	}
+	return strings.Join(frames, "\n"), true
+}
@@ -0,0 +1,213 @@
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// wrapError wraps an error in the manner of the standard library.
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string { return e.msg + ": " + e.err.Error() }
+
+func (e *wrapError) Unwrap() error { return e.err }
+
+// causeError wraps an error in the manner of github.com/pkg/errors.
+type causeError struct {
+	err error
+}
+
+func (e causeError) Error() string { return "cause: " + e.err.Error() }
+
+func (e causeError) Cause() error { return e.err }
+
+// joinError wraps several errors in the manner of errors.Join.
+type joinError []error
+
+func (e joinError) Error() string { return "join" }
+
+func (e joinError) Unwrap() []error { return e }
+
+// testFrame mimics errors.Frame of github.com/pkg/errors.
+type testFrame string
+
+func (f testFrame) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, "%s\n\t/src/%s.go:1", string(f), string(f))
+}
+
+// stackError carries its stack trace like github.com/pkg/errors errors do.
+type stackError struct {
+	msg   string
+	stack []testFrame
+}
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) StackTrace() []testFrame { return e.stack }
+
+func TestErrorChain(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := errors.New("inner")
+	err := &wrapError{"outer", causeError{inner}}
+	chain := errorChain(err)
+	assert.Equal([]error{err, causeError{inner}, inner}, chain)
+	assert.Equal("tracer.causeError, *errors.errorString", errorCauses(chain))
+	assert.Empty(errorCauses(errorChain(inner)))
+
+	// cyclic chains are bounded
+	cyclic := &wrapError{msg: "cyclic"}
+	cyclic.err = cyclic
+	assert.Len(errorChain(cyclic), maxErrorChain)
+
+	// all the errors of multi-errors are walked in order
+	first, second := errors.New("first"), errors.New("second")
+	join := joinError{&wrapError{"wrapped", first}, nil, second}
+	assert.Equal([]error{join, join[0], first, second}, errorChain(join))
+	assert.Equal("*tracer.wrapError, *errors.errorString, *errors.errorString", errorCauses(errorChain(join)))
+}
+
+func TestSpanFinishWithWrappedError(t *testing.T) {
+	assert := assert.New(t)
+
+	err := &wrapError{"query failed", causeError{errors.New("timeout")}}
+	span := newBasicSpan("web.request")
+	span.FinishWithOptionsExt(WithError(err))
+
+	assert.Equal("query failed: cause: timeout", span.Meta[ext.ErrorMsg])
+	assert.Equal("*tracer.wrapError", span.Meta[ext.ErrorKind])
+	assert.Equal("tracer.causeError, *errors.errorString", span.Meta[ext.ErrorCauses])
+	assert.Contains(span.Meta[ext.ErrorStack], "goroutine")
+}
+
+func TestSpanFinishWithCarriedStack(t *testing.T) {
+	err := &wrapError{"outer", &stackError{"inner", []testFrame{"main.query", "main.handle", "main.main"}}}
+
+	t.Run("full", func(t *testing.T) {
+		span := newBasicSpan("web.request")
+		span.FinishWithOptionsExt(WithError(err))
+		assert.Equal(t, "main.query\n\t/src/main.query.go:1\nmain.handle\n\t/src/main.handle.go:1\nmain.main\n\t/src/main.main.go:1",
+			span.Meta[ext.ErrorStack])
+	})
+
+	t.Run("frames", func(t *testing.T) {
+		span := newBasicSpan("web.request")
+		span.FinishWithOptionsExt(WithError(err), StackFrames(1, 0))
+		assert.Equal(t, "main.query\n\t/src/main.query.go:1", span.Meta[ext.ErrorStack])
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		span := newBasicSpan("web.request")
+		span.FinishWithOptionsExt(WithError(&stackError{msg: "no stack"}))
+		assert.Contains(t, span.Meta[ext.ErrorStack], "goroutine")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		span := newBasicSpan("web.request")
+		span.FinishWithOptionsExt(WithError(err), NoDebugStack())
+		assert.Empty(t, span.Meta[ext.ErrorStack])
+		assert.Equal(t, "*tracer.stackError", span.Meta[ext.ErrorCauses])
+	})
+}
+
+func TestSpanFinishWithErrorStack(t *testing.T) {
+	assert := assert.New(t)
+
+	var got error
+	provider := func(err error) string {
+		got = err
+		if strings.HasPrefix(err.Error(), "custom") {
+			return "custom stack"
+		}
+		return ""
+	}
+
+	err := errors.New("custom error")
+	span := newBasicSpan("web.request")
+	span.FinishWithOptionsExt(WithError(err), WithErrorStack(provider))
+	assert.Equal(err, got)
+	assert.Equal("custom stack", span.Meta[ext.ErrorStack])
+
+	// falls back to the default stack when the provider returns nothing
+	span = newBasicSpan("web.request")
+	span.FinishWithOptionsExt(WithError(errors.New("other error")), WithErrorStack(provider))
+	assert.Contains(span.Meta[ext.ErrorStack], "goroutine")
+
+	got = nil
+	span = newBasicSpan("web.request")
+	span.FinishWithOptionsExt(WithError(err), WithErrorStack(provider), NoDebugStack())
+	assert.Nil(got)
+	assert.Empty(span.Meta[ext.ErrorStack])
+}
+
+func TestSpanRecordError(t *testing.T) {
+	assert := assert.New(t)
+
+	span := newBasicSpan("web.request")
+	first := time.Unix(1, 0)
+	span.RecordError(errors.New("timeout"), ErrorTime(first))
+	span.RecordError(&wrapError{"retry failed", errors.New("refused")}, ErrorStackFrames(1, 0))
+	span.RecordError(nil)
+
+	assert.Equal(int32(0), span.Error)
+	assert.Empty(span.Meta[ext.ErrorMsg])
+	assert.Len(span.Logs, 2)
+
+	assert.Equal(first, span.Logs[0].time)
+	assert.Equal(ext.ErrorEvent, span.Logs[0].fields[ext.Event])
+	assert.Equal("*errors.errorString", span.Logs[0].fields[ext.EventErrorKind])
+	assert.Equal("timeout", span.Logs[0].fields[ext.Message])
+	assert.Contains(span.Logs[0].fields[ext.Stack], "goroutine")
+	assert.NotContains(span.Logs[0].fields, ext.EventErrorCauses)
+
+	assert.Equal("retry failed: refused", span.Logs[1].fields[ext.Message])
+	assert.Equal("*errors.errorString", span.Logs[1].fields[ext.EventErrorCauses])
+	assert.True(strings.HasPrefix(span.Logs[1].fields[ext.Stack].(string), "github.com/signalfx/signalfx-go-tracing/ddtrace/tracer.TestSpanRecordError\n"))
+
+	span.RecordError(errors.New("fatal"), MarkFailed(), ErrorNoDebugStack())
+	assert.Len(span.Logs, 3)
+	assert.NotContains(span.Logs[2].fields, ext.Stack)
+	assert.Equal(int32(1), span.Error)
+	assert.Equal("true", span.Meta[ext.Error])
+	assert.Equal("fatal", span.Meta[ext.ErrorMsg])
+
+	span.Finish()
+	span.RecordError(errors.New("late"))
+	assert.Len(span.Logs, 3)
+}
+
+func TestRecordError(t *testing.T) {
+	assert := assert.New(t)
+
+	span := newBasicSpan("web.request")
+	RecordError(span, errors.New("timeout"), ErrorStackFrames(1, 0))
+	assert.Len(span.Logs, 1)
+	assert.True(strings.HasPrefix(span.Logs[0].fields[ext.Stack].(string), "github.com/signalfx/signalfx-go-tracing/ddtrace/tracer.TestRecordError\n"))
+
+	// spans which can not record errors are left alone
+	assert.NotPanics(func() {
+		RecordError(struct{ ddtrace.Span }{ddtrace.NoopSpan{}}, errors.New("timeout"))
+	})
+}
+
+func TestRecordErrorAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	span := newBasicSpan("web.request")
+	span.RecordError(errors.New("timeout"), ErrorTime(time.Unix(2, 0)), ErrorNoDebugStack())
+	annotations := convertLogs(span.Logs)
+	assert.Len(annotations, 1)
+	assert.Equal(int64(2000000), *annotations[0].Timestamp)
+	assert.JSONEq(`{"event":"error","error.kind":"*errors.errorString","message":"timeout"}`, *annotations[0].Value)
+}
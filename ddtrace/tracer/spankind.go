@@ -0,0 +1,57 @@
+package tracer
+
+import (
+	"strings"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+// Kind is the role of the operation of a span in a trace, as reported by all
+// the exporters.
+type Kind string
+
+const (
+	// KindServer is the kind of spans handling a synchronous request, such as
+	// an incoming HTTP request or RPC.
+	KindServer Kind = ext.SpanKindServer
+
+	// KindClient is the kind of spans making a synchronous request to a remote
+	// service, such as an outgoing HTTP request, RPC or database query.
+	KindClient Kind = ext.SpanKindClient
+
+	// KindProducer is the kind of spans sending a message to a broker.
+	KindProducer Kind = ext.SpanKindProducer
+
+	// KindConsumer is the kind of spans receiving a message from a broker.
+	KindConsumer Kind = ext.SpanKindConsumer
+
+	// KindInternal is the kind of spans of operations which do not cross a
+	// process boundary.
+	KindInternal Kind = ext.SpanKindInternal
+)
+
+// SpanKind sets the kind of the started span. Spans without a kind are
+// assigned one based on their span type, if known.
+func SpanKind(kind Kind) StartSpanOption {
+	return Tag(ext.SpanKind, string(kind))
+}
+
+// spanKindOf returns the kind of s, as set with SpanKind or the "span.kind"
+// tag, or derived from its span type. It returns the empty string when the
+// kind is unknown. This function is not safe for concurrent use.
+func spanKindOf(s *span) string {
+	if kind, ok := s.Meta[ext.SpanKind]; ok {
+		return strings.ToUpper(kind)
+	}
+	switch s.Type {
+	case ext.SpanTypeHTTP, ext.SpanTypeMongoDB, ext.SpanTypeSQL:
+		return ext.SpanKindClient
+	case ext.SpanTypeWeb:
+		return ext.SpanKindServer
+	case ext.SpanTypeMessageConsumer:
+		return ext.SpanKindConsumer
+	case ext.SpanTypeMessageProducer:
+		return ext.SpanKindProducer
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package tracer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+func TestSpanKindOf(t *testing.T) {
+	for name, tt := range map[string]struct {
+		span *span
+		kind string
+	}{
+		"tag":      {&span{Meta: map[string]string{ext.SpanKind: "server"}}, ext.SpanKindServer},
+		"override": {&span{Type: ext.SpanTypeHTTP, Meta: map[string]string{ext.SpanKind: ext.SpanKindInternal}}, ext.SpanKindInternal},
+		"http":     {&span{Type: ext.SpanTypeHTTP}, ext.SpanKindClient},
+		"web":      {&span{Type: ext.SpanTypeWeb}, ext.SpanKindServer},
+		"sql":      {&span{Type: ext.SpanTypeSQL}, ext.SpanKindClient},
+		"consumer": {&span{Type: ext.SpanTypeMessageConsumer}, ext.SpanKindConsumer},
+		"producer": {&span{Type: ext.SpanTypeMessageProducer}, ext.SpanKindProducer},
+		"unknown":  {&span{Type: ext.SpanTypeRedis}, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, spanKindOf(tt.span))
+		})
+	}
+}
+
+func TestSpanKindExporters(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	var spans spanList
+	for _, kind := range []Kind{KindServer, KindClient, KindProducer, KindConsumer, KindInternal} {
+		s := tracer.StartSpan("op", SpanKind(kind), SpanType(ext.SpanTypeHTTP)).(*span)
+		s.Finish()
+		spans = append(spans, s)
+	}
+
+	converted := newZipkinPayload("test-service").convertSpans(spans)
+	for i, kind := range []string{ext.SpanKindServer, ext.SpanKindClient, ext.SpanKindProducer, ext.SpanKindConsumer} {
+		require.NotNil(t, converted[i].Kind)
+		assert.Equal(kind, *converted[i].Kind)
+	}
+	assert.Nil(converted[4].Kind)
+	assert.NotContains(converted[4].Tags, ext.SpanKind)
+
+	assert.Equal(ext.SpanKindInternal, newConsoleSpan(spans[4]).Kind)
+
+	agg := newSpanMetricsAggregator("test-service", 10)
+	agg.addSpan(spans[4])
+	require.Len(t, agg.metrics, 1)
+	for key := range agg.metrics {
+		assert.Equal(ext.SpanKindInternal, key.Kind)
+	}
+}
+
+func TestSpanSetStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	t.Run("error", func(t *testing.T) {
+		s := tracer.StartSpan("op").(*span)
+		s.SetStatus(StatusError, "invalid argument")
+		s.Finish()
+		assert.Equal(int32(1), s.Error)
+		assert.Equal("true", s.Meta[ext.Error])
+		assert.Equal("ERROR", s.Meta[ext.StatusCode])
+		assert.Equal("invalid argument", s.Meta[ext.StatusDescription])
+		assert.Equal("invalid argument", s.Meta[ext.ErrorMsg])
+	})
+
+	t.Run("error-message", func(t *testing.T) {
+		s := tracer.StartSpan("op").(*span)
+		s.SetTag(ext.Error, errors.New("failed"))
+		s.SetStatus(StatusError, "invalid argument")
+		s.Finish()
+		assert.Equal("failed", s.Meta[ext.ErrorMsg])
+		assert.Equal("invalid argument", s.Meta[ext.StatusDescription])
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		s := tracer.StartSpan("op").(*span)
+		s.SetStatus(StatusError, "invalid argument")
+		s.SetStatus(StatusOK, "ignored")
+		s.Finish()
+		assert.Equal(int32(0), s.Error)
+		assert.NotContains(s.Meta, ext.Error)
+		assert.NotContains(s.Meta, ext.StatusDescription)
+		assert.Equal("OK", s.Meta[ext.StatusCode])
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		s := tracer.StartSpan("op").(*span)
+		s.SetStatus(StatusOK, "")
+		s.SetStatus(StatusUnset, "")
+		s.Finish()
+		assert.NotContains(s.Meta, ext.StatusCode)
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		s := tracer.StartSpan("op").(*span)
+		s.Finish()
+		s.SetStatus(StatusError, "late")
+		assert.Equal(int32(0), s.Error)
+		assert.NotContains(s.Meta, ext.StatusCode)
+	})
+
+	t.Run("helper", func(t *testing.T) {
+		s := tracer.StartSpan("op")
+		SetStatus(s, StatusError, "invalid argument")
+		assert.Equal(int32(1), s.(*span).Error)
+
+		// spans which can not hold a status are left alone
+		assert.NotPanics(func() {
+			SetStatus(struct{ ddtrace.Span }{ddtrace.NoopSpan{}}, StatusError, "invalid argument")
+		})
+	})
+}
@@ -0,0 +1,256 @@
+package tracer
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+const (
+	// defaultSpanMetricsInterval is the default interval at which span metrics
+	// are flushed.
+	defaultSpanMetricsInterval = 10 * time.Second
+
+	// defaultSpanMetricsMaxKeys is the default maximum number of distinct
+	// metric keys aggregated during one interval.
+	defaultSpanMetricsMaxKeys = 1000
+)
+
+// SpanMetricsOverflow is the operation name of the key which aggregates spans
+// that would have exceeded the key cardinality limit.
+const SpanMetricsOverflow = "_overflow"
+
+// SpanDurationBuckets holds the upper bounds of the span duration histogram.
+// The last bucket is unbounded.
+var SpanDurationBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// SpanMetricsKey identifies a group of spans aggregated together.
+type SpanMetricsKey struct {
+	Service    string
+	Operation  string
+	Resource   string
+	Kind       string // e.g. "SERVER" or "CLIENT"; empty if unknown
+	StatusCode string // the http.status_code tag; empty if not set
+}
+
+// SpanMetrics holds request rate, error and duration metrics aggregated over
+// all spans sharing the same key during one flush interval.
+type SpanMetrics struct {
+	SpanMetricsKey
+
+	// Count is the number of finished spans.
+	Count uint64
+
+	// Errors is the number of finished spans marked as errors.
+	Errors uint64
+
+	// Duration is the sum of the span durations.
+	Duration time.Duration
+
+	// MaxDuration is the longest span duration.
+	MaxDuration time.Duration
+
+	// Buckets holds the number of spans whose duration falls in each bucket of
+	// SpanDurationBuckets, followed by the unbounded bucket.
+	Buckets []uint64
+}
+
+// SpanMetricsSink receives the span metrics aggregated by the tracer.
+type SpanMetricsSink interface {
+	// Export is called at every flush interval with the metrics aggregated
+	// since the previous call. It is called from the tracer's worker and
+	// should not block for long.
+	Export(metrics []*SpanMetrics) error
+}
+
+// spanMetricsAggregator aggregates finished spans into SpanMetrics. It is
+// only used by the tracer's worker and is not safe for concurrent use.
+type spanMetricsAggregator struct {
+	service string // the service used for the overflow key
+	maxKeys int
+	metrics map[SpanMetricsKey]*SpanMetrics
+}
+
+func newSpanMetricsAggregator(service string, maxKeys int) *spanMetricsAggregator {
+	return &spanMetricsAggregator{
+		service: service,
+		maxKeys: maxKeys,
+		metrics: make(map[SpanMetricsKey]*SpanMetrics),
+	}
+}
+
+// add records all the spans of a finished trace.
+func (a *spanMetricsAggregator) add(trace []*span) {
+	for _, s := range trace {
+		a.addSpan(s)
+	}
+}
+
+func (a *spanMetricsAggregator) addSpan(s *span) {
+	key := SpanMetricsKey{
+		Service:    s.Service,
+		Operation:  s.Name,
+		Resource:   s.Resource,
+		StatusCode: s.Meta[ext.HTTPCode],
+		Kind:       spanKindOf(s),
+	}
+	m, ok := a.metrics[key]
+	if !ok {
+		if len(a.metrics) >= a.maxKeys {
+			key = SpanMetricsKey{Service: a.service, Operation: SpanMetricsOverflow}
+			m, ok = a.metrics[key]
+		}
+		if !ok {
+			m = &SpanMetrics{SpanMetricsKey: key, Buckets: make([]uint64, len(SpanDurationBuckets)+1)}
+			a.metrics[key] = m
+		}
+	}
+	d := time.Duration(s.Duration)
+	m.Count++
+	if s.Error != 0 {
+		m.Errors++
+	}
+	m.Duration += d
+	if d > m.MaxDuration {
+		m.MaxDuration = d
+	}
+	m.Buckets[durationBucket(d)]++
+}
+
+// durationBucket returns the index of the histogram bucket d falls in.
+func durationBucket(d time.Duration) int {
+	for i, upper := range SpanDurationBuckets {
+		if d <= upper {
+			return i
+		}
+	}
+	return len(SpanDurationBuckets)
+}
+
+// reset returns the aggregated metrics and starts a new interval.
+func (a *spanMetricsAggregator) reset() []*SpanMetrics {
+	metrics := make([]*SpanMetrics, 0, len(a.metrics))
+	for _, m := range a.metrics {
+		metrics = append(metrics, m)
+	}
+	a.metrics = make(map[SpanMetricsKey]*SpanMetrics, len(metrics))
+	return metrics
+}
+
+// sfxSpanMetricsSink is the default SpanMetricsSink. It sends span metrics
+// as SignalFx datapoints.
+type sfxSpanMetricsSink struct {
+	transport metricsTransport
+	dims      map[string]string // dimensions common to all datapoints
+}
+
+var _ SpanMetricsSink = (*sfxSpanMetricsSink)(nil)
+
+// Export implements SpanMetricsSink.
+func (s *sfxSpanMetricsSink) Export(metrics []*SpanMetrics) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	var (
+		p  datapointPayload
+		ts = time.Now().UnixNano() / int64(time.Millisecond)
+	)
+	for _, m := range metrics {
+		dims := make(map[string]string, len(s.dims)+5)
+		for k, v := range s.dims {
+			dims[k] = v
+		}
+		dims["service"] = m.Service
+		dims["operation"] = m.Operation
+		if m.Resource != "" {
+			dims["resource"] = m.Resource
+		}
+		if m.Kind != "" {
+			dims["kind"] = m.Kind
+		}
+		if m.StatusCode != "" {
+			dims[sanitizeDimensionKey(ext.HTTPCode)] = m.StatusCode
+		}
+		p.counter("spans.count", float64(m.Count), dims, ts)
+		p.counter("spans.errors", float64(m.Errors), dims, ts)
+		p.counter("spans.duration_ns.sum", float64(m.Duration), dims, ts)
+		p.gauge("spans.duration_ns.max", float64(m.MaxDuration), dims, ts)
+		for i, n := range m.Buckets {
+			bdims := make(map[string]string, len(dims)+1)
+			for k, v := range dims {
+				bdims[k] = v
+			}
+			if i < len(SpanDurationBuckets) {
+				bdims["le"] = strconv.FormatInt(int64(SpanDurationBuckets[i]), 10)
+			} else {
+				bdims["le"] = strconv.FormatFloat(math.Inf(1), 'f', -1, 64)
+			}
+			p.counter("spans.duration_ns.bucket", float64(n), bdims, ts)
+		}
+	}
+	if err := s.transport.send(&p); err != nil {
+		return &metricsLossError{context: err, count: p.len()}
+	}
+	return nil
+}
+
+// pushDroppedTrace sends a trace dropped by the sampler to the worker, so that
+// it is accounted for in span metrics without being encoded.
+func (t *tracer) pushDroppedTrace(trace []*span) {
+	select {
+	case <-t.stopped:
+		return
+	default:
+	}
+	select {
+	case t.droppedQueue <- trace:
+	default:
+		t.pushError(&dataLossError{
+			context: errors.New("span metrics queue full, dropping trace"),
+			count:   len(trace),
+		})
+	}
+}
+
+// drainDroppedQueue accounts for all the dropped traces still in the queue.
+func (t *tracer) drainDroppedQueue() {
+	for {
+		select {
+		case trace := <-t.droppedQueue:
+			t.spanMetrics.add(trace)
+		default:
+			return
+		}
+	}
+}
+
+// flushSpanMetrics exports the span metrics aggregated since the previous flush.
+func (t *tracer) flushSpanMetrics() {
+	metrics := t.spanMetrics.reset()
+	if len(metrics) == 0 {
+		return
+	}
+	if err := t.config.spanMetricsSink.Export(metrics); err != nil {
+		if _, ok := err.(*metricsLossError); !ok {
+			err = &metricsLossError{context: err, count: len(metrics)}
+		}
+		t.pushError(err)
+	}
+}
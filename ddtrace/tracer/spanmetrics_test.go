@@ -0,0 +1,166 @@
+package tracer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+)
+
+type testSpanMetricsSink struct {
+	mu      sync.Mutex
+	metrics []*SpanMetrics
+	err     error
+}
+
+func (s *testSpanMetricsSink) Export(metrics []*SpanMetrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, metrics...)
+	return s.err
+}
+
+func (s *testSpanMetricsSink) get() []*SpanMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metrics
+}
+
+type testMetricsTransport struct {
+	payloads []*datapointPayload
+	err      error
+}
+
+func (t *testMetricsTransport) send(p *datapointPayload) error {
+	t.payloads = append(t.payloads, p)
+	return t.err
+}
+
+func TestSpanMetricsAggregator(t *testing.T) {
+	assert := assert.New(t)
+	a := newSpanMetricsAggregator("svc", 2)
+	mk := func(name string, d time.Duration, errored bool) *span {
+		s := newBasicSpan(name)
+		s.Service = "svc"
+		s.Type = ext.SpanTypeWeb
+		s.Meta[ext.HTTPCode] = "200"
+		s.Duration = int64(d)
+		if errored {
+			s.Error = 1
+		}
+		return s
+	}
+	a.add([]*span{
+		mk("a", 3*time.Millisecond, false),
+		mk("a", 20*time.Millisecond, true),
+		mk("b", time.Minute, false),
+		mk("c", time.Millisecond, false), // overflow
+		mk("d", time.Millisecond, false), // overflow
+	})
+	metrics := a.reset()
+	assert.Len(metrics, 3)
+	byOp := map[string]*SpanMetrics{}
+	for _, m := range metrics {
+		byOp[m.Operation] = m
+	}
+
+	ma := byOp["a"]
+	assert.Equal(SpanMetricsKey{Service: "svc", Operation: "a", Kind: ext.SpanKindServer, StatusCode: "200"}, ma.SpanMetricsKey)
+	assert.Equal(uint64(2), ma.Count)
+	assert.Equal(uint64(1), ma.Errors)
+	assert.Equal(23*time.Millisecond, ma.Duration)
+	assert.Equal(20*time.Millisecond, ma.MaxDuration)
+	assert.Equal(uint64(1), ma.Buckets[durationBucket(5*time.Millisecond)])
+	assert.Equal(uint64(1), ma.Buckets[durationBucket(25*time.Millisecond)])
+
+	assert.Equal(uint64(1), byOp["b"].Buckets[len(SpanDurationBuckets)])
+
+	overflow := byOp[SpanMetricsOverflow]
+	assert.Equal(SpanMetricsKey{Service: "svc", Operation: SpanMetricsOverflow}, overflow.SpanMetricsKey)
+	assert.Equal(uint64(2), overflow.Count)
+
+	assert.Empty(a.reset())
+}
+
+func TestDurationBucket(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(0, durationBucket(0))
+	assert.Equal(0, durationBucket(time.Millisecond))
+	assert.Equal(1, durationBucket(time.Millisecond+1))
+	assert.Equal(len(SpanDurationBuckets), durationBucket(time.Hour))
+}
+
+func TestSfxSpanMetricsSink(t *testing.T) {
+	assert := assert.New(t)
+	transport := &testMetricsTransport{}
+	sink := &sfxSpanMetricsSink{transport: transport, dims: map[string]string{"service": "app", "env": "prod"}}
+
+	assert.NoError(sink.Export(nil))
+	assert.Empty(transport.payloads)
+
+	m := &SpanMetrics{
+		SpanMetricsKey: SpanMetricsKey{Service: "redis", Operation: "redis.command", StatusCode: "500"},
+		Count:          3,
+		Errors:         1,
+		Duration:       time.Second,
+		MaxDuration:    time.Second / 2,
+		Buckets:        make([]uint64, len(SpanDurationBuckets)+1),
+	}
+	assert.NoError(sink.Export([]*SpanMetrics{m}))
+	assert.Len(transport.payloads, 1)
+	p := transport.payloads[0]
+	assert.Len(p.Counter, 3+len(SpanDurationBuckets)+1)
+	assert.Len(p.Gauge, 1)
+
+	count := p.Counter[0]
+	assert.Equal("spans.count", count.Metric)
+	assert.Equal(3., count.Value)
+	assert.Equal(map[string]string{
+		"service":          "redis",
+		"operation":        "redis.command",
+		"env":              "prod",
+		"http_status_code": "500",
+	}, count.Dimensions)
+	assert.Equal("+Inf", p.Counter[len(p.Counter)-1].Dimensions["le"])
+
+	transport.err = errors.New("boom")
+	err := sink.Export([]*SpanMetrics{m})
+	assert.IsType(&metricsLossError{}, err)
+}
+
+func TestTracerSpanMetrics(t *testing.T) {
+	assert := assert.New(t)
+	sink := &testSpanMetricsSink{}
+	tracer, transport, stop := startTestTracer(
+		WithSpanMetricsSink(sink),
+		WithSpanMetricsInterval(time.Hour),
+		WithSampler(NewRateSampler(0)),
+	)
+
+	root := tracer.StartSpan("web.request", SpanType(ext.SpanTypeWeb)).(*span)
+	child := tracer.StartSpan("db.query", ChildOf(root.Context())).(*span)
+	assert.True(root.context.drop)
+	child.Finish()
+	root.Finish()
+
+	// the trace is dropped but accounted for in metrics on stop
+	stop()
+	assert.Empty(transport.Traces())
+	metrics := sink.get()
+	assert.Len(metrics, 2)
+	for _, m := range metrics {
+		assert.Equal(uint64(1), m.Count)
+	}
+}
+
+func TestTracerSpanMetricsDisabled(t *testing.T) {
+	tracer, _, stop := startTestTracer()
+	defer stop()
+	assert.Nil(t, tracer.spanMetrics)
+	assert.Nil(t, tracer.droppedQueue)
+	assert.Nil(t, tracer.config.metricsTransport)
+}
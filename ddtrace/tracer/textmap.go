@@ -101,6 +101,12 @@ type PropagatorConfig struct {
 	// PriorityHeader specifies the map key that will be used to store the sampling priority.
 	// It defaults to DefaultPriorityHeader.
 	PriorityHeader string
+
+	// Styles specifies the propagation styles, "b3" or "datadog", used to inject
+	// and extract span contexts. When empty, the styles are read from the
+	// DD_PROPAGATION_STYLE_INJECT and DD_PROPAGATION_STYLE_EXTRACT environment
+	// variables, defaulting to "b3".
+	Styles []string
 }
 
 // NewPropagator returns a new propagator which uses TextMap to inject
@@ -136,18 +142,23 @@ type chainedPropagator struct {
 	extractors []Propagator
 }
 
-// getPropagators returns a list of propagators based on the list found in the
-// given environment variable. If the list doesn't contain a value or has invalid
-// values, the default propagator will be returned.
+// getPropagators returns a list of propagators based on the styles configured in
+// cfg or, if none, the list found in the given environment variable. If the list
+// doesn't contain a value or has invalid values, the default propagator will be
+// returned.
 func getPropagators(cfg *PropagatorConfig, env string) []Propagator {
 	b3 := &propagatorB3{}
-	ps := os.Getenv(env)
-	if ps == "" {
-		return []Propagator{b3}
+	styles := cfg.Styles
+	if len(styles) == 0 {
+		ps := os.Getenv(env)
+		if ps == "" {
+			return []Propagator{b3}
+		}
+		styles = strings.Split(ps, ",")
 	}
 	var list []Propagator
-	for _, v := range strings.Split(ps, ",") {
-		switch strings.ToLower(v) {
+	for _, v := range styles {
+		switch strings.ToLower(strings.TrimSpace(v)) {
 		case "datadog":
 			list = append(list, &propagator{cfg})
 		case "b3":
@@ -301,7 +312,7 @@ func (*propagatorB3) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWr
 	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
 		return ErrInvalidSpanContext
 	}
-	writer.Set(b3TraceIDHeader, toHex(ctx.traceID))
+	writer.Set(b3TraceIDHeader, traceIDToHex(ctx.traceIDHigh, ctx.traceID))
 	writer.Set(b3SpanIDHeader, toHex(ctx.spanID))
 	if ctx.hasSamplingPriority() {
 		if ctx.samplingPriority() >= ext.PriorityAutoKeep {
@@ -333,7 +344,7 @@ func (*propagatorB3) extractTextMap(reader TextMapReader) (ddtrace.SpanContext,
 		key := strings.ToLower(k)
 		switch key {
 		case b3TraceIDHeader:
-			ctx.traceID, err = strconv.ParseUint(v, 16, 64)
+			ctx.traceIDHigh, ctx.traceID, err = parseTraceIDHex(v)
 			if err != nil {
 				return ErrSpanContextCorrupted
 			}
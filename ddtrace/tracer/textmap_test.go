@@ -287,3 +287,25 @@ func TestB3(t *testing.T) {
 		assert.Equal(sctx.samplingPriority(), 2)
 	})
 }
+
+func TestPropagatorStyles(t *testing.T) {
+	os.Setenv("DD_PROPAGATION_STYLE_INJECT", "b3")
+	defer os.Unsetenv("DD_PROPAGATION_STYLE_INJECT")
+	assert := assert.New(t)
+
+	propagator := NewPropagator(&PropagatorConfig{Styles: []string{"datadog", " B3 "}})
+	tracer := newTracer(WithPropagator(propagator))
+	defer tracer.Stop()
+	root := tracer.StartSpan("web.request").(*span)
+	headers := TextMapCarrier(map[string]string{})
+	assert.Nil(tracer.Inject(root.Context(), headers))
+	assert.Equal(strconv.FormatUint(root.TraceID, 10), headers[DefaultTraceIDHeader])
+	assert.Equal(toHex(root.TraceID), headers[b3TraceIDHeader])
+
+	sctx, err := tracer.Extract(TextMapCarrier(map[string]string{
+		DefaultTraceIDHeader:  "1",
+		DefaultParentIDHeader: "2",
+	}))
+	assert.Nil(err)
+	assert.Equal(uint64(1), sctx.(*spanContext).traceID)
+}
@@ -10,6 +10,6 @@ func FormatAsTraceParent(context ddtrace.SpanContext) (string, bool) {
 	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
 		return "", false
 	}
-	answer := fmt.Sprintf("traceparent;desc=\"00-%032x-%016x-01\"", ctx.traceID, ctx.spanID)
+	answer := fmt.Sprintf("traceparent;desc=\"00-%016x%016x-%016x-01\"", ctx.traceIDHigh, ctx.traceID, ctx.spanID)
 	return answer, true
 }
@@ -2,9 +2,12 @@ package tracer
 
 import (
 	"errors"
+	"io"
 	"log"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	sfxtracing "github.com/signalfx/signalfx-go-tracing"
@@ -23,6 +26,12 @@ var _ ddtrace.Tracer = (*tracer)(nil)
 // channels. It additionally holds two buffers which accumulates error and trace
 // queues to be processed by the payload encoder.
 type tracer struct {
+	// rejectedTraces and rejectedSpans count the traces, and their spans,
+	// dropped because of their sampling priority. They are accessed
+	// atomically and kept first for 64-bit alignment.
+	rejectedTraces uint64
+	rejectedSpans  uint64
+
 	*config
 	payload encoder
 
@@ -34,9 +43,24 @@ type tracer struct {
 	payloadQueue chan []*span
 	errorBuffer  chan error
 
+	// droppedQueue receives the traces dropped by the sampler when span
+	// metrics are enabled; it is nil otherwise.
+	droppedQueue chan []*span
+
+	// spanMetrics aggregates finished spans into metrics when enabled.
+	spanMetrics *spanMetricsAggregator
+
 	// stopped is a channel that will be closed when the worker has exited.
 	stopped chan struct{}
 
+	// wg waits for background reporters, such as runtime metrics, to exit.
+	wg sync.WaitGroup
+
+	// dynamic holds the *Settings which can be changed with Reconfigure.
+	// reconfigureMu serializes changes.
+	dynamic       atomic.Value
+	reconfigureMu sync.Mutex
+
 	// syncPush is used for testing. When non-nil, it causes pushTrace to become
 	// a synchronous (blocking) operation, meaning that it will only return after
 	// the trace has been fully processed and added onto the payload.
@@ -72,6 +96,15 @@ func Start(opts ...StartOption) {
 	ddtrace.SetGlobalTracer(newTracer(opts...))
 }
 
+// New returns a tracer configured with the given options which, unlike the one
+// started with Start, is not the global tracer. It can be used alongside it,
+// for example by a library reporting under its own service name, and must be
+// stopped with its Stop method. The traces of the spans it starts are sent by
+// this tracer, whether or not it is global.
+func New(opts ...StartOption) ddtrace.Tracer {
+	return newTracer(opts...)
+}
+
 // Stop stops the started tracer. Subsequent calls are valid but become no-op.
 func Stop() {
 	ddtrace.SetGlobalTracer(&ddtrace.NoopTracer{})
@@ -94,14 +127,16 @@ func StartSpan(operationName string, opts ...StartSpanOption) ddtrace.Span {
 }
 
 // Extract extracts a SpanContext from the carrier. The carrier is expected
-// to implement TextMapReader, otherwise an error is returned.
+// to implement TextMapReader, or io.Reader for span contexts injected into an
+// io.Writer, otherwise an error is returned.
 // If the tracer is not started, calling this function is a no-op.
 func Extract(carrier interface{}) (ddtrace.SpanContext, error) {
 	return ddtrace.GetGlobalTracer().Extract(carrier)
 }
 
 // Inject injects the given SpanContext into the carrier. The carrier is
-// expected to implement TextMapWriter, otherwise an error is returned.
+// expected to implement TextMapWriter, or io.Writer to write the span context
+// in a compact binary encoding, otherwise an error is returned.
 // If the tracer is not started, calling this function is a no-op.
 func Inject(ctx ddtrace.SpanContext, carrier interface{}) error {
 	return ddtrace.GetGlobalTracer().Inject(ctx, carrier)
@@ -115,14 +150,24 @@ func SpanIDHex(ctx ddtrace.SpanContext) string {
 	return ""
 }
 
-// TraceIDHex returns the span ID from ddtrace.TraceContext
+// TraceIDHex returns the trace ID from ddtrace.SpanContext, as 32 hex digits
+// for 128-bit trace IDs and 16 otherwise.
 func TraceIDHex(ctx ddtrace.SpanContext) string {
 	if c, ok := ctx.(*spanContext); ok {
-		return idToHex(c.traceID)
+		return traceIDToHex(c.traceIDHigh, c.traceID)
 	}
 	return ""
 }
 
+// TraceIDHigh returns the high 64 bits of 128-bit trace IDs from
+// ddtrace.SpanContext, and 0 for 64-bit trace IDs. TraceID returns the low bits.
+func TraceIDHigh(ctx ddtrace.SpanContext) uint64 {
+	if c, ok := ctx.(*spanContext); ok {
+		return c.traceIDHigh
+	}
+	return 0
+}
+
 // SpanID returns the span ID from ddtrace.SpanContext
 func SpanID(ctx ddtrace.SpanContext) uint64 {
 	if c, ok := ctx.(*spanContext); ok {
@@ -139,6 +184,38 @@ func TraceID(ctx ddtrace.SpanContext) uint64 {
 	return 0
 }
 
+// SamplingPriority returns the sampling priority of the trace of the given
+// ddtrace.SpanContext. The second return value is false if no sampling decision
+// was made yet or the context is foreign.
+func SamplingPriority(ctx ddtrace.SpanContext) (int, bool) {
+	if c, ok := ctx.(*spanContext); ok && c.hasSamplingPriority() {
+		return c.samplingPriority(), true
+	}
+	return 0, false
+}
+
+// SpanServiceName returns the service name of the span which owns the given
+// ddtrace.SpanContext. It returns the empty string for remote or foreign contexts.
+func SpanServiceName(ctx ddtrace.SpanContext) string {
+	if c, ok := ctx.(*spanContext); ok && c.span != nil {
+		c.span.RLock()
+		defer c.span.RUnlock()
+		return c.span.Service
+	}
+	return ""
+}
+
+// SpanTag returns the value of the string tag key set on the span which owns
+// the given ddtrace.SpanContext, or the empty string if it is not set.
+func SpanTag(ctx ddtrace.SpanContext, key string) string {
+	if c, ok := ctx.(*spanContext); ok && c.span != nil {
+		c.span.RLock()
+		defer c.span.RUnlock()
+		return c.span.Meta[key]
+	}
+	return ""
+}
+
 const (
 	// payloadQueueSize is the buffer size of the trace channel.
 	payloadQueueSize = 1000
@@ -159,6 +236,16 @@ func newTracer(opts ...StartOption) *tracer {
 	if c.propagator == nil {
 		c.propagator = NewPropagator(nil)
 	}
+	if p, ok := c.payload.(*zipkinPayload); ok {
+		p.appServiceName = c.zipkinAppServiceName
+		if c.zipkinMetricTags != nil {
+			p.metricTags = *c.zipkinMetricTags
+		}
+	}
+	needsMetricsTransport := c.runtimeMetrics || (c.spanMetrics && c.spanMetricsSink == nil)
+	if needsMetricsTransport && c.metricsTransport == nil {
+		c.metricsTransport = newMetricsTransport(c.metricsURL, c.metricsAccessToken, c.httpRoundTripper)
+	}
 	t := &tracer{
 		config:           c,
 		payload:          c.payload,
@@ -172,8 +259,25 @@ func newTracer(opts ...StartOption) *tracer {
 		prioritySampling: newPrioritySampler(),
 		pid:              strconv.Itoa(os.Getpid()),
 	}
+	t.dynamic.Store((&Settings{
+		Sampler:                c.sampler,
+		GlobalTags:             c.globalTags,
+		Debug:                  c.debug,
+		RecordedValueMaxLength: c.recordedValueMaxLength,
+	}).clone())
+	if c.spanMetrics {
+		if c.spanMetricsSink == nil {
+			c.spanMetricsSink = &sfxSpanMetricsSink{transport: c.metricsTransport, dims: c.metricDimensions()}
+		}
+		t.spanMetrics = newSpanMetricsAggregator(c.serviceName, c.spanMetricsMaxKeys)
+		t.droppedQueue = make(chan []*span, payloadQueueSize)
+	}
 
 	go t.worker()
+	if c.runtimeMetrics {
+		t.wg.Add(1)
+		go t.reportRuntimeMetrics()
+	}
 
 	return t
 }
@@ -184,15 +288,30 @@ func (t *tracer) worker() {
 	defer close(t.stopped)
 	ticker := time.NewTicker(flushInterval)
 	defer ticker.Stop()
+	var spanMetricsTick <-chan time.Time
+	if t.spanMetrics != nil {
+		spanMetricsTicker := time.NewTicker(t.config.spanMetricsInterval)
+		defer spanMetricsTicker.Stop()
+		spanMetricsTick = spanMetricsTicker.C
+	}
 
 	for {
 		select {
 		case trace := <-t.payloadQueue:
+			if t.spanMetrics != nil {
+				t.spanMetrics.add(trace)
+			}
 			t.pushPayload(trace)
 
+		case trace := <-t.droppedQueue:
+			t.spanMetrics.add(trace)
+
 		case <-ticker.C:
 			t.flush()
 
+		case <-spanMetricsTick:
+			t.flushSpanMetrics()
+
 		case done := <-t.flushAllReq:
 			t.flush()
 			done <- struct{}{}
@@ -204,6 +323,10 @@ func (t *tracer) worker() {
 			t.flushErrors()
 
 		case <-t.exitReq:
+			if t.spanMetrics != nil {
+				t.drainDroppedQueue()
+				t.flushSpanMetrics()
+			}
 			t.flush()
 			return
 		}
@@ -254,8 +377,9 @@ func (t *tracer) pushError(err error) {
 
 // StartSpan creates, starts, and returns a new Span with the given `operationName`.
 func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOption) ddtrace.Span {
+	settings := t.settings()
 	var opts ddtrace.StartSpanConfig
-	opts.RecordedValueMaxLength = t.recordedValueMaxLength
+	opts.RecordedValueMaxLength = settings.RecordedValueMaxLength
 	for _, fn := range options {
 		fn(&opts)
 	}
@@ -271,9 +395,15 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 			context = ctx
 		}
 	}
-	id := opts.SpanID
-	if id == 0 {
-		id = random.Uint64()
+	// the root span of a trace has the same ID as the low bits of the trace
+	var traceIDHigh, id uint64
+	if context == nil {
+		traceIDHigh, id = t.config.idGenerator.NewTraceID()
+	}
+	if opts.SpanID != 0 {
+		id = opts.SpanID
+	} else if context != nil {
+		id = t.config.idGenerator.NewSpanID()
 	}
 	// span defaults
 	span := &span{
@@ -286,11 +416,19 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 		TraceID:                id,
 		ParentID:               0,
 		Start:                  startTime,
+		traceIDHigh:            traceIDHigh,
 		recordedValueMaxLength: opts.RecordedValueMaxLength,
+		redactions:             t.config.redactions,
+		limits:                 t.config.limits,
+		tracer:                 t,
+	}
+	if context == nil && opts.TraceID != 0 {
+		span.TraceID, span.traceIDHigh = opts.TraceID, opts.TraceIDHigh
 	}
 	if context != nil {
 		// this is a child span
 		span.TraceID = context.traceID
+		span.traceIDHigh = context.traceIDHigh
 		span.ParentID = context.spanID
 		if context.hasSamplingPriority() {
 			span.Metrics[keySamplingPriority] = float64(context.samplingPriority())
@@ -320,17 +458,23 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 			span.SetTag(ext.SFXTracingVersion, sfxtracing.Version)
 		}
 	}
+	if context != nil && opts.FollowsFrom {
+		span.SetTag(ext.FollowsFrom, true)
+	}
+	if links := spanLinks(opts.Links); links != "" {
+		span.SetTag(ext.SpanLinks, links)
+	}
 	// add tags from options
 	for k, v := range opts.Tags {
 		span.SetTag(k, v)
 	}
 	// add global tags
-	for k, v := range t.config.globalTags {
+	for k, v := range settings.GlobalTags {
 		span.SetTag(k, v)
 	}
 	if context == nil {
 		// this is a brand new trace, sample it
-		t.sample(span)
+		t.sample(span, settings.Sampler)
 	}
 	return span
 }
@@ -343,17 +487,40 @@ func (t *tracer) Stop() {
 	default:
 		t.exitReq <- struct{}{}
 		<-t.stopped
+		t.wg.Wait()
 	}
 }
 
-// Inject uses the configured or default TextMap Propagator.
+// Inject uses the configured or default TextMap Propagator. The span context is
+// written in a compact binary encoding to io.Writer carriers which do not
+// implement TextMapWriter.
 func (t *tracer) Inject(ctx ddtrace.SpanContext, carrier interface{}) error {
+	if _, ok := carrier.(TextMapWriter); !ok {
+		if w, ok := carrier.(io.Writer); ok {
+			return injectBinary(ctx, w)
+		}
+	}
 	return t.config.propagator.Inject(ctx, carrier)
 }
 
-// Extract uses the configured or default TextMap Propagator.
+// Extract uses the configured or default TextMap Propagator. The span context is
+// read in the binary encoding used by Inject from io.Reader carriers which do
+// not implement TextMapReader.
 func (t *tracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
-	return t.config.propagator.Extract(carrier)
+	var (
+		ctx ddtrace.SpanContext
+		err error
+	)
+	_, textMap := carrier.(TextMapReader)
+	if r, ok := carrier.(io.Reader); ok && !textMap {
+		ctx, err = extractBinary(r)
+	} else {
+		ctx, err = t.config.propagator.Extract(carrier)
+	}
+	if c, ok := ctx.(*spanContext); ok && t.config.limits != nil {
+		c.applyLimits(t.config.limits)
+	}
+	return ctx, err
 }
 
 // flushTraces will push any currently buffered traces to the server.
@@ -362,7 +529,7 @@ func (t *tracer) flushTraces() {
 		return
 	}
 	size, count := t.payload.size(), t.payload.itemCount()
-	if t.config.debug {
+	if t.settings().Debug {
 		log.Printf("Sending payload: size: %d traces: %d\n", size, count)
 	}
 	rc, err := t.config.transport.send(t.payload)
@@ -418,13 +585,12 @@ func (t *tracer) pushPayload(trace []*span) {
 // sampleRateMetricKey is the metric key holding the applied sample rate. Has to be the same as the Agent.
 const sampleRateMetricKey = "_sample_rate"
 
-// Sample samples a span with the internal sampler.
-func (t *tracer) sample(span *span) {
+// Sample samples a span with the given sampler.
+func (t *tracer) sample(span *span, sampler Sampler) {
 	if span.context.hasSamplingPriority() {
 		// sampling decision was already made
 		return
 	}
-	sampler := t.config.sampler
 	if !sampler.Sample(span) {
 		span.context.drop = true
 		return
@@ -16,6 +16,7 @@ import (
 
 	"github.com/signalfx/signalfx-go-tracing/ddtrace"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/opentracer"
 	"github.com/stretchr/testify/assert"
 	"github.com/tinylib/msgp/msgp"
 )
@@ -151,6 +152,47 @@ func TestTracerStart(t *testing.T) {
 	})
 }
 
+func TestTracerNew(t *testing.T) {
+	assert := assert.New(t)
+	global, globalTransport, stop := startTestTracer(WithServiceName("global"))
+	defer stop()
+
+	transport := newDummyTransport()
+	tr := New(WithServiceName("library"), withTransport(transport))
+	defer tr.Stop()
+	tr.(*tracer).syncPush = make(chan struct{})
+	assert.Equal(global, ddtrace.GetGlobalTracer())
+
+	root := tr.StartSpan("library.op")
+	child := StartSpan("global.child", ChildOf(root.Context()))
+	assert.Equal(opentracer.Wrap(tr), root.Tracer())
+	assert.Equal(opentracer.Wrap(global), child.Tracer())
+	child.Finish()
+	root.Finish()
+	global.StartSpan("global.op").Finish()
+
+	tr.ForceFlush()
+	global.ForceFlush()
+	traces := transport.Traces()
+	if assert.Len(traces, 1) && assert.Len(traces[0], 2) {
+		assert.Equal("library", traces[0][0].Service)
+		assert.Equal("global.child", traces[0][1].Name)
+	}
+	traces = globalTransport.Traces()
+	if assert.Len(traces, 1) && assert.Len(traces[0], 1) {
+		assert.Equal("global.op", traces[0][0].Name)
+	}
+
+	t.Run("stopped", func(t *testing.T) {
+		tr := New(withTransport(newDummyTransport()))
+		span := tr.StartSpan("op")
+		tr.Stop()
+		span.Finish()
+		global.ForceFlush()
+		assert.Empty(globalTransport.Traces(), "traces of stopped tracers are not sent by the global one")
+	})
+}
+
 func TestTracerStartSpan(t *testing.T) {
 	t.Run("generic", func(t *testing.T) {
 		tracer := newTracer()
@@ -1180,3 +1222,29 @@ func TestSpanAndTraceID(t *testing.T) {
 	assert.Equal(t, TraceID(c3), uint64(0))
 
 }
+
+func TestSpanServiceNameAndTag(t *testing.T) {
+	tracer := newTracer(withTransport(newDefaultTransport()), WithServiceName("svc"))
+	defer tracer.Stop()
+	span := tracer.StartSpan("op", Tag(ext.Environment, "prod"))
+	assert.Equal(t, "svc", SpanServiceName(span.Context()))
+	assert.Equal(t, "prod", SpanTag(span.Context(), ext.Environment))
+	assert.Equal(t, "", SpanTag(span.Context(), "missing"))
+
+	assert.Equal(t, "", SpanServiceName(&spanContext{spanID: 1, traceID: 2}))
+	assert.Equal(t, "", SpanTag(&externalSpanContext{}, ext.Environment))
+}
+
+func TestSamplingPriority(t *testing.T) {
+	tracer := newTracer(withTransport(newDefaultTransport()))
+	defer tracer.Stop()
+	span := tracer.StartSpan("op", Tag(ext.SamplingPriority, ext.PriorityUserKeep))
+	p, ok := SamplingPriority(span.Context())
+	assert.True(t, ok)
+	assert.Equal(t, ext.PriorityUserKeep, p)
+
+	_, ok = SamplingPriority(&spanContext{spanID: 1, traceID: 2})
+	assert.False(t, ok)
+	_, ok = SamplingPriority(&externalSpanContext{})
+	assert.False(t, ok)
+}
@@ -57,3 +57,25 @@ func toHex(value uint64) string {
 	binary.BigEndian.PutUint64(b, value)
 	return hex.EncodeToString(b)
 }
+
+// traceIDToHex returns the trace ID made of the high and low bits as 32 hex
+// digits, or 16 if high is 0.
+func traceIDToHex(high, low uint64) string {
+	if high == 0 {
+		return toHex(low)
+	}
+	return toHex(high) + toHex(low)
+}
+
+// parseTraceIDHex parses a trace ID of up to 32 hex digits into its high and
+// low 64 bits.
+func parseTraceIDHex(s string) (high, low uint64, err error) {
+	if len(s) > 16 {
+		if high, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return 0, 0, err
+		}
+		s = s[len(s)-16:]
+	}
+	low, err = strconv.ParseUint(s, 16, 64)
+	return high, low, err
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,21 +17,17 @@ import (
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 )
 
-const (
-	spanKind         = "span.kind"
-	spanKindServer   = "SERVER"
-	spanKindClient   = "CLIENT"
-	spanKindProducer = "PRODUCER"
-	spanKindConsumer = "CONSUMER"
-)
-
 var _ encoder = (*zipkinPayload)(nil)
 
 type zipkinPayload struct {
-	service   string
-	buf       bytes.Buffer
-	spanCount int
-	reader    *bytes.Reader
+	service string
+	// appServiceName, when true, reports service as the local service of all
+	// spans rather than their own service name.
+	appServiceName bool
+	metricTags     metricTagFilter
+	buf            bytes.Buffer
+	spanCount      int
+	reader         *bytes.Reader
 }
 
 func (p *zipkinPayload) Read(b []byte) (n int, err error) {
@@ -76,6 +74,33 @@ func idToHexPtr(id uint64) *string {
 	return pointer.String(idToHex(id))
 }
 
+// defaultMetricTagDeny holds the key prefixes of internal span metrics, which
+// are not exported as Zipkin tags unless allowed explicitly.
+var defaultMetricTagDeny = []string{"_dd", "_sampling", "_sample_rate"}
+
+// metricTagFilter selects the span metrics exported as Zipkin tags.
+type metricTagFilter struct {
+	allow []string // key prefixes always exported
+	deny  []string // key prefixes not exported, in addition to the defaults
+}
+
+// exports reports whether the metric with the given key is exported.
+func (f *metricTagFilter) exports(key string) bool {
+	if hasAnyPrefix(key, f.allow) {
+		return true
+	}
+	return !hasAnyPrefix(key, defaultMetricTagDeny) && !hasAnyPrefix(key, f.deny)
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
 func formatTags(tags map[string]string) {
 	hostname := tags[ext.TargetHost]
 	if hostname != "" {
@@ -99,27 +124,32 @@ func (p *zipkinPayload) convertSpans(spans spanList) []*traceformat.Span {
 
 	for _, span := range spans {
 		sfxSpan := traceformat.Span{}
-		localEndpoint := &sfxtrace.Endpoint{ServiceName: pointer.String(p.service)}
 		tags := map[string]string{}
 
+		for key, val := range span.Metrics {
+			if p.metricTags.exports(key) {
+				tags[key] = strconv.FormatFloat(val, 'f', -1, 64)
+			}
+		}
+		// string tags take precedence over metrics with the same key
 		for key, val := range span.Meta {
-			if key == spanKind {
+			if key == ext.SpanKind {
 				continue
 			}
 			tags[key] = val
 		}
 
-		sfxSpan.TraceID = idToHex(span.TraceID)
+		sfxSpan.TraceID = traceIDToHex(span.traceIDHigh, span.TraceID)
 		sfxSpan.Name = pointer.String(span.Name)
 		sfxSpan.ParentID = idToHexPtr(span.ParentID)
 		sfxSpan.ID = idToHex(span.SpanID)
 		sfxSpan.Kind = deriveKind(span)
-		sfxSpan.LocalEndpoint = localEndpoint
+		sfxSpan.LocalEndpoint = &sfxtrace.Endpoint{ServiceName: pointer.String(p.localService(span, sfxSpan.Kind, tags))}
 		sfxSpan.Timestamp = pointer.Int64(span.Start / 1000)
 		sfxSpan.Duration = pointer.Int64(span.Duration / 1000)
 		sfxSpan.Annotations = convertLogs(span.Logs)
 
-		if span.Resource != "" && sfxSpan.Kind != nil && *sfxSpan.Kind == spanKindServer {
+		if span.Resource != "" && sfxSpan.Kind != nil && *sfxSpan.Kind == ext.SpanKindServer {
 			sfxSpan.Name = pointer.String(span.Resource)
 		}
 
@@ -128,6 +158,7 @@ func (p *zipkinPayload) convertSpans(spans spanList) []*traceformat.Span {
 		}
 
 		formatTags(tags)
+		sfxSpan.RemoteEndpoint = remoteEndpoint(tags)
 		sfxSpan.Tags = tags
 
 		sfxSpans = append(sfxSpans, &sfxSpan)
@@ -136,6 +167,63 @@ func (p *zipkinPayload) convertSpans(spans spanList) []*traceformat.Span {
 	return sfxSpans
 }
 
+// localService returns the local service name of span, of the given kind. When
+// the service name of the application is kept, the service name of client and
+// producer spans set by integrations, such as "redis", is added to tags as the
+// peer service instead, unless already set.
+func (p *zipkinPayload) localService(span *span, kind *string, tags map[string]string) string {
+	if span.Service == "" || span.Service == p.service {
+		return p.service
+	}
+	if !p.appServiceName {
+		return span.Service
+	}
+	if kind != nil && (*kind == ext.SpanKindClient || *kind == ext.SpanKindProducer) {
+		if tags[ext.PeerService] == "" {
+			tags[ext.PeerService] = span.Service
+		}
+	}
+	return p.service
+}
+
+// remoteEndpoint returns the endpoint of the peer described by the peer tags,
+// formatted by formatTags, or nil if there are none. The host name stands for
+// the service name if the peer service is unknown.
+func remoteEndpoint(tags map[string]string) *sfxtrace.Endpoint {
+	var e sfxtrace.Endpoint
+	found := false
+	if v := tags[ext.PeerService]; v != "" {
+		e.ServiceName, found = pointer.String(v), true
+	}
+	if v := tags[ext.PeerHostIPV4]; v != "" {
+		e.Ipv4, found = pointer.String(v), true
+	}
+	if v := tags[ext.PeerHostIPV6]; v != "" {
+		e.Ipv6, found = pointer.String(v), true
+	}
+	if host := tags[ext.PeerHostname]; host != "" {
+		found = true
+		if ip := net.ParseIP(host); ip == nil {
+			if e.ServiceName == nil {
+				e.ServiceName = pointer.String(host)
+			}
+		} else if ip.To4() != nil {
+			if e.Ipv4 == nil {
+				e.Ipv4 = pointer.String(host)
+			}
+		} else if e.Ipv6 == nil {
+			e.Ipv6 = pointer.String(host)
+		}
+	}
+	if port, err := strconv.ParseUint(tags[ext.PeerPort], 10, 16); err == nil {
+		e.Port, found = pointer.Int32(int32(port)), true
+	}
+	if !found {
+		return nil
+	}
+	return &e
+}
+
 // convertLogs to annotations
 func convertLogs(logs []*logFields) []*sfxtrace.Annotation {
 	var annotations []*sfxtrace.Annotation
@@ -155,27 +243,15 @@ func convertLogs(logs []*logFields) []*sfxtrace.Annotation {
 	return annotations
 }
 
+// deriveKind returns the Zipkin kind of s, or nil for internal spans and spans
+// of unknown kind.
 func deriveKind(s *span) *string {
-	if kind, ok := s.Meta[spanKind]; ok {
-		return pointer.String(strings.ToUpper(kind))
-	}
-
-	switch s.Type {
-	case ext.SpanTypeHTTP:
-		return pointer.String(spanKindClient)
-	case ext.SpanTypeWeb:
-		return pointer.String(spanKindServer)
-	case ext.SpanTypeMongoDB:
-		return pointer.String(spanKindClient)
-	case ext.SpanTypeSQL:
-		return pointer.String(spanKindClient)
-	case ext.SpanTypeMessageConsumer:
-		return pointer.String(spanKindConsumer)
-	case ext.SpanTypeMessageProducer:
-		return pointer.String(spanKindProducer)
+	switch kind := spanKindOf(s); kind {
+	case "", ext.SpanKindInternal:
+		return nil
+	default:
+		return pointer.String(kind)
 	}
-
-	return nil
 }
 
 func (p *zipkinPayload) itemCount() int {
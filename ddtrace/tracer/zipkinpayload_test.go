@@ -8,10 +8,13 @@ import (
 	"testing"
 
 	"github.com/mailru/easyjson"
+	"github.com/signalfx/golib/pointer"
 	sfxtrace "github.com/signalfx/golib/trace"
 	traceformat "github.com/signalfx/golib/trace/format"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
 )
 
 func encodeZipkin(traces [][]*span) (*zipkinPayload, error) {
@@ -145,3 +148,110 @@ func benchmarkZipkinPayloadThroughput(count int) func(*testing.B) {
 		}
 	}
 }
+
+func TestZipkinMetricTags(t *testing.T) {
+	newSpan := func() *span {
+		return &span{
+			Meta: map[string]string{"rows": "overridden"},
+			Metrics: map[string]float64{
+				"partition":           3,
+				"ratio":               0.25,
+				"rows":                12,
+				keySamplingPriority:   1,
+				sampleRateMetricKey:   0.5,
+				ext.EventSampleRate:   1,
+				"_dd.measured":        1,
+				"kafka.offset.latest": 1e6,
+			},
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		p := newZipkinPayload("test-service")
+		tags := p.convertSpans(spanList{newSpan()})[0].Tags
+		require.Equal(t, map[string]string{
+			"partition":           "3",
+			"ratio":               "0.25",
+			"rows":                "overridden",
+			"kafka.offset.latest": "1000000",
+		}, tags)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		tracer := newTracer(
+			WithZipkinMetricTags([]string{keySamplingPriority}, []string{"kafka."}),
+			WithZipkin("test-service", "http://localhost:9080/v1/trace", ""),
+		)
+		defer tracer.Stop()
+		p := tracer.payload.(*zipkinPayload)
+		tags := p.convertSpans(spanList{newSpan()})[0].Tags
+		require.Equal(t, map[string]string{
+			"partition":         "3",
+			"ratio":             "0.25",
+			"rows":              "overridden",
+			keySamplingPriority: "1",
+		}, tags)
+	})
+}
+
+func TestZipkinEndpoints(t *testing.T) {
+	spans := func() spanList {
+		return spanList{
+			&span{Service: "test-service", Meta: map[string]string{"span.kind": "server"}},
+			&span{Service: "redis", Type: ext.SpanTypeRedis, Meta: map[string]string{
+				"span.kind":    "client",
+				ext.TargetHost: "cache.local",
+				ext.TargetPort: "6379",
+			}},
+			&span{Service: "http.router", Meta: map[string]string{"span.kind": "server", ext.PeerHostIPV4: "10.0.0.1"}},
+			&span{Service: "aws.s3", Meta: map[string]string{
+				"span.kind":      "client",
+				ext.PeerService:  "s3",
+				ext.PeerHostname: "10.0.0.2",
+				ext.PeerPort:     "443",
+			}},
+			&span{},
+			&span{Service: "worker"},
+		}
+	}
+
+	t.Run("span", func(t *testing.T) {
+		converted := newZipkinPayload("test-service").convertSpans(spans())
+		require.Equal(t, "test-service", *converted[0].LocalEndpoint.ServiceName)
+		require.Nil(t, converted[0].RemoteEndpoint)
+
+		require.Equal(t, "redis", *converted[1].LocalEndpoint.ServiceName)
+		require.Equal(t, &sfxtrace.Endpoint{
+			ServiceName: pointer.String("cache.local"),
+			Port:        pointer.Int32(6379),
+		}, converted[1].RemoteEndpoint)
+		require.NotContains(t, converted[1].Tags, ext.PeerService)
+
+		require.Equal(t, "http.router", *converted[2].LocalEndpoint.ServiceName)
+		require.Equal(t, &sfxtrace.Endpoint{Ipv4: pointer.String("10.0.0.1")}, converted[2].RemoteEndpoint)
+
+		require.Equal(t, &sfxtrace.Endpoint{
+			ServiceName: pointer.String("s3"),
+			Ipv4:        pointer.String("10.0.0.2"),
+			Port:        pointer.Int32(443),
+		}, converted[3].RemoteEndpoint)
+
+		require.Equal(t, "test-service", *converted[4].LocalEndpoint.ServiceName)
+	})
+
+	t.Run("app", func(t *testing.T) {
+		tracer := newTracer(WithZipkin("test-service", "http://localhost:9080/v1/trace", ""), WithZipkinAppServiceName())
+		defer tracer.Stop()
+		converted := tracer.payload.(*zipkinPayload).convertSpans(spans())
+		for _, s := range converted {
+			require.Equal(t, "test-service", *s.LocalEndpoint.ServiceName)
+		}
+		require.Equal(t, "redis", converted[1].Tags[ext.PeerService])
+		require.Equal(t, "redis", *converted[1].RemoteEndpoint.ServiceName)
+		require.NotContains(t, converted[2].Tags, ext.PeerService)
+		require.Equal(t, "s3", converted[3].Tags[ext.PeerService])
+		// spans of unknown kind are not assumed to call another service
+		require.NotContains(t, converted[5].Tags, ext.PeerService)
+		require.Nil(t, converted[5].RemoteEndpoint)
+	})
+}
@@ -9,6 +9,7 @@ var cfg = &config{}
 type config struct {
 	mu            sync.RWMutex
 	analyticsRate float64
+	integrations  map[string]map[string]string
 }
 
 // AnalyticsRate returns the sampling rate at which events should be marked. It uses
@@ -26,3 +27,20 @@ func SetAnalyticsRate(rate float64) {
 	cfg.analyticsRate = rate
 	cfg.mu.Unlock()
 }
+
+// SetIntegrationSettings replaces the settings of all integrations. Settings are
+// keyed by integration name, such as "net/http", and then by setting name.
+func SetIntegrationSettings(settings map[string]map[string]string) {
+	cfg.mu.Lock()
+	cfg.integrations = settings
+	cfg.mu.Unlock()
+}
+
+// IntegrationSetting returns the value of the given setting of an integration,
+// and whether it was set.
+func IntegrationSetting(integration, key string) (string, bool) {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	v, ok := cfg.integrations[integration][key]
+	return v, ok
+}
@@ -0,0 +1,371 @@
+package tracing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+// signalfxTracingConfig is the environment variable holding the path of the
+// configuration file read by Start.
+const signalfxTracingConfig = "SIGNALFX_TRACING_CONFIG"
+
+// integrationSettings lists, by integration name, the settings read by the
+// integrations from the "integrations" section of the configuration file. Other
+// integrations are configured with their options only.
+var integrationSettings = map[string][]string{
+	"net/http": {"service_name", "analytics_rate"},
+}
+
+// ConfigError is returned when a configuration file can not be loaded.
+type ConfigError struct {
+	// Path is the path of the configuration file.
+	Path string
+
+	// Field is the invalid field, such as "sampler.rules[1].rate". It is empty
+	// when the file can not be read or parsed.
+	Field string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("config file %s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("config file %s: invalid %s: %v", e.Path, e.Field, e.Err)
+}
+
+// fileConfig holds the content of a configuration file. JSON documents are
+// valid YAML, so both formats are decoded the same way. Pointer fields are nil
+// when the field is absent from the file.
+type fileConfig struct {
+	ServiceName            string                            `yaml:"service_name"`
+	EndpointURL            string                            `yaml:"endpoint_url"`
+	AccessToken            string                            `yaml:"access_token"`
+	GlobalTags             map[string]string                 `yaml:"global_tags"`
+	RecordedValueMaxLength *int                              `yaml:"recorded_value_max_length"`
+	Debug                  *bool                             `yaml:"debug"`
+	Exporter               string                            `yaml:"exporter"`
+	Console                *fileConsoleConfig                `yaml:"console"`
+	Propagators            []string                          `yaml:"propagators"`
+	Sampler                *fileSamplerConfig                `yaml:"sampler"`
+	Redaction              []fileRedactionRule               `yaml:"redaction"`
+	Metrics                *fileMetricsConfig                `yaml:"metrics"`
+	Limits                 *fileLimitsConfig                 `yaml:"limits"`
+	Integrations           map[string]map[string]interface{} `yaml:"integrations"`
+}
+
+type fileSamplerConfig struct {
+	Rate  *float64           `yaml:"rate" json:"rate,omitempty"`
+	Rules []fileSamplingRule `yaml:"rules" json:"rules,omitempty"`
+}
+
+type fileSamplingRule struct {
+	Service   string  `yaml:"service" json:"service,omitempty"`
+	Operation string  `yaml:"operation" json:"operation,omitempty"`
+	Rate      float64 `yaml:"rate" json:"rate"`
+}
+
+type fileRedactionRule struct {
+	Tag         string `yaml:"tag"`
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+}
+
+type fileConsoleConfig struct {
+	Format string `yaml:"format"`
+	File   string `yaml:"file"`
+}
+
+type fileMetricsConfig struct {
+	EndpointURL     string `yaml:"endpoint_url"`
+	Runtime         *bool  `yaml:"runtime"`
+	RuntimeInterval string `yaml:"runtime_interval"`
+	Spans           *bool  `yaml:"spans"`
+}
+
+type fileLimitsConfig struct {
+	MaxTags          int `yaml:"max_tags"`
+	MaxLogs          int `yaml:"max_logs"`
+	MaxLogFields     int `yaml:"max_log_fields"`
+	MaxSpansPerTrace int `yaml:"max_spans_per_trace"`
+	MaxBaggageItems  int `yaml:"max_baggage_items"`
+	MaxBaggageBytes  int `yaml:"max_baggage_bytes"`
+}
+
+// ValidateConfigFile reads and validates the configuration file at path, as
+// Start does when the SIGNALFX_TRACING_CONFIG environment variable is set. The
+// returned error, if any, is a *ConfigError.
+func ValidateConfigFile(path string) error {
+	_, err := loadConfigFile(path)
+	return err
+}
+
+// loadConfigFile reads, decodes and validates the configuration file at path.
+func loadConfigFile(path string) (*fileConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	var fc fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && err != io.EOF {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if field, err := fc.validate(); err != nil {
+		return nil, &ConfigError{Path: path, Field: field, Err: err}
+	}
+	return &fc, nil
+}
+
+// validate checks the values of the configuration, returning the name of the
+// first invalid field along with the reason.
+func (fc *fileConfig) validate() (string, error) {
+	if err := validateURL(fc.EndpointURL); err != nil {
+		return "endpoint_url", err
+	}
+	for k := range fc.GlobalTags {
+		if strings.TrimSpace(k) == "" {
+			return "global_tags", fmt.Errorf("empty tag key")
+		}
+	}
+	if l := fc.RecordedValueMaxLength; l != nil && *l < -1 {
+		return "recorded_value_max_length", fmt.Errorf("%d is lower than -1", *l)
+	}
+	switch fc.Exporter {
+	case "", exporterZipkin, exporterConsole:
+	default:
+		return "exporter", fmt.Errorf("unknown exporter %q", fc.Exporter)
+	}
+	if fc.Console != nil && fc.Console.Format != "" {
+		if _, err := tracer.ParseConsoleFormat(fc.Console.Format); err != nil {
+			return "console.format", err
+		}
+	}
+	for i, p := range fc.Propagators {
+		switch strings.ToLower(strings.TrimSpace(p)) {
+		case "b3", "datadog":
+		default:
+			return fmt.Sprintf("propagators[%d]", i), fmt.Errorf("unknown propagator %q", p)
+		}
+	}
+	if s := fc.Sampler; s != nil {
+		if s.Rate != nil {
+			if err := validateRate(*s.Rate); err != nil {
+				return "sampler.rate", err
+			}
+		}
+		for i, r := range s.Rules {
+			if err := validateRate(r.Rate); err != nil {
+				return fmt.Sprintf("sampler.rules[%d].rate", i), err
+			}
+		}
+	}
+	for i, r := range fc.Redaction {
+		if r.Tag == "" {
+			return fmt.Sprintf("redaction[%d].tag", i), fmt.Errorf("missing tag")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Sprintf("redaction[%d].pattern", i), err
+		}
+	}
+	if m := fc.Metrics; m != nil {
+		if err := validateURL(m.EndpointURL); err != nil {
+			return "metrics.endpoint_url", err
+		}
+		if m.RuntimeInterval != "" {
+			d, err := time.ParseDuration(m.RuntimeInterval)
+			if err != nil {
+				return "metrics.runtime_interval", err
+			}
+			if d <= 0 {
+				return "metrics.runtime_interval", fmt.Errorf("%s is not positive", d)
+			}
+		}
+	}
+	if l := fc.Limits; l != nil {
+		// in a fixed order, so that the same field is reported every time
+		for _, f := range []struct {
+			field string
+			value int
+		}{
+			{"limits.max_baggage_bytes", l.MaxBaggageBytes},
+			{"limits.max_baggage_items", l.MaxBaggageItems},
+			{"limits.max_log_fields", l.MaxLogFields},
+			{"limits.max_logs", l.MaxLogs},
+			{"limits.max_spans_per_trace", l.MaxSpansPerTrace},
+			{"limits.max_tags", l.MaxTags},
+		} {
+			if f.value < 0 {
+				return f.field, fmt.Errorf("%d is negative", f.value)
+			}
+		}
+	}
+	names := make([]string, 0, len(fc.Integrations))
+	for name := range fc.Integrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		known, ok := integrationSettings[name]
+		if !ok {
+			return "integrations", fmt.Errorf("unknown integration %q, supported integrations are %s",
+				name, strings.Join(supportedIntegrations(), ", "))
+		}
+		keys := make([]string, 0, len(fc.Integrations[name]))
+		for k := range fc.Integrations[name] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !containsString(known, k) {
+				return "integrations." + name, fmt.Errorf("unknown setting %q, supported settings are %s",
+					k, strings.Join(known, ", "))
+			}
+		}
+	}
+	return "", nil
+}
+
+// supportedIntegrations returns the sorted names of the integrations which
+// read settings from the configuration file.
+func supportedIntegrations() []string {
+	names := make([]string, 0, len(integrationSettings))
+	for name := range integrationSettings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func validateURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute URL", s)
+	}
+	return nil
+}
+
+func validateRate(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("%g is not between 0 and 1", rate)
+	}
+	return nil
+}
+
+// apply sets the values found in the file on c. The file must be valid.
+func (fc *fileConfig) apply(c *config) {
+	if fc.ServiceName != "" {
+		c.serviceName = fc.ServiceName
+	}
+	if fc.EndpointURL != "" {
+		c.url = fc.EndpointURL
+	}
+	if fc.AccessToken != "" {
+		c.accessToken = fc.AccessToken
+	}
+	for k, v := range fc.GlobalTags {
+		c.setGlobalTag(strings.TrimSpace(k), v)
+	}
+	if fc.RecordedValueMaxLength != nil {
+		l := *fc.RecordedValueMaxLength
+		c.recordedValueMaxLength = &l
+	}
+	if fc.Debug != nil {
+		c.debug = *fc.Debug
+	}
+	if fc.Exporter != "" {
+		c.exporter = fc.Exporter
+	}
+	if fc.Console != nil {
+		if fc.Console.Format != "" {
+			c.consoleFormat, _ = tracer.ParseConsoleFormat(fc.Console.Format)
+		}
+		if fc.Console.File != "" {
+			c.consoleFile = fc.Console.File
+		}
+	}
+	if len(fc.Propagators) > 0 {
+		c.propagators = fc.Propagators
+	}
+	if s := fc.Sampler; s != nil {
+		rate := 1.0
+		if s.Rate != nil {
+			rate = *s.Rate
+		}
+		rules := make([]tracer.SamplingRule, len(s.Rules))
+		for i, r := range s.Rules {
+			rules[i] = tracer.SamplingRule{Service: r.Service, Operation: r.Operation, Rate: r.Rate}
+		}
+		c.sampler = &samplerConfig{rate: rate, rules: rules}
+	}
+	for _, r := range fc.Redaction {
+		c.redactions = append(c.redactions, redaction{
+			tag:         r.Tag,
+			pattern:     regexp.MustCompile(r.Pattern),
+			replacement: r.Replacement,
+		})
+	}
+	if m := fc.Metrics; m != nil {
+		if m.EndpointURL != "" {
+			c.metricsURL = m.EndpointURL
+		}
+		if m.Runtime != nil {
+			c.runtimeMetrics = *m.Runtime
+		}
+		if m.RuntimeInterval != "" {
+			c.runtimeMetricsInterval, _ = time.ParseDuration(m.RuntimeInterval)
+		}
+		if m.Spans != nil {
+			c.spanMetrics = *m.Spans
+		}
+	}
+	if l := fc.Limits; l != nil {
+		c.limits = &tracer.SpanLimits{
+			MaxTags:          l.MaxTags,
+			MaxLogs:          l.MaxLogs,
+			MaxLogFields:     l.MaxLogFields,
+			MaxSpansPerTrace: l.MaxSpansPerTrace,
+			MaxBaggageItems:  l.MaxBaggageItems,
+			MaxBaggageBytes:  l.MaxBaggageBytes,
+		}
+	}
+	if len(fc.Integrations) > 0 {
+		c.integrations = make(map[string]map[string]string, len(fc.Integrations))
+		for name, settings := range fc.Integrations {
+			m := make(map[string]string, len(settings))
+			for k, v := range settings {
+				m[k] = fmt.Sprint(v)
+			}
+			c.integrations[name] = m
+		}
+	}
+}
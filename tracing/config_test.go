@@ -0,0 +1,298 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/internal/globalconfig"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+// writeConfigFile writes content to a temporary file with the given extension
+// and returns its path. The caller removes the file.
+func writeConfigFile(t *testing.T, ext, content string) string {
+	f, err := ioutil.TempFile("", "tracing-*"+ext)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+const testConfigYAML = `
+service_name: file-service
+endpoint_url: http://collector:9080/v1/trace
+access_token: secret
+global_tags:
+  env: staging
+recorded_value_max_length: 10
+debug: true
+exporter: zipkin
+propagators: [datadog, b3]
+sampler:
+  rate: 0.5
+  rules:
+    - service: file-service
+      operation: health
+      rate: 0
+redaction:
+  - tag: http.url
+    pattern: "token=[^&]*"
+    replacement: "token=?"
+metrics:
+  endpoint_url: http://collector:9080/v2/datapoint
+  runtime: true
+  runtime_interval: 30s
+  spans: true
+limits:
+  max_tags: 64
+  max_spans_per_trace: 1000
+integrations:
+  net/http:
+    service_name: web
+    analytics_rate: 0.5
+`
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		assert := assert.New(t)
+		path := writeConfigFile(t, ".yaml", testConfigYAML)
+		defer os.Remove(path)
+		fc, err := loadConfigFile(path)
+		require.NoError(t, err)
+
+		var c config
+		fc.apply(&c)
+		assert.Equal("file-service", c.serviceName)
+		assert.Equal("http://collector:9080/v1/trace", c.url)
+		assert.Equal("secret", c.accessToken)
+		assert.Len(c.globalTags, 1)
+		assert.Equal(10, *c.recordedValueMaxLength)
+		assert.True(c.debug)
+		assert.Equal([]string{"datadog", "b3"}, c.propagators)
+		assert.Equal(&samplerConfig{
+			rate:  0.5,
+			rules: []tracer.SamplingRule{{Service: "file-service", Operation: "health", Rate: 0}},
+		}, c.sampler)
+		require.Len(t, c.redactions, 1)
+		assert.Equal("http.url", c.redactions[0].tag)
+		assert.Equal("token=?", c.redactions[0].replacement)
+		assert.Equal("http://collector:9080/v2/datapoint", c.metricsURL)
+		assert.True(c.runtimeMetrics)
+		assert.Equal(30*time.Second, c.runtimeMetricsInterval)
+		assert.True(c.spanMetrics)
+		assert.Equal(&tracer.SpanLimits{MaxTags: 64, MaxSpansPerTrace: 1000}, c.limits)
+		assert.Equal(map[string]map[string]string{
+			"net/http": {"service_name": "web", "analytics_rate": "0.5"},
+		}, c.integrations)
+		assert.Contains(c.String(), `access_token="****"`)
+		assert.NotContains(c.String(), "secret")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := writeConfigFile(t, ".json", `{"service_name": "json-service", "sampler": {"rate": 0.25}}`)
+		defer os.Remove(path)
+		fc, err := loadConfigFile(path)
+		require.NoError(t, err)
+
+		var c config
+		fc.apply(&c)
+		assert.Equal(t, "json-service", c.serviceName)
+		assert.Equal(t, 0.25, c.sampler.rate)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for content, field := range map[string]string{
+			"endpoint_url: collector":                        "endpoint_url",
+			"recorded_value_max_length: -2":                  "recorded_value_max_length",
+			"exporter: jaeger":                               "exporter",
+			"propagators: [b3, w3c]":                         "propagators[1]",
+			"sampler: {rate: 2}":                             "sampler.rate",
+			"sampler: {rules: [{rate: 1}, {rate: -1}]}":      "sampler.rules[1].rate",
+			"redaction: [{pattern: x}]":                      "redaction[0].tag",
+			"redaction: [{tag: t, pattern: '('}]":            "redaction[0].pattern",
+			"metrics: {runtime_interval: soon}":              "metrics.runtime_interval",
+			"metrics: {endpoint_url: 'ingest/v2/datapoint'}": "metrics.endpoint_url",
+			"limits: {max_logs: -1}":                         "limits.max_logs",
+			"integrations: {gin: {service_name: web}}":       "integrations",
+			"integrations: {net/http: {service: web}}":       "integrations.net/http",
+		} {
+			path := writeConfigFile(t, ".yaml", content)
+			err := ValidateConfigFile(path)
+			os.Remove(path)
+			require.Error(t, err, content)
+			assert.Equal(t, field, err.(*ConfigError).Field, content)
+			assert.Contains(t, err.Error(), "invalid "+field)
+		}
+	})
+
+	t.Run("invalid-order", func(t *testing.T) {
+		for content, field := range map[string]string{
+			"limits: {max_tags: -1, max_logs: -1, max_baggage_items: -1}": "limits.max_baggage_items",
+			"integrations: {net/http: {service: web}, gin: {}, chi: {}}":  "integrations",
+		} {
+			path := writeConfigFile(t, ".yaml", content)
+			defer os.Remove(path)
+			for i := 0; i < 10; i++ {
+				err := ValidateConfigFile(path)
+				require.Error(t, err, content)
+				assert.Equal(t, field, err.(*ConfigError).Field, content)
+			}
+		}
+		path := writeConfigFile(t, ".yaml", "integrations: {net/http: {service: web}, gin: {}, chi: {}}")
+		defer os.Remove(path)
+		err := ValidateConfigFile(path)
+		assert.Contains(t, err.Error(), `unknown integration "chi", supported integrations are net/http`)
+	})
+
+	t.Run("unknown-field", func(t *testing.T) {
+		path := writeConfigFile(t, ".yaml", "service: x")
+		defer os.Remove(path)
+		err := ValidateConfigFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field service not found")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		err := ValidateConfigFile(filepath.Join(os.TempDir(), "does-not-exist.yaml"))
+		require.Error(t, err)
+		assert.Empty(t, err.(*ConfigError).Field)
+	})
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := writeConfigFile(t, ".yaml", testConfigYAML)
+	defer os.Remove(path)
+	require.NoError(os.Setenv(signalfxTracingConfig, path))
+	defer os.Unsetenv(signalfxTracingConfig)
+	require.NoError(os.Setenv(signalfxEndpointURL, "http://env:9080/v1/trace"))
+	defer os.Unsetenv(signalfxEndpointURL)
+	require.NoError(os.Setenv(signalfxRuntimeMetricsEnabled, "false"))
+	defer os.Unsetenv(signalfxRuntimeMetricsEnabled)
+
+	c := defaultConfig()
+	assert.Equal("file-service", c.serviceName)
+	assert.Equal("http://env:9080/v1/trace", c.url)
+	assert.False(c.runtimeMetrics)
+	assert.True(c.spanMetrics)
+
+	WithEndpointURL("http://code:9080/v1/trace")(c)
+	assert.Equal("http://code:9080/v1/trace", c.url)
+}
+
+func TestStartWithConfigFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+
+	path := writeConfigFile(t, ".yaml", `
+service_name: file-service
+global_tags:
+  env: staging
+redaction:
+  - tag: http.url
+    pattern: "token=[^&]*"
+    replacement: "token=?"
+integrations:
+  net/http:
+    service_name: web
+`)
+	defer os.Remove(path)
+	require.NoError(os.Setenv(signalfxTracingConfig, path))
+	defer os.Unsetenv(signalfxTracingConfig)
+
+	Start(WithEndpointURL(zipkin.URL()), WithoutLibraryTags())
+	defer Stop()
+	defer globalconfig.SetIntegrationSettings(nil)
+
+	v, ok := globalconfig.IntegrationSetting("net/http", "service_name")
+	assert.True(ok)
+	assert.Equal("web", v)
+
+	span := tracer.StartSpan("test")
+	span.SetTag("http.url", "/path?token=abc&q=1")
+	span.Finish()
+
+	tracer.ForceFlush()
+	spans := zipkin.WaitForSpans(t, 1)
+
+	assert.Equal("file-service", *spans[0].LocalEndpoint.ServiceName)
+	assert.Equal("staging", spans[0].Tags["env"])
+	assert.Equal("/path?token=?&q=1", spans[0].Tags["http.url"])
+}
+
+func TestConsoleExporter(t *testing.T) {
+	// flush flushes the tracer until written returns a positive size.
+	flush := func(t *testing.T, written func() int) {
+		assert.Eventually(t, func() bool {
+			tracer.ForceFlush()
+			return written() > 0
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("option", func(t *testing.T) {
+		var buf bytes.Buffer
+		Start(WithConsoleExporter(&buf, tracer.ConsoleTree), WithServiceName("console"), WithoutLibraryTags())
+		tracer.StartSpan("op").Finish()
+		flush(t, buf.Len)
+		Stop()
+		assert.Regexp(t, `^trace [0-9a-f]{16} \(1 spans\)\n  op \[console\] `, buf.String())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		dir, err := ioutil.TempDir("", "console")
+		require.NoError(err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "traces.jsonl")
+
+		for k, v := range map[string]string{
+			signalfxExporter:              "console",
+			signalfxExporterConsoleFormat: "json",
+			signalfxExporterConsoleFile:   path,
+		} {
+			require.NoError(os.Setenv(k, v))
+			defer os.Unsetenv(k)
+		}
+		// no endpoint is listening: the console exporter needs no network
+		Start(WithEndpointURL("http://127.0.0.1:1/v1/trace"), WithServiceName("console"))
+		// restarting closes the file opened by the previous Start
+		first := consoleFile
+		Start(WithEndpointURL("http://127.0.0.1:1/v1/trace"), WithServiceName("console"))
+		_, err = first.(io.Writer).Write([]byte("x"))
+		assert.Equal(os.ErrClosed, err)
+		tracer.StartSpan("op").Finish()
+		flush(t, func() int {
+			fi, err := os.Stat(path)
+			if err != nil {
+				return 0
+			}
+			return int(fi.Size())
+		})
+		Stop()
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(err)
+		var s map[string]interface{}
+		require.NoError(json.Unmarshal(data, &s))
+		assert.Equal("op", s["name"])
+		assert.Equal("console", s["service"])
+	})
+}
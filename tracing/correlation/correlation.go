@@ -0,0 +1,150 @@
+// Package correlation links log lines with the span active in a context.Context.
+// It extracts the trace ID, span ID, service name and deployment environment of
+// the span, which can then be attached to log records as fields.
+//
+// Adapters for the standard library logger are provided here. Adapters for
+// other loggers live in the contrib directory:
+// https://godoc.org/github.com/signalfx/signalfx-go-tracing/contrib/sirupsen/logrus,
+// https://godoc.org/github.com/signalfx/signalfx-go-tracing/contrib/go.uber.org/zap and
+// https://godoc.org/github.com/signalfx/signalfx-go-tracing/contrib/rs/zerolog.
+package correlation // import "github.com/signalfx/signalfx-go-tracing/tracing/correlation"
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+const (
+	// TraceIDKey is the log field holding the hex-encoded trace ID.
+	TraceIDKey = "trace_id"
+
+	// SpanIDKey is the log field holding the hex-encoded span ID.
+	SpanIDKey = "span_id"
+
+	// ServiceNameKey is the log field holding the service name of the span.
+	ServiceNameKey = "service.name"
+
+	// EnvironmentKey is the log field holding the deployment environment.
+	EnvironmentKey = "deployment.environment"
+)
+
+// environmentTags lists the span tags which hold the deployment environment,
+// in order of preference.
+var environmentTags = []string{ext.DeploymentEnvironment, "environment", ext.Environment}
+
+// Fields holds the correlation values of a span.
+type Fields struct {
+	TraceID     string
+	SpanID      string
+	ServiceName string
+	Environment string
+}
+
+// FromContext returns the correlation fields of the span found in ctx. The
+// second return value is false if ctx holds no span of the tracer.
+func FromContext(ctx context.Context) (Fields, bool) {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return Fields{}, false
+	}
+	sctx := span.Context()
+	f := Fields{
+		TraceID:     tracer.TraceIDHex(sctx),
+		SpanID:      tracer.SpanIDHex(sctx),
+		ServiceName: tracer.SpanServiceName(sctx),
+	}
+	if f.TraceID == "" {
+		return Fields{}, false
+	}
+	for _, key := range environmentTags {
+		if env := tracer.SpanTag(sctx, key); env != "" {
+			f.Environment = env
+			break
+		}
+	}
+	return f, true
+}
+
+// Map returns the non-empty fields keyed by their log field names.
+func (f Fields) Map() map[string]string {
+	m := make(map[string]string, 4)
+	f.each(func(k, v string) { m[k] = v })
+	return m
+}
+
+// KeyValues returns the non-empty fields as alternating keys and values, as
+// expected by most structured loggers.
+func (f Fields) KeyValues() []interface{} {
+	kvs := make([]interface{}, 0, 8)
+	f.each(func(k, v string) { kvs = append(kvs, k, v) })
+	return kvs
+}
+
+// String returns the non-empty fields in logfmt format, for example:
+//
+//	trace_id=0000000000000001 span_id=0000000000000002 service.name=api
+func (f Fields) String() string {
+	var b strings.Builder
+	f.each(func(k, v string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		if strings.ContainsAny(v, " \"=") {
+			fmt.Fprintf(&b, "%q", v)
+		} else {
+			b.WriteString(v)
+		}
+	})
+	return b.String()
+}
+
+// each calls fn for every non-empty field, in a stable order.
+func (f Fields) each(fn func(k, v string)) {
+	for _, kv := range [...][2]string{
+		{TraceIDKey, f.TraceID},
+		{SpanIDKey, f.SpanID},
+		{ServiceNameKey, f.ServiceName},
+		{EnvironmentKey, f.Environment},
+	} {
+		if kv[1] != "" {
+			fn(kv[0], kv[1])
+		}
+	}
+}
+
+// Print calls l.Print with the correlation fields of the span in ctx appended
+// to the message. If l is nil, the standard logger is used.
+func Print(ctx context.Context, l *log.Logger, v ...interface{}) {
+	output(ctx, l, fmt.Sprint(v...))
+}
+
+// Printf calls l.Printf with the correlation fields of the span in ctx appended
+// to the message. If l is nil, the standard logger is used.
+func Printf(ctx context.Context, l *log.Logger, format string, v ...interface{}) {
+	output(ctx, l, fmt.Sprintf(format, v...))
+}
+
+// Println calls l.Println with the correlation fields of the span in ctx appended
+// to the message. If l is nil, the standard logger is used.
+func Println(ctx context.Context, l *log.Logger, v ...interface{}) {
+	output(ctx, l, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func output(ctx context.Context, l *log.Logger, msg string) {
+	if f, ok := FromContext(ctx); ok {
+		msg += " " + f.String()
+	}
+	if l == nil {
+		// calldepth 3 reports the caller of Print, Printf or Println
+		_ = log.Output(3, msg)
+		return
+	}
+	_ = l.Output(3, msg)
+}
@@ -0,0 +1,99 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func startTracer() func() {
+	zipkin := zipkinserver.Start()
+	tracer.Start(
+		tracer.WithServiceName("svc"),
+		tracer.WithZipkin("svc", zipkin.URL(), ""),
+		tracer.WithGlobalTag("environment", "prod"),
+	)
+	return func() {
+		tracer.Stop()
+		zipkin.Stop()
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	defer startTracer()()
+
+	t.Run("span", func(t *testing.T) {
+		assert := assert.New(t)
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "op")
+		defer span.Finish()
+
+		f, ok := FromContext(ctx)
+		assert.True(ok)
+		assert.Equal(tracer.TraceIDHex(span.Context()), f.TraceID)
+		assert.Equal(tracer.SpanIDHex(span.Context()), f.SpanID)
+		assert.Equal("svc", f.ServiceName)
+		assert.Equal("prod", f.Environment)
+		assert.Equal(map[string]string{
+			TraceIDKey:     f.TraceID,
+			SpanIDKey:      f.SpanID,
+			ServiceNameKey: "svc",
+			EnvironmentKey: "prod",
+		}, f.Map())
+		assert.Equal([]interface{}{
+			TraceIDKey, f.TraceID,
+			SpanIDKey, f.SpanID,
+			ServiceNameKey, "svc",
+			EnvironmentKey, "prod",
+		}, f.KeyValues())
+	})
+
+	t.Run("environment-precedence", func(t *testing.T) {
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "op", tracer.Tag(ext.DeploymentEnvironment, "staging"))
+		defer span.Finish()
+		f, _ := FromContext(ctx)
+		assert.Equal(t, "staging", f.Environment)
+	})
+
+	t.Run("no-span", func(t *testing.T) {
+		f, ok := FromContext(context.Background())
+		assert.False(t, ok)
+		assert.Equal(t, Fields{}, f)
+		assert.Empty(t, f.Map())
+		assert.Equal(t, "", f.String())
+	})
+}
+
+func TestFieldsString(t *testing.T) {
+	f := Fields{TraceID: "01", SpanID: "02", ServiceName: "my svc"}
+	assert.Equal(t, `trace_id=01 span_id=02 service.name="my svc"`, f.String())
+}
+
+func TestPrint(t *testing.T) {
+	defer startTracer()()
+	assert := assert.New(t)
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "op")
+	defer span.Finish()
+	traceID := tracer.TraceIDHex(span.Context())
+
+	var buf bytes.Buffer
+	l := log.New(&buf, "", 0)
+
+	Printf(ctx, l, "hello %s", "world")
+	assert.Contains(buf.String(), "hello world trace_id="+traceID)
+	buf.Reset()
+
+	Println(ctx, l, "hello")
+	assert.Contains(buf.String(), "hello trace_id="+traceID)
+	buf.Reset()
+
+	Print(context.Background(), l, "plain")
+	assert.Equal("plain\n", buf.String())
+}
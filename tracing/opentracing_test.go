@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/log"
@@ -299,3 +300,48 @@ func annotationToMap(t *testing.T, annotation *traceformat.Annotation) map[strin
 	}
 	return m
 }
+
+func TestRuntimeMetricsConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	c := defaultConfig()
+	assert.False(c.runtimeMetrics)
+	assert.False(c.spanMetrics)
+	assert.Equal("http://localhost:9080/v2/datapoint", c.metricsURL)
+
+	require.NoError(os.Setenv(signalfxRuntimeMetricsEnabled, "true"))
+	defer os.Unsetenv(signalfxRuntimeMetricsEnabled)
+	require.NoError(os.Setenv(signalfxRuntimeMetricsInterval, "30s"))
+	defer os.Unsetenv(signalfxRuntimeMetricsInterval)
+	require.NoError(os.Setenv(signalfxMetricsEndpointURL, "http://ingest:9943/v2/datapoint"))
+	defer os.Unsetenv(signalfxMetricsEndpointURL)
+
+	require.NoError(os.Setenv(signalfxSpanMetricsEnabled, "true"))
+	defer os.Unsetenv(signalfxSpanMetricsEnabled)
+
+	c = defaultConfig()
+	assert.True(c.runtimeMetrics)
+	assert.True(c.spanMetrics)
+	assert.Equal(30*time.Second, c.runtimeMetricsInterval)
+	assert.Equal("http://ingest:9943/v2/datapoint", c.metricsURL)
+
+	WithMetricsEndpointURL("http://other/v2/datapoint")(c)
+	WithRuntimeMetricsInterval(time.Minute)(c)
+	assert.Equal("http://other/v2/datapoint", c.metricsURL)
+	assert.Equal(time.Minute, c.runtimeMetricsInterval)
+}
+
+func TestDropRejectedTracesConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(defaultConfig().dropRejected)
+
+	require.NoError(t, os.Setenv(signalfxDropRejectedTraces, "true"))
+	defer os.Unsetenv(signalfxDropRejectedTraces)
+	assert.True(defaultConfig().dropRejected)
+
+	c := &config{}
+	WithDropRejectedTraces()(c)
+	assert.True(c.dropRejected)
+}
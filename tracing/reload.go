@@ -0,0 +1,229 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+)
+
+// reloader holds the state shared by the configuration file watcher and the
+// admin handler while tracing is started.
+var reloader struct {
+	sync.Mutex
+	opts []StartOption // options given to Start, reapplied on every reload
+	cfg  *config       // configuration applied to the running tracer
+	stop chan struct{} // closed to stop the watcher
+	done chan struct{} // closed when the watcher exited
+}
+
+// startReloader records the configuration of the started tracer and, if
+// enabled, starts watching the configuration file.
+func startReloader(c *config, opts []StartOption) {
+	reloader.Lock()
+	defer reloader.Unlock()
+	reloader.opts = opts
+	reloader.cfg = c
+	path := os.Getenv(signalfxTracingConfig)
+	if path == "" || c.reloadInterval <= 0 {
+		return
+	}
+	reloader.stop = make(chan struct{})
+	reloader.done = make(chan struct{})
+	go watchConfigFile(path, c.reloadInterval, reloader.stop, reloader.done)
+}
+
+// stopReloader stops the watcher, if any, and forgets the configuration.
+func stopReloader() {
+	reloader.Lock()
+	stop, done := reloader.stop, reloader.done
+	reloader.stop, reloader.done = nil, nil
+	reloader.opts, reloader.cfg = nil, nil
+	reloader.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// watchConfigFile checks the file at path every interval and reloads the
+// configuration when its modification time or size change.
+func watchConfigFile(path string, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, _ := os.Stat(path)
+	for {
+		select {
+		case <-ticker.C:
+			fi, err := os.Stat(path)
+			if err != nil || (last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size()) {
+				continue
+			}
+			last = fi
+			if err := reloadConfig(); err != nil {
+				log.Printf("%s%v\n", errorPrefix, err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reloadConfig resolves the configuration again, as Start does, and applies
+// the settings which can be changed at runtime to the tracer. The tracer is
+// left unchanged if the configuration file is invalid.
+func reloadConfig() error {
+	reloader.Lock()
+	defer reloader.Unlock()
+	if reloader.cfg == nil {
+		return nil
+	}
+	c, err := newConfig(reloader.opts)
+	if err != nil {
+		return err
+	}
+	return reconfigure(c)
+}
+
+// reconfigure applies the runtime settings of c to the tracer and records c as
+// the current configuration. reloader must be locked.
+func reconfigure(c *config) error {
+	if err := tracer.Reconfigure(c.applySettings); err != nil {
+		return err
+	}
+	reloader.cfg = c
+	if c.debug {
+		log.Printf("SignalFx Tracing configuration: %s\n", c)
+	}
+	return nil
+}
+
+// newSampler returns the sampler described by the configuration.
+func (c *config) newSampler() tracer.Sampler {
+	if c.sampler == nil {
+		return tracer.NewAllSampler()
+	}
+	return tracer.NewRuleSampler(c.sampler.rate, c.sampler.rules...)
+}
+
+// applySettings sets the runtime settings of the configuration on s.
+func (c *config) applySettings(s *tracer.Settings) {
+	s.Sampler = c.newSampler()
+	s.GlobalTags = make(map[string]interface{}, len(c.globalTags))
+	for k, v := range c.globalTags {
+		s.GlobalTags[k] = v
+	}
+	s.Debug = c.debug
+	s.RecordedValueMaxLength = c.recordedValueMaxLength
+}
+
+// adminSettings holds the settings read and written through AdminHandler, in
+// the format of the configuration file.
+type adminSettings struct {
+	GlobalTags             map[string]string  `yaml:"global_tags" json:"global_tags"`
+	RecordedValueMaxLength *int               `yaml:"recorded_value_max_length" json:"recorded_value_max_length"`
+	Debug                  *bool              `yaml:"debug" json:"debug"`
+	Sampler                *fileSamplerConfig `yaml:"sampler" json:"sampler,omitempty"`
+}
+
+// AdminHandler returns an HTTP handler to inspect and change the settings of
+// the running tracer, meant to be served on an internal admin port. GET
+// returns the current settings as JSON. PUT and POST take a JSON or YAML
+// document with any of the global_tags, recorded_value_max_length, debug and
+// sampler fields of the configuration file: global tags are added, the other
+// fields replace the current values. For example:
+//
+//	curl -X PUT -d '{"sampler": {"rate": 1}}' http://localhost:8081/tracing
+//
+// Changes last until tracing is restarted or the configuration file is
+// reloaded.
+func AdminHandler() http.Handler {
+	return http.HandlerFunc(serveAdmin)
+}
+
+func serveAdmin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var update adminSettings
+		dec := yaml.NewDecoder(r.Body)
+		dec.KnownFields(true)
+		if err := dec.Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fc := &fileConfig{
+			GlobalTags:             update.GlobalTags,
+			RecordedValueMaxLength: update.RecordedValueMaxLength,
+			Debug:                  update.Debug,
+			Sampler:                update.Sampler,
+		}
+		if field, err := fc.validate(); err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: %v", field, err), http.StatusBadRequest)
+			return
+		}
+		if code, err := applyAdminUpdate(fc); err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	reloader.Lock()
+	c := reloader.cfg
+	reloader.Unlock()
+	if c == nil {
+		http.Error(w, "tracing not started", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.adminSettings())
+}
+
+// applyAdminUpdate applies the valid update fc on top of the current
+// configuration, returning an HTTP status code along with any error.
+func applyAdminUpdate(fc *fileConfig) (int, error) {
+	reloader.Lock()
+	defer reloader.Unlock()
+	if reloader.cfg == nil {
+		return http.StatusServiceUnavailable, fmt.Errorf("tracing not started")
+	}
+	c := reloader.cfg.clone()
+	fc.apply(c)
+	if err := reconfigure(c); err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+	return http.StatusOK, nil
+}
+
+// adminSettings returns the runtime settings of the configuration.
+func (c *config) adminSettings() *adminSettings {
+	s := &adminSettings{
+		GlobalTags:             c.globalTags,
+		RecordedValueMaxLength: c.recordedValueMaxLength,
+		Debug:                  &c.debug,
+	}
+	if c.sampler != nil {
+		rate := c.sampler.rate
+		s.Sampler = &fileSamplerConfig{Rate: &rate}
+		for _, r := range c.sampler.rules {
+			s.Sampler.Rules = append(s.Sampler.Rules, fileSamplingRule{
+				Service:   r.Service,
+				Operation: r.Operation,
+				Rate:      r.Rate,
+			})
+		}
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/zipkinserver"
+)
+
+func TestConfigReload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+
+	path := writeConfigFile(t, ".yaml", "sampler: {rate: 0}\nglobal_tags: {env: staging}\n")
+	defer os.Remove(path)
+	require.NoError(os.Setenv(signalfxTracingConfig, path))
+	defer os.Unsetenv(signalfxTracingConfig)
+
+	Start(WithEndpointURL(zipkin.URL()), WithConfigReloadInterval(10*time.Millisecond), WithGlobalTag("app", "code"))
+	defer Stop()
+
+	s, ok := tracer.CurrentSettings()
+	require.True(ok)
+	assert.False(s.Sampler.Sample(tracer.StartSpan("dropped")))
+
+	// an invalid file leaves the settings unchanged
+	require.NoError(ioutil.WriteFile(path, []byte("sampler: {rate: 3}\n"), 0600))
+	time.Sleep(100 * time.Millisecond)
+	s, _ = tracer.CurrentSettings()
+	assert.Equal("staging", s.GlobalTags["env"])
+
+	require.NoError(ioutil.WriteFile(path, []byte("sampler: {rate: 1}\nglobal_tags: {env: production, app: file}\ndebug: true\n"), 0600))
+	assert.Eventually(func() bool {
+		s, _ := tracer.CurrentSettings()
+		return s.Debug
+	}, 5*time.Second, 10*time.Millisecond)
+
+	s, _ = tracer.CurrentSettings()
+	assert.Equal(map[string]interface{}{"env": "production", "app": "code"}, s.GlobalTags)
+
+	span := tracer.StartSpan("kept")
+	span.Finish()
+	tracer.ForceFlush()
+	spans := zipkin.WaitForSpans(t, 1)
+	assert.Equal("kept", *spans[0].Name)
+	assert.Equal("production", spans[0].Tags["env"])
+}
+
+func TestAdminHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := httptest.NewServer(AdminHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+
+	zipkin := zipkinserver.Start()
+	defer zipkin.Stop()
+
+	Start(WithEndpointURL(zipkin.URL()), WithGlobalTag("env", "staging"))
+	defer Stop()
+
+	put := func(body string) (int, map[string]interface{}) {
+		req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(body))
+		require.NoError(err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(err)
+		defer resp.Body.Close()
+		var m map[string]interface{}
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(json.NewDecoder(resp.Body).Decode(&m))
+		}
+		return resp.StatusCode, m
+	}
+
+	code, m := put(`{"sampler": {"rate": 0.5, "rules": [{"service": "api", "rate": 1}]}, "global_tags": {"team": "x"}}`)
+	require.Equal(http.StatusOK, code)
+	assert.Equal(map[string]interface{}{"env": "staging", "team": "x"}, m["global_tags"])
+	assert.Equal(map[string]interface{}{
+		"rate":  0.5,
+		"rules": []interface{}{map[string]interface{}{"service": "api", "rate": 1.0}},
+	}, m["sampler"])
+
+	s, ok := tracer.CurrentSettings()
+	require.True(ok)
+	assert.Equal("x", s.GlobalTags["team"])
+	assert.Equal(1200, *s.RecordedValueMaxLength)
+
+	code, _ = put(`{"sampler": {"rate": 2}}`)
+	assert.Equal(http.StatusBadRequest, code)
+	code, _ = put(`{"service_name": "other"}`)
+	assert.Equal(http.StatusBadRequest, code)
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	require.NoError(err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+}
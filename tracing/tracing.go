@@ -1,13 +1,19 @@
 package tracing
 
 import (
+	"fmt"
+	"io"
+	"log"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/opentracer"
 	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/internal/globalconfig"
 )
 
 const (
@@ -16,57 +22,255 @@ const (
 	signalfxAccessToken            = "SIGNALFX_ACCESS_TOKEN"
 	signalfxSpanTags               = "SIGNALFX_SPAN_TAGS"
 	signalfxRecordedValueMaxLength = "SIGNALFX_RECORDED_VALUE_MAX_LENGTH"
+	signalfxRuntimeMetricsEnabled  = "SIGNALFX_RUNTIME_METRICS_ENABLED"
+	signalfxRuntimeMetricsInterval = "SIGNALFX_RUNTIME_METRICS_INTERVAL"
+	signalfxMetricsEndpointURL     = "SIGNALFX_METRICS_ENDPOINT_URL"
+	signalfxSpanMetricsEnabled     = "SIGNALFX_SPAN_METRICS_ENABLED"
+	signalfxDropRejectedTraces     = "SIGNALFX_DROP_REJECTED_TRACES"
+	signalfxTracingDebug           = "SIGNALFX_TRACING_DEBUG"
+	signalfxPropagators            = "SIGNALFX_PROPAGATORS"
+
+	signalfxTracingConfigReloadInterval = "SIGNALFX_TRACING_CONFIG_RELOAD_INTERVAL"
+
+	signalfxExporter              = "SIGNALFX_EXPORTER"
+	signalfxExporterConsoleFormat = "SIGNALFX_EXPORTER_CONSOLE_FORMAT"
+	signalfxExporterConsoleFile   = "SIGNALFX_EXPORTER_CONSOLE_FILE"
 )
 
 const defaultRecordedValueMaxLength int = 1200
 
+const (
+	exporterZipkin  = "zipkin"
+	exporterConsole = "console"
+)
+
+// The console exporter file is rotated when it reaches consoleFileMaxSize
+// bytes, keeping consoleFileMaxBackups rotated files.
+const (
+	consoleFileMaxSize    = 10 * 1024 * 1024
+	consoleFileMaxBackups = 3
+)
+
+// consoleFile is the file opened for the console exporter by Start, closed by Stop.
+var consoleFile io.Closer
+
+// errorPrefix is prepended to the errors logged by Start.
+const errorPrefix = "SignalFx Tracing Error: "
+
 var defaults = map[string]string{
-	signalfxServiceName: "unnamed-go-service",
-	signalfxEndpointURL: "http://localhost:9080/v1/trace",
-	signalfxAccessToken: "",
+	signalfxServiceName:        "unnamed-go-service",
+	signalfxEndpointURL:        "http://localhost:9080/v1/trace",
+	signalfxAccessToken:        "",
+	signalfxMetricsEndpointURL: "http://localhost:9080/v2/datapoint",
 }
 
 type config struct {
 	serviceName string
 	accessToken string
 	url         string
-	// Because there can be multiple global tags added via environment variable
-	// or calls to WithGlobalTag, store them in the required StartOption format to
-	// call tracer.Start() in the variadic format.
-	globalTags []tracer.StartOption
+	// globalTags holds the tags added by the configuration file, environment
+	// variable and calls to WithGlobalTag, in that order of precedence.
+	globalTags map[string]string
 
 	// flag to disable injecting library tags
 	disableLibraryTags bool
 
 	recordedValueMaxLength *int
+
+	runtimeMetrics         bool
+	runtimeMetricsInterval time.Duration
+	metricsURL             string
+	spanMetrics            bool
+
+	// dropRejected drops the traces rejected by their sampling priority.
+	dropRejected bool
+
+	debug       bool
+	propagators []string
+
+	// exporter is "zipkin", the default, or "console". The console exporter
+	// writes to consoleWriter if set, else to consoleFile if set, else to
+	// the standard output.
+	exporter      string
+	consoleWriter io.Writer
+	consoleFile   string
+	consoleFormat tracer.ConsoleFormat
+
+	idGenerator tracer.IDGenerator
+
+	limits *tracer.SpanLimits
+
+	// appServiceName keeps the service name of the application as the local
+	// service of all the spans exported to Zipkin.
+	appServiceName bool
+
+	// metricTagsAllow and metricTagsDeny hold the key prefixes of the span
+	// metrics always and never exported as Zipkin tags.
+	metricTagsAllow []string
+	metricTagsDeny  []string
+
+	// reloadInterval is how often the configuration file is checked for
+	// changes; 0 disables reloading.
+	reloadInterval time.Duration
+
+	// sampler, redactions and integrations are only set from the
+	// configuration file.
+	sampler      *samplerConfig
+	redactions   []redaction
+	integrations map[string]map[string]string
+}
+
+// samplerConfig holds the default sampling rate and the sampling rules.
+type samplerConfig struct {
+	rate  float64
+	rules []tracer.SamplingRule
+}
+
+// redaction replaces the matches of pattern in the values of tag.
+type redaction struct {
+	tag         string
+	pattern     *regexp.Regexp
+	replacement string
 }
 
 // StartOption is a function that configures an option for Start
 type StartOption = func(*config)
 
+// defaultConfig returns the configuration built from the defaults, then the
+// configuration file named by SIGNALFX_TRACING_CONFIG, if any, and then the
+// environment variables, each overriding the previous ones.
 func defaultConfig() *config {
-	return &config{
-		serviceName:            envOrDefault(signalfxServiceName),
-		accessToken:            envOrDefault(signalfxAccessToken),
-		url:                    envOrDefault(signalfxEndpointURL),
-		globalTags:             envGlobalTags(),
-		recordedValueMaxLength: envRecordedValueMaxLength(),
+	c, err := newConfig(nil)
+	if err != nil {
+		log.Printf("%s%v\n", errorPrefix, err)
 	}
+	return c
 }
 
-func envRecordedValueMaxLength() *int {
-	val := os.Getenv(signalfxRecordedValueMaxLength)
-	num, err := strconv.Atoi(val)
-	if err != nil {
-		num = defaultRecordedValueMaxLength
+// newConfig returns the configuration built as defaultConfig does, with opts
+// applied last. An invalid configuration file is ignored and reported by the
+// returned error, along with a configuration which is still usable.
+func newConfig(opts []StartOption) (*config, error) {
+	l := defaultRecordedValueMaxLength
+	c := &config{
+		serviceName:            defaults[signalfxServiceName],
+		accessToken:            defaults[signalfxAccessToken],
+		url:                    defaults[signalfxEndpointURL],
+		recordedValueMaxLength: &l,
+		metricsURL:             defaults[signalfxMetricsEndpointURL],
 	}
-	return &num
+	var err error
+	if path := os.Getenv(signalfxTracingConfig); path != "" {
+		var fc *fileConfig
+		if fc, err = loadConfigFile(path); err == nil {
+			fc.apply(c)
+		}
+	}
+	c.applyEnv()
+	for _, fn := range opts {
+		fn(c)
+	}
+	return c, err
+}
+
+// clone returns a copy of c which can be modified without changing c.
+func (c *config) clone() *config {
+	cp := *c
+	cp.globalTags = make(map[string]string, len(c.globalTags))
+	for k, v := range c.globalTags {
+		cp.globalTags[k] = v
+	}
+	return &cp
+}
+
+// applyEnv sets the values of the environment variables which are set on c.
+func (c *config) applyEnv() {
+	if v := os.Getenv(signalfxServiceName); v != "" {
+		c.serviceName = v
+	}
+	if v := os.Getenv(signalfxAccessToken); v != "" {
+		c.accessToken = v
+	}
+	if v := os.Getenv(signalfxEndpointURL); v != "" {
+		c.url = v
+	}
+	for k, v := range envGlobalTags() {
+		c.setGlobalTag(k, v)
+	}
+	if v, err := strconv.Atoi(os.Getenv(signalfxRecordedValueMaxLength)); err == nil {
+		c.recordedValueMaxLength = &v
+	}
+	if v := os.Getenv(signalfxRuntimeMetricsEnabled); v != "" {
+		c.runtimeMetrics = strings.EqualFold(v, "true")
+	}
+	if d, err := time.ParseDuration(os.Getenv(signalfxRuntimeMetricsInterval)); err == nil {
+		c.runtimeMetricsInterval = d
+	}
+	if v := os.Getenv(signalfxMetricsEndpointURL); v != "" {
+		c.metricsURL = v
+	}
+	if v := os.Getenv(signalfxSpanMetricsEnabled); v != "" {
+		c.spanMetrics = strings.EqualFold(v, "true")
+	}
+	if v := os.Getenv(signalfxDropRejectedTraces); v != "" {
+		c.dropRejected = strings.EqualFold(v, "true")
+	}
+	if v := os.Getenv(signalfxTracingDebug); v != "" {
+		c.debug = strings.EqualFold(v, "true")
+	}
+	if v := os.Getenv(signalfxPropagators); v != "" {
+		c.propagators = strings.Split(v, ",")
+	}
+	if v := os.Getenv(signalfxExporter); v != "" {
+		c.exporter = strings.ToLower(strings.TrimSpace(v))
+	}
+	if f, err := tracer.ParseConsoleFormat(os.Getenv(signalfxExporterConsoleFormat)); err == nil {
+		c.consoleFormat = f
+	}
+	if v := os.Getenv(signalfxExporterConsoleFile); v != "" {
+		c.consoleFile = v
+	}
+	if d, err := time.ParseDuration(os.Getenv(signalfxTracingConfigReloadInterval)); err == nil {
+		c.reloadInterval = d
+	}
+}
+
+// String returns the resolved configuration, with the access token masked,
+// for logging in debug mode.
+func (c *config) String() string {
+	token := ""
+	if c.accessToken != "" {
+		token = "****"
+	}
+	maxLength := "default"
+	if c.recordedValueMaxLength != nil {
+		maxLength = strconv.Itoa(*c.recordedValueMaxLength)
+	}
+	exporter := c.exporter
+	if exporter == "" {
+		exporter = exporterZipkin
+	}
+	s := fmt.Sprintf("service_name=%q endpoint_url=%q access_token=%q exporter=%q recorded_value_max_length=%s "+
+		"propagators=%q runtime_metrics=%t runtime_metrics_interval=%s span_metrics=%t metrics_endpoint_url=%q "+
+		"drop_rejected_traces=%t",
+		c.serviceName, c.url, token, exporter, maxLength, c.propagators, c.runtimeMetrics, c.runtimeMetricsInterval,
+		c.spanMetrics, c.metricsURL, c.dropRejected)
+	if c.sampler != nil {
+		s += fmt.Sprintf(" sampler.rate=%g sampler.rules=%d", c.sampler.rate, len(c.sampler.rules))
+	}
+	if len(c.redactions) > 0 {
+		s += fmt.Sprintf(" redaction=%d", len(c.redactions))
+	}
+	if len(c.integrations) > 0 {
+		s += fmt.Sprintf(" integrations=%d", len(c.integrations))
+	}
+	return s
 }
 
 // envGlobalTags extract global tags from the environment variable and parses the value in the expected format
 // key1:value1,
-func envGlobalTags() []tracer.StartOption {
-	var globalTags []tracer.StartOption
+func envGlobalTags() map[string]string {
+	globalTags := make(map[string]string)
 	var val string
 
 	if val = os.Getenv(signalfxSpanTags); val == "" {
@@ -86,20 +290,19 @@ func envGlobalTags() []tracer.StartOption {
 			if key == "" {
 				continue
 			}
-			globalTag := tracer.WithGlobalTag(key, value)
-			globalTags = append(globalTags, globalTag)
+			globalTags[key] = value
 		}
 	}
 
 	return globalTags
 }
 
-// envOrDefault gets the given environment variable if set otherwise a default value.
-func envOrDefault(envVar string) string {
-	if val := os.Getenv(envVar); val != "" {
-		return val
+// setGlobalTag adds the tag k with value v to the global tags.
+func (c *config) setGlobalTag(k, v string) {
+	if c.globalTags == nil {
+		c.globalTags = make(map[string]string)
 	}
-	return defaults[envVar]
+	c.globalTags[k] = v
 }
 
 // WithServiceName changes the reported service name
@@ -137,8 +340,7 @@ func WithoutLibraryTags() StartOption {
 // are accepted.
 func WithGlobalTag(k string, v string) StartOption {
 	return func(c *config) {
-		globalTag := tracer.WithGlobalTag(k, v)
-		c.globalTags = append(c.globalTags, globalTag)
+		c.setGlobalTag(k, v)
 	}
 }
 
@@ -152,30 +354,230 @@ func WithRecordedValueMaxLength(l int) StartOption {
 	}
 }
 
-// Start tracing globally
+// WithRuntimeMetrics enables periodic reporting of Go runtime metrics
+// (goroutines, heap, GC pauses, cgo calls and scheduler latency) to the
+// metrics endpoint. Datapoints carry the service name and global tags.
+func WithRuntimeMetrics() StartOption {
+	return func(c *config) {
+		c.runtimeMetrics = true
+	}
+}
+
+// WithRuntimeMetricsInterval configures how often runtime metrics are reported
+func WithRuntimeMetricsInterval(d time.Duration) StartOption {
+	return func(c *config) {
+		c.runtimeMetricsInterval = d
+	}
+}
+
+// WithSpanMetrics enables request rate, error and duration metrics derived
+// from all finished spans, before sampling, per service, operation, resource,
+// span kind and HTTP status code.
+func WithSpanMetrics() StartOption {
+	return func(c *config) {
+		c.spanMetrics = true
+	}
+}
+
+// WithDropRejectedTraces drops the traces whose sampling priority rejects
+// them, such as the ones marked with ext.ManualDrop, instead of sending them,
+// unless marked with ext.ManualKeep.
+func WithDropRejectedTraces() StartOption {
+	return func(c *config) {
+		c.dropRejected = true
+	}
+}
+
+// WithMetricsEndpointURL configures the URL to send datapoints to
+func WithMetricsEndpointURL(url string) StartOption {
+	return func(c *config) {
+		c.metricsURL = url
+	}
+}
+
+// WithDebugMode enables debug mode, in which the tracer logs the resolved
+// configuration and the details of the spans it sends.
+func WithDebugMode(enabled bool) StartOption {
+	return func(c *config) {
+		c.debug = enabled
+	}
+}
+
+// WithPropagators configures the propagation styles, "b3" or "datadog", used to
+// inject and extract span contexts. It defaults to "b3".
+func WithPropagators(styles ...string) StartOption {
+	return func(c *config) {
+		c.propagators = styles
+	}
+}
+
+// WithConsoleExporter writes finished traces to w in the given format instead
+// of sending them to the endpoint URL, for local development.
+func WithConsoleExporter(w io.Writer, format tracer.ConsoleFormat) StartOption {
+	return func(c *config) {
+		c.exporter = exporterConsole
+		c.consoleWriter = w
+		c.consoleFormat = format
+	}
+}
+
+// WithIDGenerator sets the generator of trace and span IDs, for example
+// tracer.NewXRayIDGenerator(). Random 64-bit IDs are generated by default.
+func WithIDGenerator(g tracer.IDGenerator) StartOption {
+	return func(c *config) {
+		c.idGenerator = g
+	}
+}
+
+// WithSpanLimits sets the limits applied to the tags, logs and baggage of spans
+// and to the number of spans of a trace. No limits are applied by default.
+func WithSpanLimits(l tracer.SpanLimits) StartOption {
+	return func(c *config) {
+		c.limits = &l
+	}
+}
+
+// WithAppServiceName reports the service name of the application as the local
+// service of all spans, instead of the service name set by integrations, which
+// is then reported as the peer.service tag of client spans. See
+// tracer.WithZipkinAppServiceName.
+func WithAppServiceName() StartOption {
+	return func(c *config) {
+		c.appServiceName = true
+	}
+}
+
+// WithZipkinMetricTags sets which span metrics are exported as string tags to
+// the Zipkin endpoint. All metrics are exported except internal ones by default.
+// See tracer.WithZipkinMetricTags.
+func WithZipkinMetricTags(allow, deny []string) StartOption {
+	return func(c *config) {
+		c.metricTagsAllow = allow
+		c.metricTagsDeny = deny
+	}
+}
+
+// WithConfigReloadInterval enables reloading the configuration file named by
+// SIGNALFX_TRACING_CONFIG when it changes, checking it at the given interval.
+// Changes of the sampler, global tags, debug mode and recorded value limit
+// are applied to the running tracer; other changes need a restart.
+func WithConfigReloadInterval(d time.Duration) StartOption {
+	return func(c *config) {
+		c.reloadInterval = d
+	}
+}
+
+// Start tracing globally. The configuration is resolved from the options, then
+// the environment variables and then the configuration file named by the
+// SIGNALFX_TRACING_CONFIG environment variable, in order of precedence.
 func Start(opts ...StartOption) {
-	c := defaultConfig()
-	for _, fn := range opts {
-		fn(c)
+	stopReloader()
+	if consoleFile != nil {
+		// flush the running tracer before closing the file it writes to
+		tracer.Stop()
+		consoleFile.Close()
+		consoleFile = nil
+	}
+	c, err := newConfig(opts)
+	if err != nil {
+		log.Printf("%s%v\n", errorPrefix, err)
+	}
+	if c.debug {
+		log.Printf("SignalFx Tracing configuration: %s\n", c)
 	}
 
-	startOptions := append(c.globalTags, tracer.WithServiceName(c.serviceName))
-	startOptions = append(startOptions, tracer.WithZipkin(c.serviceName, c.url, c.accessToken))
+	startOptions := []tracer.StartOption{tracer.WithServiceName(c.serviceName)}
+	for k, v := range c.globalTags {
+		startOptions = append(startOptions, tracer.WithGlobalTag(k, v))
+	}
+	startOptions = append(startOptions, c.exporterOption())
 	if c.disableLibraryTags {
 		startOptions = append(startOptions, tracer.WithoutLibraryTags())
 	}
 	if c.recordedValueMaxLength != nil {
 		startOptions = append(startOptions, tracer.WithTracerRecordedValueMaxLength(*c.recordedValueMaxLength))
 	}
+	if c.runtimeMetrics {
+		startOptions = append(startOptions,
+			tracer.WithRuntimeMetrics(),
+			tracer.WithRuntimeMetricsInterval(c.runtimeMetricsInterval),
+		)
+	}
+	if c.spanMetrics {
+		startOptions = append(startOptions, tracer.WithSpanMetrics())
+	}
+	if c.dropRejected {
+		startOptions = append(startOptions, tracer.WithDropRejectedTraces())
+	}
+	if c.runtimeMetrics || c.spanMetrics {
+		startOptions = append(startOptions, tracer.WithMetricsEndpoint(c.metricsURL, c.accessToken))
+	}
+	if c.debug {
+		startOptions = append(startOptions, tracer.WithDebugMode(true))
+	}
+	if len(c.propagators) > 0 {
+		startOptions = append(startOptions, tracer.WithPropagator(tracer.NewPropagator(&tracer.PropagatorConfig{
+			Styles: c.propagators,
+		})))
+	}
+	if c.sampler != nil {
+		startOptions = append(startOptions, tracer.WithSampler(c.newSampler()))
+	}
+	if c.idGenerator != nil {
+		startOptions = append(startOptions, tracer.WithIDGenerator(c.idGenerator))
+	}
+	if c.limits != nil {
+		startOptions = append(startOptions, tracer.WithSpanLimits(*c.limits))
+	}
+	if c.appServiceName {
+		startOptions = append(startOptions, tracer.WithZipkinAppServiceName())
+	}
+	if c.metricTagsAllow != nil || c.metricTagsDeny != nil {
+		startOptions = append(startOptions, tracer.WithZipkinMetricTags(c.metricTagsAllow, c.metricTagsDeny))
+	}
+	for _, r := range c.redactions {
+		startOptions = append(startOptions, tracer.WithTagRedaction(r.tag, r.pattern, r.replacement))
+	}
+	globalconfig.SetIntegrationSettings(c.integrations)
 	tracer.Start(
 		startOptions...,
 	)
 
 	opentracing.SetGlobalTracer(opentracer.New())
+	startReloader(c, opts)
+}
+
+// exporterOption returns the option selecting the exporter of the tracer.
+func (c *config) exporterOption() tracer.StartOption {
+	switch c.exporter {
+	case "", exporterZipkin:
+	case exporterConsole:
+		w := c.consoleWriter
+		if w == nil && c.consoleFile != "" {
+			f, err := tracer.NewRotatingFile(c.consoleFile, consoleFileMaxSize, consoleFileMaxBackups)
+			if err != nil {
+				log.Printf("%scannot open console exporter file: %v\n", errorPrefix, err)
+			} else {
+				w, consoleFile = f, f
+			}
+		}
+		if w == nil {
+			w = os.Stdout
+		}
+		return tracer.WithConsoleExporter(w, c.consoleFormat)
+	default:
+		log.Printf("%sunknown exporter %q, using %q\n", errorPrefix, c.exporter, exporterZipkin)
+	}
+	return tracer.WithZipkin(c.serviceName, c.url, c.accessToken)
 }
 
 // Stop tracing globally
 func Stop() {
+	stopReloader()
 	tracer.Stop()
+	if consoleFile != nil {
+		consoleFile.Close()
+		consoleFile = nil
+	}
 	opentracing.SetGlobalTracer(&opentracing.NoopTracer{})
 }
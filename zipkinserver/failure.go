@@ -0,0 +1,89 @@
+package zipkinserver
+
+import (
+	"net/http"
+	"time"
+)
+
+// Failure describes how the Zipkin server fails a request, to exercise the
+// retries of the transport.
+type Failure struct {
+	// Latency delays the response, or the dropping of the connection.
+	Latency time.Duration
+
+	// StatusCode is the status code of the response. It defaults to 503
+	// Service Unavailable.
+	StatusCode int
+
+	// Drop closes the connection without responding.
+	Drop bool
+}
+
+// FailNext makes the next n requests fail as described by f. Failures add up
+// to the ones already pending.
+func (z *ZipkinServer) FailNext(n int, f Failure) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	for i := 0; i < n; i++ {
+		z.failures = append(z.failures, f)
+	}
+}
+
+// FailAll makes every request fail as described by f, once the failures
+// pending from FailNext are exhausted, until ClearFailures is called.
+func (z *ZipkinServer) FailAll(f Failure) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	z.failAll = &f
+}
+
+// ClearFailures stops failing requests.
+func (z *ZipkinServer) ClearFailures() {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	z.failures = nil
+	z.failAll = nil
+}
+
+// nextFailure returns the failure of the next request, if any.
+func (z *ZipkinServer) nextFailure() (Failure, bool) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	if len(z.failures) > 0 {
+		f := z.failures[0]
+		z.failures = z.failures[1:]
+		return f, true
+	}
+	if z.failAll != nil {
+		return *z.failAll, true
+	}
+	return Failure{}, false
+}
+
+// fail fails the request as described by f and returns the status code of the
+// response, or zero when the connection was dropped.
+func (z *ZipkinServer) fail(w http.ResponseWriter, r *http.Request, f Failure) int {
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return 0
+		}
+	}
+	if f.Drop {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return 0
+			}
+		}
+		// the connection can't be dropped, abort the response instead
+		panic(http.ErrAbortHandler)
+	}
+	code := f.StatusCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+	http.Error(w, http.StatusText(code), code)
+	return code
+}
@@ -0,0 +1,26 @@
+package zipkinserver
+
+// Option configures a ZipkinServer.
+type Option func(*config)
+
+type config struct {
+	maxTraces int
+	hooks     []func(Request)
+}
+
+// WithMaxTraces bounds the number of traces kept by the server. Once the
+// bound is reached, the oldest trace is dropped when a new one is received.
+// As many of the latest requests are kept.
+func WithMaxTraces(n int) Option {
+	return func(c *config) {
+		c.maxTraces = n
+	}
+}
+
+// WithRequestHook sets a function called with each request received by the
+// server, once its spans are stored.
+func WithRequestHook(fn func(Request)) Option {
+	return func(c *config) {
+		c.hooks = append(c.hooks, fn)
+	}
+}
@@ -0,0 +1,308 @@
+package zipkinserver
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/signalfx/golib/trace"
+	traceformat "github.com/signalfx/golib/trace/format"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// spanKinds maps the values of the Span.Kind enum of zipkin.proto3 to Zipkin
+// JSON kinds.
+var spanKinds = map[uint64]string{
+	1: "CLIENT",
+	2: "SERVER",
+	3: "PRODUCER",
+	4: "CONSUMER",
+}
+
+var errTruncated = errors.New("zipkinserver: truncated protobuf message")
+
+// protoReader reads the fields of a protobuf message.
+type protoReader struct {
+	data []byte
+}
+
+// next returns the number and the wire type of the next field, or false when
+// the message is fully read.
+func (r *protoReader) next() (num uint64, typ int, ok bool, err error) {
+	if len(r.data) == 0 {
+		return 0, 0, false, nil
+	}
+	key, err := r.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return key >> 3, int(key & 7), true, nil
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	r.data = r.data[n:]
+	return v, nil
+}
+
+func (r *protoReader) fixed64() (uint64, error) {
+	if len(r.data) < 8 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v, nil
+}
+
+func (r *protoReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.data)) < n {
+		return nil, errTruncated
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
+
+// skip skips a field of the given wire type.
+func (r *protoReader) skip(typ int) error {
+	var err error
+	switch typ {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		if len(r.data) < 4 {
+			return errTruncated
+		}
+		r.data = r.data[4:]
+	default:
+		err = fmt.Errorf("zipkinserver: unsupported protobuf wire type %d", typ)
+	}
+	return err
+}
+
+// readFields calls fn for each field of the message in data. fn reads the
+// field, or returns false to have it skipped.
+func readFields(data []byte, fn func(r *protoReader, num uint64, typ int) (bool, error)) error {
+	r := &protoReader{data: data}
+	for {
+		num, typ, ok, err := r.next()
+		if err != nil || !ok {
+			return err
+		}
+		read, err := fn(r, num, typ)
+		if err != nil {
+			return err
+		}
+		if !read {
+			if err := r.skip(typ); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decodeProto decodes the spans of a zipkin.proto3 ListOfSpans message.
+func decodeProto(data []byte) (traceformat.Trace, error) {
+	var spans traceformat.Trace
+	err := readFields(data, func(r *protoReader, num uint64, typ int) (bool, error) {
+		if num != 1 || typ != wireBytes {
+			return false, nil
+		}
+		b, err := r.bytes()
+		if err != nil {
+			return true, err
+		}
+		s, err := decodeSpan(b)
+		spans = append(spans, s)
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
+
+func decodeSpan(data []byte) (*trace.Span, error) {
+	s := &trace.Span{}
+	err := readFields(data, func(r *protoReader, num uint64, typ int) (bool, error) {
+		var err error
+		switch {
+		case typ == wireBytes && num <= 3: // trace_id, parent_id, id
+			var b []byte
+			if b, err = r.bytes(); err != nil {
+				return true, err
+			}
+			id := hex.EncodeToString(b)
+			switch num {
+			case 1:
+				s.TraceID = id
+			case 2:
+				s.ParentID = &id
+			case 3:
+				s.ID = id
+			}
+		case num == 4 && typ == wireVarint:
+			var k uint64
+			if k, err = r.varint(); err == nil {
+				if kind, ok := spanKinds[k]; ok {
+					s.Kind = &kind
+				}
+			}
+		case num == 5 && typ == wireBytes:
+			var b []byte
+			if b, err = r.bytes(); err == nil {
+				name := string(b)
+				s.Name = &name
+			}
+		case num == 6 && typ == wireFixed64:
+			var ts uint64
+			if ts, err = r.fixed64(); err == nil {
+				s.Timestamp = int64Ptr(int64(ts))
+			}
+		case num == 7 && typ == wireVarint:
+			var d uint64
+			if d, err = r.varint(); err == nil {
+				s.Duration = int64Ptr(int64(d))
+			}
+		case (num == 8 || num == 9) && typ == wireBytes:
+			var b []byte
+			if b, err = r.bytes(); err != nil {
+				return true, err
+			}
+			var e *trace.Endpoint
+			if e, err = decodeEndpoint(b); num == 8 {
+				s.LocalEndpoint = e
+			} else {
+				s.RemoteEndpoint = e
+			}
+		case num == 10 && typ == wireBytes:
+			var b []byte
+			if b, err = r.bytes(); err != nil {
+				return true, err
+			}
+			var a *trace.Annotation
+			a, err = decodeAnnotation(b)
+			s.Annotations = append(s.Annotations, a)
+		case num == 11 && typ == wireBytes:
+			var b []byte
+			if b, err = r.bytes(); err != nil {
+				return true, err
+			}
+			if s.Tags == nil {
+				s.Tags = make(map[string]string)
+			}
+			err = decodeTag(b, s.Tags)
+		case (num == 12 || num == 13) && typ == wireVarint:
+			var v uint64
+			if v, err = r.varint(); err == nil {
+				b := v != 0
+				if num == 12 {
+					s.Debug = &b
+				} else {
+					s.Shared = &b
+				}
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	return s, err
+}
+
+func decodeEndpoint(data []byte) (*trace.Endpoint, error) {
+	e := &trace.Endpoint{}
+	err := readFields(data, func(r *protoReader, num uint64, typ int) (bool, error) {
+		switch {
+		case num <= 3 && typ == wireBytes: // service_name, ipv4, ipv6
+			b, err := r.bytes()
+			if err != nil {
+				return true, err
+			}
+			v := string(b)
+			if num == 1 {
+				e.ServiceName = &v
+				return true, nil
+			}
+			v = net.IP(b).String()
+			if num == 2 {
+				e.Ipv4 = &v
+			} else {
+				e.Ipv6 = &v
+			}
+		case num == 4 && typ == wireVarint:
+			p, err := r.varint()
+			if err != nil {
+				return true, err
+			}
+			port := int32(p)
+			e.Port = &port
+		default:
+			return false, nil
+		}
+		return true, nil
+	})
+	return e, err
+}
+
+func decodeAnnotation(data []byte) (*trace.Annotation, error) {
+	a := &trace.Annotation{}
+	err := readFields(data, func(r *protoReader, num uint64, typ int) (bool, error) {
+		switch {
+		case num == 1 && typ == wireFixed64:
+			ts, err := r.fixed64()
+			a.Timestamp = int64Ptr(int64(ts))
+			return true, err
+		case num == 2 && typ == wireBytes:
+			b, err := r.bytes()
+			v := string(b)
+			a.Value = &v
+			return true, err
+		}
+		return false, nil
+	})
+	return a, err
+}
+
+// decodeTag decodes a map entry of the tags of a span into tags.
+func decodeTag(data []byte, tags map[string]string) error {
+	var key, value string
+	err := readFields(data, func(r *protoReader, num uint64, typ int) (bool, error) {
+		if (num != 1 && num != 2) || typ != wireBytes {
+			return false, nil
+		}
+		b, err := r.bytes()
+		if num == 1 {
+			key = string(b)
+		} else {
+			value = string(b)
+		}
+		return true, err
+	})
+	tags[key] = value
+	return err
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -1,22 +1,58 @@
+// Package zipkinserver provides an embedded Zipkin server receiving the spans
+// sent by the tracer, to be queried by tests and local tools.
 package zipkinserver
 
 import (
-	"github.com/davecgh/go-spew/spew"
-	"github.com/mailru/easyjson"
-	traceformat "github.com/signalfx/golib/trace/format"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/mailru/easyjson"
+	"github.com/signalfx/golib/trace"
+	traceformat "github.com/signalfx/golib/trace/format"
 )
 
+// Request is a request received by the Zipkin server.
+type Request struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Header holds the headers of the request, such as X-SF-Token.
+	Header http.Header
+
+	// Spans holds the spans decoded from the request. It is empty for
+	// failed requests.
+	Spans traceformat.Trace
+
+	// StatusCode is the status code of the response, or zero when the
+	// connection was dropped.
+	StatusCode int
+}
+
 // ZipkinServer is an embedded Zipkin server
 type ZipkinServer struct {
 	server *httptest.Server
-	spans  traceformat.Trace
-	lock   sync.Mutex
+	cfg    config
+
+	lock     sync.Mutex
+	spans    traceformat.Trace
+	traceIDs []string                     // trace IDs in order of arrival
+	traces   map[string]traceformat.Trace // spans by trace ID
+	requests []Request
+	failures []Failure // failures of the next requests
+	failAll  *Failure  // failure of all requests, if set
+	changed  chan struct{}
 }
 
 // URL of the Zipkin server
@@ -29,72 +65,307 @@ func (z *ZipkinServer) Stop() {
 	z.server.Close()
 }
 
-// Reset received spans
+// Reset received spans and requests
 func (z *ZipkinServer) Reset() {
 	z.lock.Lock()
 	z.spans = nil
+	z.traceIDs = nil
+	z.traces = make(map[string]traceformat.Trace)
+	z.requests = nil
+	z.notify()
 	z.lock.Unlock()
 }
 
-// WaitForSpans waits for numSpans to become available
-func (z *ZipkinServer) WaitForSpans(t *testing.T, numSpans int) traceformat.Trace {
-	deadline := time.Now().Add(3 * time.Second)
-	var spans traceformat.Trace
+// notify wakes up the waiters. The lock must be held.
+func (z *ZipkinServer) notify() {
+	close(z.changed)
+	z.changed = make(chan struct{})
+}
+
+// Spans returns the received spans, in order of arrival.
+func (z *ZipkinServer) Spans() traceformat.Trace {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	return append(traceformat.Trace(nil), z.spans...)
+}
+
+// Traces returns the received spans grouped by trace, in order of arrival of
+// the first span of each trace.
+func (z *ZipkinServer) Traces() []traceformat.Trace {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	traces := make([]traceformat.Trace, 0, len(z.traceIDs))
+	for _, id := range z.traceIDs {
+		traces = append(traces, append(traceformat.Trace(nil), z.traces[id]...))
+	}
+	return traces
+}
+
+// Trace returns the received spans of the trace with the given hex encoded
+// ID, or nil if none was received.
+func (z *ZipkinServer) Trace(traceID string) traceformat.Trace {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	return append(traceformat.Trace(nil), z.traces[normalizeID(traceID)]...)
+}
+
+// SpansByService returns the received spans of the given local service.
+func (z *ZipkinServer) SpansByService(service string) traceformat.Trace {
+	return z.FindSpans(func(s *trace.Span) bool {
+		return s.LocalEndpoint != nil && s.LocalEndpoint.ServiceName != nil && *s.LocalEndpoint.ServiceName == service
+	})
+}
+
+// SpansByName returns the received spans with the given name.
+func (z *ZipkinServer) SpansByName(name string) traceformat.Trace {
+	return z.FindSpans(func(s *trace.Span) bool {
+		return s.Name != nil && *s.Name == name
+	})
+}
+
+// FindSpans returns the received spans for which match returns true.
+func (z *ZipkinServer) FindSpans(match func(*trace.Span) bool) traceformat.Trace {
+	var found traceformat.Trace
+	for _, s := range z.Spans() {
+		if match(s) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// Requests returns the requests received by the server, failed ones
+// included, in order of arrival.
+func (z *ZipkinServer) Requests() []Request {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	return append([]Request(nil), z.requests...)
+}
 
-	for time.Now().Before(deadline) {
+// waitFor waits until cond, called with the lock held, returns true or the
+// context is done.
+func (z *ZipkinServer) waitFor(ctx context.Context, cond func() bool) error {
+	for {
 		z.lock.Lock()
-		spans = z.spans
+		done, changed := cond(), z.changed
 		z.lock.Unlock()
-
-		switch {
-		case len(spans) == numSpans:
-			return spans
-		case len(spans) > numSpans:
-			t.Fatalf("received %d spans, expected %d: %s", len(spans), numSpans, spew.Sdump(spans))
+		if done {
 			return nil
-		default:
-			time.Sleep(250 * time.Millisecond)
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+}
+
+// WaitForSpansContext waits until at least numSpans spans were received and
+// returns the received spans. When ctx is done first, it returns the spans
+// received so far along with the error of the context.
+func (z *ZipkinServer) WaitForSpansContext(ctx context.Context, numSpans int) (traceformat.Trace, error) {
+	err := z.waitFor(ctx, func() bool { return len(z.spans) >= numSpans })
+	return z.Spans(), err
+}
+
+// WaitForTrace waits until at least numSpans spans of the trace with the given
+// hex encoded ID were received and returns the spans of the trace. When ctx
+// is done first, it returns the spans received so far along with the error
+// of the context.
+func (z *ZipkinServer) WaitForTrace(ctx context.Context, traceID string, numSpans int) (traceformat.Trace, error) {
+	id := normalizeID(traceID)
+	err := z.waitFor(ctx, func() bool { return len(z.traces[id]) >= numSpans })
+	return z.Trace(traceID), err
+}
 
-	t.Fatalf("timed out waiting for spans, received %d while expecting %d: %s", len(spans), numSpans, spew.Sdump(spans))
-	return nil
+// WaitForRequests waits until at least numRequests requests were received,
+// failed ones included, and returns the received requests. When ctx is done
+// first, it returns the requests received so far along with the error of
+// the context.
+func (z *ZipkinServer) WaitForRequests(ctx context.Context, numRequests int) ([]Request, error) {
+	err := z.waitFor(ctx, func() bool { return len(z.requests) >= numRequests })
+	return z.Requests(), err
+}
+
+// WaitForSpans waits for numSpans to become available
+func (z *ZipkinServer) WaitForSpans(t *testing.T, numSpans int) traceformat.Trace {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	spans, err := z.WaitForSpansContext(ctx, numSpans)
+	if err != nil {
+		t.Fatalf("timed out waiting for spans, received %d while expecting %d: %s", len(spans), numSpans, spew.Sdump(spans))
+		return nil
+	}
+	if len(spans) > numSpans {
+		t.Fatalf("received %d spans, expected %d: %s", len(spans), numSpans, spew.Sdump(spans))
+		return nil
+	}
+	return spans
 }
 
 // Start embedded Zipkin server
-func Start() *ZipkinServer {
-	zipkin := &ZipkinServer{}
-	zipkin.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/v1/trace" {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+func Start(opts ...Option) *ZipkinServer {
+	zipkin := New(opts...)
+	zipkin.server = httptest.NewServer(zipkin)
+	return zipkin
+}
 
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
+// New returns a Zipkin server which is not started, to be served as an
+// http.Handler. URL and Stop must not be called on it.
+func New(opts ...Option) *ZipkinServer {
+	zipkin := &ZipkinServer{
+		traces:  make(map[string]traceformat.Trace),
+		changed: make(chan struct{}),
+	}
+	for _, fn := range opts {
+		fn(&zipkin.cfg)
+	}
+	return zipkin
+}
+
+// ServeHTTP receives spans posted to /v1/trace.
+func (z *ZipkinServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/trace" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	req := Request{Method: r.Method, Header: cloneHeader(r.Header)}
+	if f, ok := z.nextFailure(); ok {
+		req.StatusCode = z.fail(w, r, f)
+		z.record(req)
+		return
+	}
+
+	spans, code, err := decodeRequest(r)
+	req.StatusCode = code
+	if err != nil {
+		z.record(req)
+		http.Error(w, err.Error(), code)
+		return
+	}
+	req.Spans = spans
+	z.record(req)
+	w.WriteHeader(code)
+}
+
+// record stores req and its spans, then calls the request hooks.
+func (z *ZipkinServer) record(req Request) {
+	z.lock.Lock()
+	z.requests = append(z.requests, req)
+	if max := z.cfg.maxTraces; max > 0 && len(z.requests) > max {
+		z.requests = append(z.requests[:0:0], z.requests[len(z.requests)-max:]...)
+	}
+	z.addSpans(req.Spans)
+	z.lock.Unlock()
+
+	for _, fn := range z.cfg.hooks {
+		fn(req)
+	}
+}
+
+// AddSpans stores spans as if they were received, such as spans previously
+// saved.
+func (z *ZipkinServer) AddSpans(spans traceformat.Trace) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	z.addSpans(spans)
+}
+
+// addSpans stores spans, dropping the oldest traces beyond the maximum
+// number of traces. The lock must be held.
+func (z *ZipkinServer) addSpans(spans traceformat.Trace) {
+	dropped := false
+	for _, s := range spans {
+		id := normalizeID(s.TraceID)
+		if _, ok := z.traces[id]; !ok {
+			if max := z.cfg.maxTraces; max > 0 && len(z.traceIDs) >= max {
+				delete(z.traces, z.traceIDs[0])
+				z.traceIDs = z.traceIDs[1:]
+				dropped = true
+			}
+			z.traceIDs = append(z.traceIDs, id)
+		}
+		z.traces[id] = append(z.traces[id], s)
+		z.spans = append(z.spans, s)
+	}
+	if dropped {
+		// keep the spans of the remaining traces
+		kept := make(map[*trace.Span]bool, len(z.spans))
+		for _, t := range z.traces {
+			for _, s := range t {
+				kept[s] = true
+			}
 		}
-		if r.Header.Get("content-type") != "application/json" {
-			w.WriteHeader(http.StatusNotAcceptable)
-			return
+		var remaining traceformat.Trace
+		for _, s := range z.spans {
+			if kept[s] {
+				remaining = append(remaining, s)
+			}
 		}
+		z.spans = remaining
+	}
+	z.notify()
+}
 
-		var trace traceformat.Trace
+// errUnsupportedType is returned when the content type of a request is not
+// supported.
+var errUnsupportedType = errors.New("unsupported content type")
 
-		if err := easyjson.UnmarshalFromReader(r.Body, &trace); err != nil {
-			_, err = io.WriteString(w, err.Error())
-			if err != nil {
-				// Probably can't successfully write the err to the response so just
-				// panic since this is used for testing.
-				panic(err)
-			}
-			w.WriteHeader(http.StatusBadRequest)
-			return
+// decodeRequest returns the spans of r, which are JSON or protobuf encoded,
+// optionally gzipped. It returns the status code of the response, along with
+// an error when the request is invalid.
+func decodeRequest(r *http.Request) (traceformat.Trace, int, error) {
+	if r.Method != http.MethodPost {
+		return nil, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, http.StatusNotAcceptable, errUnsupportedType
+	}
+
+	var body io.Reader = r.Body
+	switch enc := strings.ToLower(r.Header.Get("Content-Encoding")); enc {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
 		}
+		defer gz.Close()
+		body = gz
+	default:
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content encoding %q", enc)
+	}
 
-		zipkin.lock.Lock()
-		zipkin.spans = append(zipkin.spans, trace...)
-		zipkin.lock.Unlock()
-	}))
-	return zipkin
+	var spans traceformat.Trace
+	switch mediaType {
+	case "application/json":
+		err = easyjson.UnmarshalFromReader(body, &spans)
+	case "application/x-protobuf", "application/protobuf":
+		var data []byte
+		if data, err = ioutil.ReadAll(body); err == nil {
+			spans, err = decodeProto(data)
+		}
+	default:
+		return nil, http.StatusNotAcceptable, errUnsupportedType
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return spans, http.StatusOK, nil
+}
+
+// normalizeID returns the canonical form of a hex encoded ID.
+func normalizeID(id string) string {
+	return strings.ToLower(id)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
 }
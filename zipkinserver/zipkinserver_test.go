@@ -0,0 +1,327 @@
+package zipkinserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	traceformat "github.com/signalfx/golib/trace/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/signalfx/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/signalfx/signalfx-go-tracing/tracing"
+)
+
+const jsonSpans = `[
+	{"traceId": "00000000000000AA", "id": "0000000000000001", "name": "get", "localEndpoint": {"serviceName": "web"}},
+	{"traceId": "00000000000000bb", "id": "0000000000000002", "name": "query", "localEndpoint": {"serviceName": "db"}},
+	{"traceId": "00000000000000aa", "id": "0000000000000003", "parentId": "0000000000000001", "name": "query", "localEndpoint": {"serviceName": "db"}}
+]`
+
+func post(t *testing.T, z *ZipkinServer, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, z.URL(), bytes.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func jsonHeader() http.Header {
+	return http.Header{"Content-Type": {"application/json"}}
+}
+
+func TestQueries(t *testing.T) {
+	assert := assert.New(t)
+	z := Start()
+	defer z.Stop()
+
+	resp, err := post(t, z, []byte(jsonSpans), http.Header{
+		"Content-Type": {"application/json; charset=utf-8"},
+		"X-Sf-Token":   {"secret"},
+	})
+	require.NoError(t, err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	assert.Len(z.Spans(), 3)
+	traces := z.Traces()
+	require.Len(t, traces, 2)
+	assert.Len(traces[0], 2)
+	assert.Equal("0000000000000002", traces[1][0].ID)
+	assert.Len(z.Trace("00000000000000AA"), 2)
+	assert.Empty(z.Trace("00000000000000cc"))
+	assert.Len(z.SpansByService("db"), 2)
+	assert.Len(z.SpansByName("get"), 1)
+
+	requests := z.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal("secret", requests[0].Header.Get("X-SF-Token"))
+	assert.Equal(http.MethodPost, requests[0].Method)
+	assert.Equal(http.StatusOK, requests[0].StatusCode)
+	assert.Len(requests[0].Spans, 3)
+
+	z.Reset()
+	assert.Empty(z.Spans())
+	assert.Empty(z.Traces())
+	assert.Empty(z.Requests())
+}
+
+func TestDecoding(t *testing.T) {
+	z := Start()
+	defer z.Stop()
+
+	t.Run("gzip", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(jsonSpans))
+		gz.Close()
+		header := jsonHeader()
+		header.Set("Content-Encoding", "gzip")
+		resp, err := post(t, z, buf.Bytes(), header)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, z.Spans(), 3)
+		z.Reset()
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		assert := assert.New(t)
+		resp, err := post(t, z, protoSpans(), http.Header{"Content-Type": {"application/x-protobuf"}})
+		require.NoError(t, err)
+		assert.Equal(http.StatusOK, resp.StatusCode)
+
+		spans := z.Spans()
+		require.Len(t, spans, 1)
+		s := spans[0]
+		assert.Equal("000000000000000a000000000000000b", s.TraceID)
+		assert.Equal("0000000000000002", s.ID)
+		assert.Equal("0000000000000001", *s.ParentID)
+		assert.Equal("SERVER", *s.Kind)
+		assert.Equal("get", *s.Name)
+		assert.Equal(int64(1000), *s.Timestamp)
+		assert.Equal(int64(20), *s.Duration)
+		assert.Equal("web", *s.LocalEndpoint.ServiceName)
+		assert.Equal("10.0.0.1", *s.LocalEndpoint.Ipv4)
+		assert.Equal(int32(8080), *s.LocalEndpoint.Port)
+		require.Len(t, s.Annotations, 1)
+		assert.Equal(int64(1010), *s.Annotations[0].Timestamp)
+		assert.Equal("event", *s.Annotations[0].Value)
+		assert.Equal(map[string]string{"http.method": "GET"}, s.Tags)
+		assert.True(*s.Shared)
+		z.Reset()
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, tt := range map[string]struct {
+			body   string
+			header http.Header
+			code   int
+		}{
+			"json":     {"[{", jsonHeader(), http.StatusBadRequest},
+			"protobuf": {"\x0a\x05\x01", http.Header{"Content-Type": {"application/x-protobuf"}}, http.StatusBadRequest},
+			"type":     {jsonSpans, http.Header{"Content-Type": {"text/plain"}}, http.StatusNotAcceptable},
+			"encoding": {jsonSpans, http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"br"}}, http.StatusUnsupportedMediaType},
+		} {
+			t.Run(name, func(t *testing.T) {
+				resp, err := post(t, z, []byte(tt.body), tt.header)
+				require.NoError(t, err)
+				assert.Equal(t, tt.code, resp.StatusCode)
+			})
+		}
+		assert.Empty(t, z.Spans())
+		assert.Len(t, z.Requests(), 4)
+	})
+}
+
+func TestFailures(t *testing.T) {
+	z := Start()
+	defer z.Stop()
+
+	z.FailNext(1, Failure{})
+	z.FailNext(1, Failure{StatusCode: http.StatusTooManyRequests, Latency: 10 * time.Millisecond})
+	z.FailNext(1, Failure{Drop: true})
+
+	resp, err := post(t, z, []byte(jsonSpans), jsonHeader())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	start := time.Now()
+	resp, err = post(t, z, []byte(jsonSpans), jsonHeader())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	_, err = post(t, z, []byte(jsonSpans), jsonHeader())
+	assert.Error(t, err)
+
+	resp, err = post(t, z, []byte(jsonSpans), jsonHeader())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	z.FailAll(Failure{StatusCode: http.StatusInternalServerError})
+	for i := 0; i < 2; i++ {
+		resp, err = post(t, z, []byte(jsonSpans), jsonHeader())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	z.ClearFailures()
+	resp, err = post(t, z, []byte(jsonSpans), jsonHeader())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var codes []int
+	for _, r := range z.Requests() {
+		codes = append(codes, r.StatusCode)
+	}
+	assert.Equal(t, []int{503, 429, 0, 200, 500, 500, 200}, codes)
+	assert.Len(t, z.Spans(), 6)
+}
+
+func TestWaitContext(t *testing.T) {
+	z := Start()
+	defer z.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	spans, err := z.WaitForSpansContext(ctx, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Empty(t, spans)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		post(t, z, []byte(jsonSpans), jsonHeader())
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	trace, err := z.WaitForTrace(ctx, "00000000000000aa", 2)
+	assert.NoError(t, err)
+	assert.Len(t, trace, 2)
+	spans, err = z.WaitForSpansContext(ctx, 2)
+	assert.NoError(t, err)
+	assert.Len(t, spans, 3)
+	requests, err := z.WaitForRequests(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+}
+
+func TestTracer(t *testing.T) {
+	z := Start()
+	defer z.Stop()
+
+	tracing.Start(
+		tracing.WithEndpointURL(z.URL()),
+		tracing.WithServiceName("zipkinserver-test"),
+		tracing.WithAccessToken("token"),
+	)
+	defer tracing.Stop()
+
+	root := tracer.StartSpan("root")
+	tracer.StartSpan("child", tracer.ChildOf(root.Context())).Finish()
+	root.Finish()
+	tracer.ForceFlush()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	spans, err := z.WaitForSpansContext(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, z.Trace(spans[0].TraceID), 2)
+	assert.Len(t, z.SpansByService("zipkinserver-test"), 2)
+	assert.Equal(t, "token", z.Requests()[0].Header.Get("X-SF-Token"))
+}
+
+// protoSpans returns a zipkin.proto3 ListOfSpans message holding one span.
+func protoSpans() []byte {
+	endpoint := concat(
+		protoBytes(1, []byte("web")),
+		protoBytes(2, []byte{10, 0, 0, 1}),
+		protoVarint(4, 8080),
+	)
+	annotation := concat(protoFixed64(1, 1010), protoBytes(2, []byte("event")))
+	tag := concat(protoBytes(1, []byte("http.method")), protoBytes(2, []byte("GET")))
+	span := concat(
+		protoBytes(1, []byte{0, 0, 0, 0, 0, 0, 0, 10, 0, 0, 0, 0, 0, 0, 0, 11}),
+		protoBytes(2, []byte{0, 0, 0, 0, 0, 0, 0, 1}),
+		protoBytes(3, []byte{0, 0, 0, 0, 0, 0, 0, 2}),
+		protoVarint(4, 2),
+		protoBytes(5, []byte("get")),
+		protoFixed64(6, 1000),
+		protoVarint(7, 20),
+		protoBytes(8, endpoint),
+		protoBytes(10, annotation),
+		protoBytes(11, tag),
+		protoVarint(13, 1),
+		protoBytes(99, []byte("unknown")),
+	)
+	return protoBytes(1, span)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func uvarint(v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, v)]
+}
+
+func protoKey(num, typ uint64) []byte {
+	return uvarint(num<<3 | typ)
+}
+
+func protoVarint(num, v uint64) []byte {
+	return concat(protoKey(num, wireVarint), uvarint(v))
+}
+
+func protoFixed64(num, v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, v)
+	return concat(protoKey(num, wireFixed64), buf)
+}
+
+func protoBytes(num uint64, b []byte) []byte {
+	return concat(protoKey(num, wireBytes), uvarint(uint64(len(b))), b)
+}
+
+func TestOptions(t *testing.T) {
+	assert := assert.New(t)
+	var hooked []Request
+	z := New(WithMaxTraces(1), WithRequestHook(func(r Request) {
+		hooked = append(hooked, r)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodPost, "/v1/trace", strings.NewReader(jsonSpans))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		z.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+	}
+
+	// only the last trace and request are kept
+	traces := z.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal("00000000000000aa", traces[0][0].TraceID)
+	assert.Equal("0000000000000003", traces[0][0].ID)
+	assert.Len(z.Spans(), 1)
+	assert.Len(z.Requests(), 1)
+	assert.Len(hooked, 2)
+	assert.Len(hooked[1].Spans, 3)
+
+	z.AddSpans(traceformat.Trace{{TraceID: "00000000000000cc", ID: "0000000000000004"}})
+	traces = z.Traces()
+	require.Len(t, traces, 1)
+	assert.Equal("00000000000000cc", traces[0][0].TraceID)
+	assert.Len(z.Spans(), 1)
+}